@@ -0,0 +1,230 @@
+package main
+
+import (
+	"cmp"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/store"
+)
+
+// apiTokenEnv is the environment variable of the bearer token the API requires, when set.
+const apiTokenEnv = "VIGIL_API_TOKEN"
+
+// maxScanRequest is the largest body POST /scans reads.
+const maxScanRequest = 1 << 16
+
+// scanRequest is the body of POST /scans.
+type scanRequest struct {
+	Target string `json:"target"`
+}
+
+// scanDetail is the response of GET /scans/{id}: the scan and, once it is stored, the stats of its SLOs.
+type scanDetail struct {
+	*scan
+	SLOs []store.SLOStats `json:"slos,omitempty"`
+}
+
+// sloHistory is the response of GET /slos/{name}/history.
+type sloHistory struct {
+	Name  string           `json:"name"`
+	Since time.Time        `json:"since"`
+	Stats []store.SLOStats `json:"stats"`
+}
+
+// apiError is the body of the error responses of the API.
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// handleAPI registers the JSON API on mux, behind the bearer token of VIGIL_API_TOKEN when it is set.
+func (s *server) handleAPI(mux *http.ServeMux) {
+	token := os.Getenv(apiTokenEnv)
+	auth := func(h http.HandlerFunc) http.HandlerFunc {
+		if token == "" {
+			return h
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeAPIError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("POST /scans", auth(s.handleStartScan))
+	mux.HandleFunc("GET /scans/{id}", auth(s.handleGetScan))
+	mux.HandleFunc("GET /scans/{id}/report", auth(s.handleScanReport))
+	mux.HandleFunc("GET /slos/{name}/history", auth(s.handleHistory))
+}
+
+// handleStartScan starts a scan of a target and returns it without waiting for it, to be polled with GET /scans/{id}.
+func (s *server) handleStartScan(w http.ResponseWriter, r *http.Request) {
+	var req scanRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxScanRequest)).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	ts := s.target(req.Target)
+	if ts == nil {
+		writeAPIError(w, http.StatusNotFound, "unknown target: "+strconv.Quote(req.Target))
+		return
+	}
+	if s.ctx.Err() != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, "vigil is stopping")
+		return
+	}
+	sc, running := s.start(ts, triggerAPI)
+	if sc == nil {
+		w.Header().Set("Location", "/scans/"+strconv.FormatInt(running.ID, 10))
+		writeAPIError(w, http.StatusConflict, "target "+ts.target.Name+" is already being scanned by scan "+strconv.FormatInt(running.ID, 10))
+		return
+	}
+	s.wg.Go(func() { s.scan(s.ctx, ts, sc) })
+
+	s.mu.Lock()
+	resp := *sc
+	s.mu.Unlock()
+	w.Header().Set("Location", "/scans/"+strconv.FormatInt(sc.ID, 10))
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+func (s *server) handleGetScan(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.lookupScan(w, r)
+	if !ok {
+		return
+	}
+	resp := scanDetail{scan: &sc}
+	if sc.RunID != 0 {
+		stats, err := s.runStats(r, sc.RunID)
+		if err != nil {
+			log.Printf("%v", err)
+			writeAPIError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.SLOs = stats
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleScanReport serves the report file of a scan.
+func (s *server) handleScanReport(w http.ResponseWriter, r *http.Request) {
+	sc, ok := s.lookupScan(w, r)
+	if !ok {
+		return
+	}
+	if sc.Status == scanRunning {
+		writeAPIError(w, http.StatusNotFound, "the scan is still running")
+		return
+	}
+	if _, err := os.Stat(sc.report); err != nil {
+		writeAPIError(w, http.StatusNotFound, "the scan wrote no report")
+		return
+	}
+	http.ServeFile(w, r, sc.report)
+}
+
+// handleHistory returns the stats of an SLO, by key or display name, in every stored run since ?since=, a date or an
+// RFC 3339 time. All the runs when it is not given. Keys with slashes are passed escaped, as %2F.
+func (s *server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = parseSince(v); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	st, err := store.Open(*storePath)
+	if err != nil {
+		log.Printf("%v", err)
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer func() { _ = st.Close() }()
+	stats, err := st.History(r.Context(), name, since)
+	if err != nil {
+		log.Printf("%v", err)
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(stats) == 0 {
+		writeAPIError(w, http.StatusNotFound, "no stored run has SLO "+strconv.Quote(name))
+		return
+	}
+	writeJSON(w, http.StatusOK, sloHistory{Name: name, Since: since, Stats: stats})
+}
+
+// lookupScan returns a copy of the scan of the {id} of the request, writing the error response when there is none.
+func (s *server) lookupScan(w http.ResponseWriter, r *http.Request) (scan, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid scan ID: "+strconv.Quote(r.PathValue("id")))
+		return scan{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc, ok := s.scans[id]
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "unknown scan: "+strconv.FormatInt(id, 10)+", only the last "+strconv.Itoa(maxScans)+" scans are kept")
+		return scan{}, false
+	}
+	return *sc, true
+}
+
+// runStats returns the stats of the SLOs of a stored run, sorted by key.
+func (s *server) runStats(r *http.Request, runID int64) ([]store.SLOStats, error) {
+	st, err := store.Open(*storePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = st.Close() }()
+	byKey, err := st.Stats(r.Context(), runID)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]store.SLOStats, 0, len(byKey))
+	for _, v := range byKey {
+		stats = append(stats, v)
+	}
+	slices.SortFunc(stats, func(a, b store.SLOStats) int { return cmp.Compare(a.Key, b.Key) })
+	return stats, nil
+}
+
+// parseSince parses the since parameter of GET /slos/{name}/history: a date, in UTC, or an RFC 3339 time.
+func parseSince(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("invalid since: " + strconv.Quote(v) + ", want a date like 2006-01-02 or an RFC 3339 time")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("Failed to write the API response: %v", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, apiError{Error: msg})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+// rollbackEntry records the goal of an SLO before vigil apply changed it.
+type rollbackEntry struct {
+	Provider     model.CloudProvider `json:"provider"`
+	Key          string              `json:"key"`
+	DisplayName  string              `json:"displayName"`
+	Category     model.Category      `json:"category"`
+	PreviousGoal float64             `json:"previousGoal"`
+	Goal         float64             `json:"goal"`
+	// Applied is false for SLOs not updated yet, or whose update failed with Error.
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// rollbackFile is the --rollback-file of vigil apply, written before any goal is changed and after each update.
+type rollbackFile struct {
+	StartedAt time.Time       `json:"startedAt"`
+	SLOs      []rollbackEntry `json:"slos"`
+}
+
+// goalUpdate is a recommended goal vigil apply sets through the provider of the SLO.
+type goalUpdate struct {
+	slo     *model.SLO
+	client  Vigil
+	updater provider.GoalUpdater
+	data    *model.SLOData
+}
+
+// runApply sets the recommended goals of the flagged SLOs through their providers. Without --approve it only prints
+// them; with it, it asks for confirmation on stdin and records the previous goals in the rollback file first.
+func runApply(ctx context.Context, slos []*model.SLO, sloClients map[*model.SLO]Vigil, sloData map[string]*model.SLOData) int {
+	var updates []goalUpdate
+	unsupported := make(map[model.CloudProvider]int)
+	for _, slo := range slos {
+		v, ok := sloData[slo.Name]
+		if !ok || v.TargetSLO == 0 {
+			continue
+		}
+		client := sloClients[slo]
+		updater, ok := client.(provider.GoalUpdater)
+		if !ok {
+			unsupported[client.GetProvider()]++
+			continue
+		}
+		updates = append(updates, goalUpdate{slo: slo, client: client, updater: updater, data: v})
+	}
+	for _, p := range slices.Sorted(maps.Keys(unsupported)) {
+		infof("%s cannot update goals: %d recommended goals left unchanged", p, unsupported[p])
+	}
+	if len(updates) == 0 {
+		infof("No goals to update")
+		return exitOK
+	}
+
+	printGoalUpdates(os.Stdout, updates)
+	if !*approve {
+		infof("Run again with --approve to update the goals of these %d SLOs", len(updates))
+		return exitOK
+	}
+	if !confirm(os.Stdin, os.Stderr, fmt.Sprintf("Update the goals of %d SLOs? [y/N] ", len(updates))) {
+		infof("No goals updated")
+		return exitOK
+	}
+
+	path := *rollbackPath
+	if path == "" {
+		path = fmt.Sprintf("slo_rollback_%s.json", time.Now().Format("20060102_150405"))
+	}
+	if _, err := os.Stat(path); err == nil && !*force {
+		log.Panicf("%s already exists. use --force to overwrite it", path)
+	}
+
+	rollback := rollbackFile{StartedAt: time.Now()}
+	for _, u := range updates {
+		rollback.SLOs = append(rollback.SLOs, rollbackEntry{
+			Provider:     u.client.GetProvider(),
+			Key:          u.slo.Name,
+			DisplayName:  u.slo.DisplayName,
+			Category:     u.data.Category,
+			PreviousGoal: u.slo.Goal,
+			Goal:         u.data.TargetSLO,
+		})
+	}
+	// The previous goals are on disk before the first update, so an interrupted run can still be rolled back.
+	writeRollbackFile(path, rollback)
+
+	failed := 0
+	for i, u := range updates {
+		if ctx.Err() != nil {
+			log.Printf("Run cancelled (%v): %d of %d goals not updated", context.Cause(ctx), len(updates)-i, len(updates))
+			failed += len(updates) - i
+			break
+		}
+		if err := u.updater.UpdateGoal(ctx, u.slo, u.data.TargetSLO); err != nil {
+			log.Printf("Failed to update the goal of %s: %v", u.slo.DisplayName, err)
+			rollback.SLOs[i].Error = err.Error()
+			failed++
+		} else {
+			rollback.SLOs[i].Applied = true
+			infof("Updated the goal of %s from %s to %s", u.slo.DisplayName, formatGoalPercent(u.slo.Goal), formatGoalPercent(u.data.TargetSLO))
+		}
+		writeRollbackFile(path, rollback)
+	}
+
+	infof("Updated %d of %d goals. previous goals are recorded in %s", len(updates)-failed, len(updates), path)
+	if failed > 0 {
+		return exitError
+	}
+	return exitOK
+}
+
+// printGoalUpdates prints the goals vigil apply would change as an aligned table.
+func printGoalUpdates(w io.Writer, updates []goalUpdate) {
+	rows := [][]string{{"PROVIDER", "PROJECT", "SLO", "CATEGORY", "GOAL", "NEW GOAL"}}
+	for _, u := range updates {
+		rows = append(rows, []string{
+			string(u.client.GetProvider()), u.slo.Project, u.slo.DisplayName, string(u.data.Category),
+			formatGoalPercent(u.slo.Goal), formatGoalPercent(u.data.TargetSLO),
+		})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(cell + strings.Repeat(" ", widths[i]-displayWidth(cell)))
+		}
+		fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+	}
+}
+
+// confirm asks a yes/no question on out and reports whether the answer read from in is yes.
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprint(out, question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// writeRollbackFile writes the rollback file of vigil apply as indented JSON.
+func writeRollbackFile(path string, rollback rollbackFile) {
+	data, err := json.MarshalIndent(rollback, "", "  ")
+	if err != nil {
+		log.Panicf("Failed to encode the rollback file: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		log.Panicf("Failed to write the rollback file %s: %v", path, err)
+	}
+}
+
+// formatGoalPercent renders a goal as a percentage, e.g. 99.95%, rounded to hide the float error of the conversion.
+func formatGoalPercent(goal float64) string {
+	return fmt.Sprintf("%g%%", math.Round(goal*1e8)/1e6)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil"
+	"github.com/rluisr/vigil/pkg/vigil/analysis"
+)
+
+// findingCategories are the categories that call for action, in report order.
+var findingCategories = []model.Category{model.CategoryBurning, model.CategoryLax, model.CategoryNoData}
+
+// categoryColors are the fill and font colors of each category, after the bad, neutral and good cell styles of Excel.
+var categoryColors = map[model.Category][2]string{
+	model.CategoryBurning: {"FFC7CE", "9C0006"},
+	model.CategoryLax:     {"FFEB9C", "9C5700"},
+	model.CategoryHealthy: {"C6EFCE", "006100"},
+	model.CategoryNoData:  {"D9D9D9", "595959"},
+}
+
+// checkNames returns the checks of --checks.
+func checkNames() []string {
+	var names []string
+	for _, name := range strings.Split(*checks, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// analysisChecks returns the built-in checks opts enables that --checks runs.
+func analysisChecks(opts vigil.Options) []analysis.Check {
+	names := checkNames()
+	return slices.DeleteFunc(vigil.DefaultChecks(opts), func(c analysis.Check) bool {
+		return !slices.Contains(names, c.Name())
+	})
+}
+
+// ranCheck reports whether the audit ran the check called name.
+func ranCheck(name string) bool {
+	return slices.ContainsFunc(auditOptions.Checks, func(c analysis.Check) bool { return c.Name() == name })
+}
+
+// burning reports whether the audit ran the negative-budget check and the error budget of v was negative for at
+// least its negative budget fraction of the window.
+func burning(v *model.SLOData) bool {
+	return ranCheck(analysis.NegativeBudget) && analysis.Burning(v)
+}
+
+// fastBurn reports whether the audit ran the burn-rate check and v burned its budget faster than its burn rate
+// threshold.
+func fastBurn(v *model.SLOData) bool {
+	return ranCheck(analysis.BurnRate) && analysis.FastBurn(v, auditOptions.BurnRateThreshold)
+}
+
+// trendingToExhaustion reports whether the audit flagged degrading SLOs with the trend check and the budget of v,
+// still positive, is projected to be exhausted within one more window.
+func trendingToExhaustion(v *model.SLOData) bool {
+	return auditOptions.FlagDegrading && ranCheck(analysis.Trend) && analysis.TrendingToExhaustion(v)
+}
+
+// forecastExhaustion reports whether the audit flagged exhausting SLOs with the forecast check and the budget of v is
+// projected to hit zero within the next window.
+func forecastExhaustion(v *model.SLOData) bool {
+	return auditOptions.FlagExhaustion && ranCheck(analysis.Forecast) && v.ExhaustsWithinWindow
+}
+
+// categoryLabel returns the localized name of a category.
+func categoryLabel(c model.Category, msgs *i18n.Messages) string {
+	switch c {
+	case model.CategoryLax:
+		return msgs.CategoryLax
+	case model.CategoryBurning:
+		return msgs.CategoryBurning
+	case model.CategoryNoData:
+		return msgs.CategoryNoData
+	default:
+		return msgs.CategoryHealthy
+	}
+}
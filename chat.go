@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/notify"
+)
+
+// notifiers returns the chat channels given by --teams-webhook and --google-chat-webhook.
+func notifiers() []notify.Notifier {
+	var n []notify.Notifier
+	if *teamsWebhook != "" {
+		n = append(n, notify.NewTeams(*teamsWebhook))
+	}
+	if *googleChatWebhook != "" {
+		n = append(n, notify.NewGoogleChat(*googleChatWebhook))
+	}
+	return n
+}
+
+// validateChatWebhook checks the webhook of a chat flag is an https URL.
+func validateChatWebhook(name, webhook string) error {
+	u, err := url.Parse(webhook)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid --%s: use the https URL of the incoming webhook", name)
+	}
+	return nil
+}
+
+// chatMessage is the summary of the run posted to chat channels, the same as the body of --email-to.
+func chatMessage(data map[string]*model.SLOData, msgs *i18n.Messages) notify.Message {
+	summary := summarize(data, time.Now())
+	msg := notify.Message{
+		Title:   fmt.Sprintf("%s (%s)", msgs.ReportTitle, reportTarget()),
+		Summary: fmt.Sprintf(msgs.Summary, summary.Flagged, summary.Scanned),
+		Facts:   summary.rows(msgs),
+		Footer:  msgs.GeneratedBy,
+	}
+	if worst := worstOffenders(data, *top); len(worst) > 0 {
+		msg.ListTitle = fmt.Sprintf(msgs.SectionWorstOffenders, len(worst))
+		for _, v := range worst {
+			msg.List = append(msg.List, notify.Link{
+				Text: fmt.Sprintf("%s (%s, %s %.0f)", v.DisplayName, categoryLabel(v.Category, msgs), msgs.HeaderHealthScore, v.HealthScore),
+				URL:  v.ConsoleURL,
+			})
+		}
+	}
+	return msg
+}
+
+// notifyChats posts the summary of the run to every chat channel. Every channel is posted to even when some fail,
+// and the run fails afterwards.
+func notifyChats(ctx context.Context, data map[string]*model.SLOData, msgs *i18n.Messages) {
+	msg := chatMessage(data, msgs)
+	failed := 0
+	for _, n := range notifiers() {
+		if err := n.Notify(ctx, msg); err != nil {
+			log.Printf("%v", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Panicf("Failed to post the summary to %d chat channels", failed)
+	}
+	infof("Posted the summary to the chat channels")
+}
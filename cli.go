@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/provider"
+)
+
+// Subcommands. Running vigil without one scans, as it always has.
+const (
+	cmdScan       = "scan"
+	cmdTUI        = "tui"
+	cmdCoverage   = "coverage"
+	cmdApply      = "apply"
+	cmdDiff       = "diff"
+	cmdServe      = "serve"
+	cmdCompletion = "completion"
+)
+
+// flagValues completes the values of flags that take one of a fixed set.
+var flagValues = map[string]func() []string{
+	"cloud": func() []string {
+		var names []string
+		for _, p := range provider.Names() {
+			names = append(names, string(p))
+		}
+		return names
+	},
+	"format": func() []string {
+		return []string{formatXLSX, formatCSV, formatJSON, formatHTML, formatPDF, formatSARIF, formatTable, formatGitHub}
+	},
+	"lang":            func() []string { return []string{string(i18n.LangEN), string(i18n.LangJA)} },
+	"sort":            func() []string { return []string{sortName, sortMinBudget, sortAvgBudget} },
+	"group-by":        func() []string { return []string{groupByProject, groupByTeam} },
+	"page":            func() []string { return []string{pagePagerDuty, pageOpsgenie} },
+	"issues":          func() []string { return []string{issuesGitHub, issuesJira} },
+	"openslo-backend": func() []string { return []string{"prometheus"} },
+	"gcp-aligner":     func() []string { return []string{"mean", "min"} },
+}
+
+// fileFlags and dirFlags complete file and directory paths.
+var (
+	fileFlags = []string{"output", "config", "report-spec", "provider-plugin", "gcp-credentials-file", "rollback-file", "store", "record", "replay"}
+	dirFlags  = []string{"source-dir", "path", "export-goals", "reports-dir"}
+)
+
+const usageHeader = `Vigil flags SLOs whose objective is likely wrong by replaying their error budget over a window.
+
+Usage:
+  vigil [scan] [flags]              scan the SLOs and write a report
+  vigil tui [flags]                 scan the SLOs and browse them in the terminal
+  vigil coverage [flags]            report services without SLOs or missing an availability or latency SLO
+  vigil apply [--approve] [flags]   scan the SLOs and update the goals of flagged ones to the recommended goals
+  vigil diff [flags] [run-a [run-b]]
+                                    report the SLOs that changed between two stored runs or JSON reports
+  vigil serve --config <file> --store <file> [flags]
+                                    scan the targets of the config on their schedules and serve the reports
+  vigil completion bash|zsh|fish    print a shell completion script
+
+An SLO is flagged when either holds over --window:
+  too lax   the remaining error budget never dropped below --error-budget-threshold.
+            with 0.9, an SLO that never spent more than 10% of its budget is flagged,
+            since users were never close to noticing: the objective can likely be tightened.
+  burning   the error budget was negative for at least --negative-budget-fraction of the window,
+            the objective is likely stricter than what the service delivers.
+
+Flags:
+`
+
+const usageExamples = `
+Examples:
+  # SLOs of a GCP project that never used more than 1% of their budget in 30 days
+  vigil --cloud gcp --gcp-project my-project --error-budget-threshold 0.99 --window 720h
+
+  # one team's Datadog SLOs as a table in the terminal
+  vigil --cloud datadog --include team=payments --format table
+
+  # fail a CI job when any SLO is flagged, annotating the Terraform that defines it
+  vigil --cloud gcp --gcp-project my-project --format github --source-dir terraform --fail-on-flag
+
+  # GCP services without SLOs, or missing an availability or latency SLO
+  vigil coverage --cloud gcp --gcp-project my-project
+
+  # set the recommended goals of one service's GCP SLOs, after confirmation
+  vigil apply --approve --cloud gcp --gcp-project my-project --include checkout
+
+  # what changed since the previous run stored in vigil.db
+  vigil diff --store vigil.db --format table
+
+  # scan the targets of vigil.yaml on their schedules, serving the reports on :8080
+  vigil serve --config vigil.yaml --store vigil.db
+
+  # enable completions for the current bash session
+  source <(vigil completion bash)
+`
+
+// usage prints the help for -h and flag errors.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprint(out, usageHeader)
+	flag.PrintDefaults()
+	fmt.Fprint(out, usageExamples)
+}
+
+// runCompletion prints the completion script of a shell.
+func runCompletion(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: vigil completion bash|zsh|fish")
+		return exitError
+	}
+
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		writeZshCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported shell %q. use bash, zsh or fish\n", args[0])
+		return exitError
+	}
+	return exitOK
+}
+
+func writeBashCompletion(w io.Writer) {
+	var names, cases []string
+	flag.VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+		switch {
+		case flagValues[f.Name] != nil:
+			cases = append(cases, fmt.Sprintf("    --%s|-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;", f.Name, f.Name, strings.Join(flagValues[f.Name](), " ")))
+		case slices.Contains(dirFlags, f.Name):
+			cases = append(cases, fmt.Sprintf("    --%s|-%s) COMPREPLY=($(compgen -d -- \"$cur\")); return ;;", f.Name, f.Name))
+		case slices.Contains(fileFlags, f.Name):
+			cases = append(cases, fmt.Sprintf("    --%s|-%s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;", f.Name, f.Name))
+		case !isBoolFlag(f):
+			cases = append(cases, fmt.Sprintf("    --%s|-%s) return ;;", f.Name, f.Name))
+		}
+	})
+
+	fmt.Fprintf(w, `# bash completion for vigil. load with: source <(vigil completion bash)
+_vigil() {
+  local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+  case "$prev" in
+%s
+  esac
+  if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+    COMPREPLY=($(compgen -W "%s %s %s %s %s %s %s" -- "$cur"))
+    return
+  fi
+  if [[ ${COMP_WORDS[1]} == %s ]]; then
+    COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+    return
+  fi
+  COMPREPLY=($(compgen -W %q -- "$cur"))
+}
+complete -F _vigil vigil
+`, strings.Join(cases, "\n"), cmdScan, cmdTUI, cmdCoverage, cmdApply, cmdDiff, cmdServe, cmdCompletion, cmdCompletion, strings.Join(names, " "))
+}
+
+func writeZshCompletion(w io.Writer) {
+	var specs []string
+	flag.VisitAll(func(f *flag.Flag) {
+		spec := fmt.Sprintf("'--%s[%s]", f.Name, zshEscape(firstSentence(f.Usage)))
+		switch {
+		case flagValues[f.Name] != nil:
+			spec += fmt.Sprintf(":%s:(%s)", f.Name, strings.Join(flagValues[f.Name](), " "))
+		case slices.Contains(dirFlags, f.Name):
+			spec += ":directory:_directories"
+		case slices.Contains(fileFlags, f.Name):
+			spec += ":file:_files"
+		case !isBoolFlag(f):
+			spec += ":" + f.Name + ": "
+		}
+		specs = append(specs, "    "+spec+"'")
+	})
+
+	fmt.Fprintf(w, `#compdef vigil
+# zsh completion for vigil. save as _vigil in a directory of $fpath, or load with: source <(vigil completion zsh)
+_vigil() {
+  if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then
+    _values 'command' '%s[scan the SLOs and write a report]' '%s[scan the SLOs and browse them in the terminal]' '%s[report services missing SLOs]' '%s[update the goals of flagged SLOs]' '%s[report the SLOs that changed between two runs]' '%s[scan the targets of the config on their schedules]' '%s[print a shell completion script]'
+    return
+  fi
+  if [[ $words[2] == %s ]]; then
+    _values 'shell' bash zsh fish
+    return
+  fi
+  _arguments \
+%s
+}
+compdef _vigil vigil
+`, cmdScan, cmdTUI, cmdCoverage, cmdApply, cmdDiff, cmdServe, cmdCompletion, cmdCompletion, strings.Join(specs, " \\\n"))
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for vigil. load with: vigil completion fish | source")
+	fmt.Fprintf(w, "complete -c vigil -f -n __fish_use_subcommand -a %s -d 'scan the SLOs and write a report'\n", cmdScan)
+	fmt.Fprintf(w, "complete -c vigil -f -n __fish_use_subcommand -a %s -d 'scan the SLOs and browse them in the terminal'\n", cmdTUI)
+	fmt.Fprintf(w, "complete -c vigil -f -n __fish_use_subcommand -a %s -d 'report services missing SLOs'\n", cmdCoverage)
+	fmt.Fprintf(w, "complete -c vigil -f -n __fish_use_subcommand -a %s -d 'update the goals of flagged SLOs'\n", cmdApply)
+	fmt.Fprintf(w, "complete -c vigil -f -n __fish_use_subcommand -a %s -d 'report the SLOs that changed between two runs'\n", cmdDiff)
+	fmt.Fprintf(w, "complete -c vigil -f -n __fish_use_subcommand -a %s -d 'scan the targets of the config on their schedules'\n", cmdServe)
+	fmt.Fprintf(w, "complete -c vigil -f -n __fish_use_subcommand -a %s -d 'print a shell completion script'\n", cmdCompletion)
+	fmt.Fprintf(w, "complete -c vigil -f -n '__fish_seen_subcommand_from %s' -a 'bash zsh fish'\n", cmdCompletion)
+	flag.VisitAll(func(f *flag.Flag) {
+		line := fmt.Sprintf("complete -c vigil -l %s -d '%s'", f.Name, fishEscape(firstSentence(f.Usage)))
+		switch {
+		case flagValues[f.Name] != nil:
+			line += fmt.Sprintf(" -x -a '%s'", strings.Join(flagValues[f.Name](), " "))
+		case slices.Contains(dirFlags, f.Name):
+			line += " -x -a '(__fish_complete_directories)'"
+		case slices.Contains(fileFlags, f.Name):
+			line += " -r -F"
+		case !isBoolFlag(f):
+			line += " -x"
+		}
+		fmt.Fprintln(w, line)
+	})
+}
+
+// firstSentence returns the first sentence of a flag usage for completion descriptions.
+func firstSentence(usage string) string {
+	usage = strings.ReplaceAll(usage, "`", "")
+	if i := strings.Index(usage, ". "); i >= 0 {
+		return usage[:i]
+	}
+	return strings.TrimSuffix(usage, ".")
+}
+
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func fishEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s)
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
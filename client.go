@@ -1,13 +1,6 @@
 package main
 
-import (
-	"context"
+import "github.com/rluisr/vigil/provider"
 
-	"github.com/rluisr/vigil/model"
-)
-
-type Vigil interface {
-	GetProvider() model.CloudProvider
-	GetSLOs(ctx context.Context) ([]*model.SLO, error)
-	GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (good string, total string, points []float64, err error)
-}
+// Vigil is the cloud provider abstraction implemented by every registered provider.
+type Vigil = provider.Provider
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rluisr/vigil/cron"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil"
+)
+
+// config is the --config file.
+type config struct {
+	// Flags are default values of command line flags, such as gcp-endpoint. Flags given on the command line win.
+	Flags     map[string]string `yaml:"flags"`
+	Overrides []*override       `yaml:"overrides"`
+	// Targets are the scans of vigil serve, each on a schedule of its own.
+	Targets []*target `yaml:"targets"`
+}
+
+// target is a scan run by vigil serve on a cron schedule, e.g. one GCP project or Datadog organization.
+type target struct {
+	// Name identifies the target in the history store, the reports directory and the URLs of the server.
+	Name     string `yaml:"name"`
+	Schedule string `yaml:"schedule"`
+	// Flags are the flags of its scans, on top of the flags of the config and of vigil serve.
+	Flags map[string]string `yaml:"flags"`
+
+	schedule *cron.Schedule
+}
+
+// override changes the error budget threshold, window and negative budget fraction of the SLOs matching a pattern.
+// Settings left out keep the values of --error-budget-threshold, --window and --negative-budget-fraction.
+type override struct {
+	// Match is an --include style pattern: a glob, a /regexp/ or a key=glob label selector.
+	Match                  string        `yaml:"match"`
+	ErrorBudgetThreshold   float64       `yaml:"errorBudgetThreshold"`
+	Window                 time.Duration `yaml:"window"`
+	NegativeBudgetFraction float64       `yaml:"negativeBudgetFraction"`
+
+	matcher matcher
+}
+
+// loadConfig reads and validates the --config file. An empty path yields an empty config.
+func loadConfig(path string) (*config, error) {
+	cfg := &config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	for name := range cfg.Flags {
+		if flag.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown flag %q in %s", name, path)
+		}
+	}
+
+	names := map[string]bool{}
+	for i, t := range cfg.Targets {
+		if !validTargetName(t.Name) {
+			return nil, fmt.Errorf("target %d of %s: name must be letters, digits, '-', '_' and '.'", i+1, path)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("target %s of %s is defined twice", t.Name, path)
+		}
+		names[t.Name] = true
+		if t.schedule, err = cron.Parse(t.Schedule); err != nil {
+			return nil, fmt.Errorf("target %s of %s: %w", t.Name, path, err)
+		}
+		for name := range t.Flags {
+			if flag.Lookup(name) == nil {
+				return nil, fmt.Errorf("unknown flag %q in target %s of %s", name, t.Name, path)
+			}
+		}
+	}
+
+	for i, o := range cfg.Overrides {
+		if o.Match == "" {
+			return nil, fmt.Errorf("override %d of %s has no match pattern", i+1, path)
+		}
+		if o.ErrorBudgetThreshold < 0 || o.ErrorBudgetThreshold >= 1 {
+			return nil, fmt.Errorf("override %d of %s: errorBudgetThreshold must be between 0 and 1", i+1, path)
+		}
+		if o.NegativeBudgetFraction < 0 || o.NegativeBudgetFraction > 1 {
+			return nil, fmt.Errorf("override %d of %s: negativeBudgetFraction must be between 0 and 1", i+1, path)
+		}
+		if o.Window < 0 {
+			return nil, fmt.Errorf("override %d of %s: window must be a positive duration", i+1, path)
+		}
+		o.matcher, err = parsePattern(o.Match)
+		if err != nil {
+			return nil, fmt.Errorf("override %d of %s: %w", i+1, path, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// validTargetName reports whether name can name a target: it is used as a directory and in URLs.
+func validTargetName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	for _, c := range name {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.ContainsRune("-_.", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyFlags sets the flags of the config that were not given on the command line.
+func (c *config) applyFlags() error {
+	for _, name := range slices.Sorted(maps.Keys(c.Flags)) {
+		if isFlagSet(name) {
+			continue
+		}
+		if err := flag.Set(name, c.Flags[name]); err != nil {
+			return fmt.Errorf("invalid value %q for flag %s in the config: %w", c.Flags[name], name, err)
+		}
+	}
+	return nil
+}
+
+// sloSettings are the settings an SLO is evaluated with.
+type sloSettings = vigil.Settings
+
+// settings returns the settings of an SLO: the flag values, changed by the first matching override. The window is the
+// period the SLO is configured with in its provider unless --window is given.
+func (c *config) settings(slo *model.SLO) sloSettings {
+	s := sloSettings{
+		ErrorBudgetThreshold:   *errorBudgetThreshold,
+		Window:                 *window,
+		NegativeBudgetFraction: *negativeBudgetFraction,
+	}
+	if slo.Period > 0 && !isFlagSet("window") {
+		s.Window = slo.Period
+	}
+	for _, o := range c.Overrides {
+		if o.matcher(slo) {
+			s.ErrorBudgetThreshold = cmp.Or(o.ErrorBudgetThreshold, s.ErrorBudgetThreshold)
+			s.Window = cmp.Or(o.Window, s.Window)
+			s.NegativeBudgetFraction = cmp.Or(o.NegativeBudgetFraction, s.NegativeBudgetFraction)
+			break
+		}
+	}
+	return s
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+// defaultCoverageOutput keeps the coverage report apart from the scan report when --output is not given.
+const defaultCoverageOutput = "slo_coverage.{{.Format}}"
+
+// latencyWords mark an SLO as a latency one by its name when the provider cannot tell its kind.
+var latencyWords = []string{"latency", "duration", "response time", "slow"}
+
+// coverageGap is a service found by vigil coverage to have no SLOs, or no SLO of one kind.
+type coverageGap struct {
+	Provider model.CloudProvider `json:"provider"`
+	Project  string              `json:"project,omitempty"`
+	Service  string              `json:"service"`
+	// Missing is the kind of SLO the service lacks, empty when it has none at all.
+	Missing model.SLOKind `json:"missing,omitempty"`
+	// SLOs is the number of SLOs the service has.
+	SLOs int `json:"slos"`
+}
+
+// coverageReport is the JSON coverage report.
+type coverageReport struct {
+	GeneratedAt time.Time     `json:"generatedAt"`
+	Target      string        `json:"target"`
+	Gaps        []coverageGap `json:"gaps"`
+}
+
+// serviceKey identifies a service across providers and projects.
+type serviceKey struct {
+	provider model.CloudProvider
+	project  string
+	service  string
+}
+
+// runCoverage reports the services without SLOs and the ones missing an availability or latency SLO, instead of
+// scanning the SLOs. It returns exitFlagged when gaps are found with --fail-on-flag.
+func runCoverage(ctx context.Context, clients []Vigil, slos []*model.SLO, sloClients map[*model.SLO]Vigil) int {
+	switch *format {
+	case formatXLSX, formatJSON, formatTable:
+	default:
+		log.Panicf("vigil %s supports --format %s, %s or %s", cmdCoverage, formatXLSX, formatJSON, formatTable)
+	}
+	if !isFlagSet("output") {
+		*output = defaultCoverageOutput
+	}
+
+	gaps, err := findCoverageGaps(ctx, clients, slos, sloClients)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+
+	msgs := i18n.Get(i18n.Lang(*lang))
+	switch *format {
+	case formatTable:
+		printCoverageGaps(gaps, msgs)
+	default:
+		path, err := outputPath(slos, time.Now())
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		if *format == formatJSON {
+			generateCoverageJSON(gaps, path)
+		} else {
+			generateCoverageExcel(gaps, msgs, path)
+		}
+		infof("Coverage report written to %s", path)
+	}
+
+	if *failOnFlag && len(gaps) > 0 {
+		return exitFlagged
+	}
+	return exitOK
+}
+
+// findCoverageGaps groups the SLOs by service and compares them with the services the providers list. Providers
+// that cannot list their services only get their services missing a kind of SLO reported.
+func findCoverageGaps(ctx context.Context, clients []Vigil, slos []*model.SLO, sloClients map[*model.SLO]Vigil) ([]coverageGap, error) {
+	kinds := make(map[serviceKey]map[model.SLOKind]int)
+	for _, slo := range slos {
+		if slo.Service == "" {
+			continue
+		}
+		k := serviceKey{sloClients[slo].GetProvider(), slo.Project, slo.Service}
+		if kinds[k] == nil {
+			kinds[k] = make(map[model.SLOKind]int)
+		}
+		kinds[k][sloKind(slo)]++
+	}
+
+	var gaps []coverageGap
+	for _, client := range clients {
+		lister, ok := client.(provider.ServiceLister)
+		if !ok {
+			infof("%s cannot list its services. only services missing an availability or latency SLO are reported", client.GetProvider())
+			continue
+		}
+		services, err := lister.GetServices(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s services: %w", client.GetProvider(), err)
+		}
+		for _, s := range services {
+			k := serviceKey{client.GetProvider(), s.Project, s.Name}
+			if kinds[k] == nil {
+				gaps = append(gaps, coverageGap{Provider: k.provider, Project: k.project, Service: k.service})
+				// Listed once, even when several services share a display name.
+				kinds[k] = make(map[model.SLOKind]int)
+			}
+		}
+	}
+
+	for k, counts := range kinds {
+		n := counts[model.SLOKindAvailability] + counts[model.SLOKindLatency]
+		for _, kind := range []model.SLOKind{model.SLOKindAvailability, model.SLOKindLatency} {
+			if n > 0 && counts[kind] == 0 {
+				gaps = append(gaps, coverageGap{Provider: k.provider, Project: k.project, Service: k.service, Missing: kind, SLOs: n})
+			}
+		}
+	}
+
+	slices.SortFunc(gaps, func(a, b coverageGap) int {
+		return cmp.Or(
+			cmp.Compare(a.Provider, b.Provider),
+			cmp.Compare(a.Project, b.Project),
+			cmp.Compare(a.Service, b.Service),
+			cmp.Compare(a.Missing, b.Missing),
+		)
+	})
+	return gaps, nil
+}
+
+// sloKind returns the kind of slo, guessed from its name when the provider cannot tell.
+func sloKind(slo *model.SLO) model.SLOKind {
+	if slo.Kind != "" {
+		return slo.Kind
+	}
+	name := strings.ToLower(slo.DisplayName)
+	for _, w := range latencyWords {
+		if strings.Contains(name, w) {
+			return model.SLOKindLatency
+		}
+	}
+	return model.SLOKindAvailability
+}
+
+// gapLabel describes what a service is missing.
+func gapLabel(g coverageGap, msgs *i18n.Messages) string {
+	switch g.Missing {
+	case model.SLOKindAvailability:
+		return msgs.CoverageNoAvailability
+	case model.SLOKindLatency:
+		return msgs.CoverageNoLatency
+	default:
+		return msgs.CoverageNoSLOs
+	}
+}
+
+func generateCoverageJSON(gaps []coverageGap, output string) {
+	report := coverageReport{
+		GeneratedAt: time.Now().UTC(),
+		Target:      reportTarget(),
+		Gaps:        append([]coverageGap{}, gaps...),
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		log.Panicf("Failed to create file: %v", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Panicf("Failed to write JSON report: %v", err)
+	}
+}
+
+// printCoverageGaps prints the gaps as a table on stdout, services without any SLO in red.
+func printCoverageGaps(gaps []coverageGap, msgs *i18n.Messages) {
+	rows := [][]string{{msgs.HeaderProvider, msgs.HeaderProject, msgs.HeaderService, msgs.HeaderGap, msgs.HeaderSLOCount}}
+	for _, g := range gaps {
+		rows = append(rows, []string{string(g.Provider), g.Project, g.Service, gapLabel(g, msgs), strconv.Itoa(g.SLOs)})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	color := useColor(os.Stdout)
+	for i, row := range rows {
+		style := ansiYellow
+		switch {
+		case i == 0:
+			style = ansiBold
+		case gaps[i-1].Missing == "":
+			style = ansiRed
+		}
+		writeTableRow(os.Stdout, row, widths, style, color)
+	}
+	fmt.Fprintln(os.Stdout)
+	fmt.Fprintf(os.Stdout, msgs.CoverageSummary+"\n", len(gaps))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/report"
+)
+
+// generateCSVReport writes every SLO, flagged or not, as a row with the columns of spec, for spreadsheets and scripts
+// that have no use for the Excel layout. Values are written raw: budgets and goals as ratios, times in RFC 3339.
+func generateCSVReport(data map[string]*model.SLOData, spec *report.Spec, output string) error {
+	slos := make([]*model.SLOData, 0, len(data))
+	for _, v := range data {
+		slos = append(slos, v)
+	}
+	sortSLOs(slos)
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	w := csv.NewWriter(f)
+	header := make([]string, len(spec.Columns))
+	for i, c := range spec.Columns {
+		header[i] = c.Header
+	}
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV report: %w", err)
+	}
+	for _, v := range slos {
+		row := make([]string, len(spec.Columns))
+		for i, c := range spec.Columns {
+			value, err := c.Value(v)
+			if err != nil {
+				return err
+			}
+			row[i] = csvValue(value)
+		}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV report: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV report: %w", err)
+	}
+	return nil
+}
+
+// csvValue formats the value of a report column as a CSV field, empty when the column has no value for the SLO.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/store"
+)
+
+//go:embed templates/slos.html
+var slosTemplate string
+
+//go:embed templates/slo.html
+var sloTemplate string
+
+// trendDays are the periods in days the trends of the dashboard can cover.
+var trendDays = []int{30, 90, 180, 365}
+
+// defaultTrendDays is the period of the trends when ?days= is not given.
+const defaultTrendDays = 90
+
+// Trend chart geometry in SVG user units: the sparklines of the SLO list and the charts of an SLO.
+const (
+	trendSparkWidth  = 120
+	trendSparkHeight = 24
+	trendChartWidth  = 720
+	trendChartHeight = 160
+)
+
+// dashboardTemplates parses the pages of the dashboard.
+func dashboardTemplates() (slos, slo *template.Template, err error) {
+	msgs := i18n.Get(i18n.LangEN)
+	funcs := template.FuncMap{
+		"percent":  func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) },
+		"category": func(c model.Category) string { return categoryLabel(c, msgs) },
+		"categoryStyle": func(c model.Category) template.CSS {
+			colors := categoryColors[c]
+			return template.CSS(fmt.Sprintf("background: #%s; color: #%s", colors[0], colors[1]))
+		},
+		"datetime": func(t time.Time) string { return t.Format(time.DateTime) },
+	}
+	if slos, err = template.New("slos").Funcs(funcs).Parse(slosTemplate); err != nil {
+		return nil, nil, err
+	}
+	if slo, err = template.New("slo").Funcs(funcs).Parse(sloTemplate); err != nil {
+		return nil, nil, err
+	}
+	return slos, slo, nil
+}
+
+// dashboardFilter are the filters of the SLO list, from the query of the request.
+type dashboardFilter struct {
+	Target, Team, Provider, Category string
+	Flagged                          bool
+}
+
+func (f dashboardFilter) match(st store.SLOStats) bool {
+	return (f.Target == "" || st.Target == f.Target) &&
+		(f.Team == "" || st.Team == f.Team) &&
+		(f.Provider == "" || string(st.Provider) == f.Provider) &&
+		(f.Category == "" || string(st.Category) == f.Category) &&
+		(!f.Flagged || st.Flag)
+}
+
+// sloRow is an SLO of the SLO list: its stats in the last run of its target and the trend of its minimum budget.
+type sloRow struct {
+	store.SLOStats
+	URL   string
+	Trend template.HTML
+}
+
+type slosPage struct {
+	Filter                             dashboardFilter
+	Targets, Teams, Providers          []string
+	Categories                         []model.Category
+	SLOs                               []sloRow
+	Total, Flagged, Days               int
+	GeneratedBy, GeneratedAt, StoreErr string
+}
+
+// handleSLOs lists the SLOs of the last run of every target, flagged first, filtered by the query of the request.
+func (s *server) handleSLOs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := dashboardFilter{
+		Target:   q.Get("target"),
+		Team:     q.Get("team"),
+		Provider: q.Get("provider"),
+		Category: q.Get("category"),
+		Flagged:  q.Get("flagged") != "",
+	}
+	page := slosPage{
+		Filter:      filter,
+		Categories:  append([]model.Category{model.CategoryHealthy}, findingCategories...),
+		Days:        defaultTrendDays,
+		GeneratedBy: i18n.Get(i18n.LangEN).GeneratedBy,
+		GeneratedAt: time.Now().Format(time.DateTime),
+	}
+
+	current, trends, err := s.currentSLOs(r, page.Days)
+	if err != nil {
+		log.Printf("%v", err)
+		page.StoreErr = err.Error()
+	}
+	for _, st := range current {
+		page.Targets = appendUnique(page.Targets, st.Target)
+		page.Teams = appendUnique(page.Teams, st.Team)
+		page.Providers = appendUnique(page.Providers, string(st.Provider))
+		if !filter.match(st) {
+			continue
+		}
+		page.Total++
+		if st.Flag {
+			page.Flagged++
+		}
+		runs := trends[st.Target+"\x00"+st.Key]
+		lo, hi := budgetRange(runs)
+		page.SLOs = append(page.SLOs, sloRow{
+			SLOStats: st,
+			URL:      sloURL(st.Key, st.Target),
+			Trend:    trendChart(trendSparkWidth, trendSparkHeight, lo, hi, runs, minBudget),
+		})
+	}
+	for _, list := range [][]string{page.Targets, page.Teams, page.Providers} {
+		slices.Sort(list)
+	}
+	// Flagged SLOs first, then the least healthy.
+	slices.SortStableFunc(page.SLOs, func(a, b sloRow) int {
+		if a.Flag != b.Flag {
+			if a.Flag {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.HealthScore, b.HealthScore)
+	})
+	s.render(w, s.slosTmpl, page)
+}
+
+// currentSLOs returns the stats of the SLOs in the last run of every target, and their stats over the last days by
+// target and key, oldest first.
+func (s *server) currentSLOs(r *http.Request, days int) ([]store.SLOStats, map[string][]store.SLOStats, error) {
+	st, err := store.Open(*storePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = st.Close() }()
+
+	var current []store.SLOStats
+	for _, ts := range s.targets {
+		run, ok, err := st.Latest(r.Context(), ts.target.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+		stats, err := st.Stats(r.Context(), run.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range stats {
+			current = append(current, v)
+		}
+	}
+
+	history, err := st.Since(r.Context(), time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, nil, err
+	}
+	trends := make(map[string][]store.SLOStats)
+	for _, v := range history {
+		trends[v.Target+"\x00"+v.Key] = append(trends[v.Target+"\x00"+v.Key], v)
+	}
+	return current, trends, nil
+}
+
+// sloSeries is an SLO of a target on the page of an SLO, with its runs newest first.
+type sloSeries struct {
+	Latest                   store.SLOStats
+	BudgetChart, HealthChart template.HTML
+	Runs                     []store.SLOStats
+}
+
+type sloPage struct {
+	Name, Target             string
+	Days                     int
+	DayOptions               []int
+	Series                   []sloSeries
+	GeneratedBy, GeneratedAt string
+}
+
+// handleSLO shows the trends of the SLOs whose key or display name is {name} over the last ?days=, of the ?target=
+// only when given.
+func (s *server) handleSLO(w http.ResponseWriter, r *http.Request) {
+	page := sloPage{
+		Name:        r.PathValue("name"),
+		Target:      r.URL.Query().Get("target"),
+		Days:        defaultTrendDays,
+		DayOptions:  trendDays,
+		GeneratedBy: i18n.Get(i18n.LangEN).GeneratedBy,
+		GeneratedAt: time.Now().Format(time.DateTime),
+	}
+	if v := r.URL.Query().Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days <= 0 {
+			http.Error(w, "invalid days: "+strconv.Quote(v), http.StatusBadRequest)
+			return
+		}
+		page.Days = days
+	}
+
+	st, err := store.Open(*storePath)
+	if err != nil {
+		log.Printf("%v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = st.Close() }()
+	history, err := st.History(r.Context(), page.Name, time.Now().AddDate(0, 0, -page.Days))
+	if err != nil {
+		log.Printf("%v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var (
+		order    []string
+		bySeries = make(map[string][]store.SLOStats)
+	)
+	for _, v := range history {
+		if page.Target != "" && v.Target != page.Target {
+			continue
+		}
+		id := v.Target + "\x00" + v.Key
+		if _, ok := bySeries[id]; !ok {
+			order = append(order, id)
+		}
+		bySeries[id] = append(bySeries[id], v)
+	}
+	if len(order) == 0 {
+		http.Error(w, fmt.Sprintf("no stored run of the last %d days has SLO %q", page.Days, page.Name), http.StatusNotFound)
+		return
+	}
+	for _, id := range order {
+		runs := bySeries[id]
+		lo, hi := budgetRange(runs)
+		newest := slices.Clone(runs)
+		slices.Reverse(newest)
+		page.Series = append(page.Series, sloSeries{
+			Latest:      runs[len(runs)-1],
+			BudgetChart: trendChart(trendChartWidth, trendChartHeight, lo, hi, runs, minBudget, avgBudget),
+			HealthChart: trendChart(trendChartWidth, trendChartHeight/2, 0, 100, runs, healthScore),
+			Runs:        newest,
+		})
+	}
+	s.render(w, s.sloTmpl, page)
+}
+
+// render writes a page of the dashboard, or an error when its template fails.
+func (s *server) render(w http.ResponseWriter, tmpl *template.Template, data interface{}) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// sloURL is the dashboard page of an SLO of a target. Keys can have slashes, so the key is escaped as one segment.
+func sloURL(key, target string) string {
+	return "/slos/" + url.PathEscape(key) + "?" + url.Values{"target": {target}}.Encode()
+}
+
+func minBudget(st store.SLOStats) float64   { return st.MinBudget }
+func avgBudget(st store.SLOStats) float64   { return st.AvgBudget }
+func healthScore(st store.SLOStats) float64 { return st.HealthScore }
+
+// budgetRange is the range of the budget charts of runs: 0 to 1, widened to the budgets beyond.
+func budgetRange(runs []store.SLOStats) (lo, hi float64) {
+	lo, hi = 0, 1
+	for _, st := range runs {
+		lo = math.Min(lo, math.Min(st.MinBudget, st.AvgBudget))
+		hi = math.Max(hi, math.Max(st.MinBudget, st.AvgBudget))
+	}
+	return lo, hi
+}
+
+// trendChart renders values of runs, oldest first, as the lines of an inline SVG, placed by the start times of the
+// runs, with a dashed line at zero when it is within lo and hi. A single run is drawn as dots.
+func trendChart(width, height int, lo, hi float64, runs []store.SLOStats, values ...func(store.SLOStats) float64) template.HTML {
+	if len(runs) == 0 || hi <= lo {
+		return ""
+	}
+	first, last := runs[0].StartedAt, runs[len(runs)-1].StartedAt
+	x := func(t time.Time) float64 {
+		if !last.After(first) {
+			return float64(width) / 2
+		}
+		return float64(t.Sub(first)) / float64(last.Sub(first)) * float64(width)
+	}
+	y := func(v float64) float64 {
+		return float64(height) - (v-lo)/(hi-lo)*float64(height)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="trend" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	if lo < 0 && hi > 0 {
+		fmt.Fprintf(&b, `<line class="zero" x1="0" y1="%.1f" x2="%d" y2="%.1f"/>`, y(0), width, y(0))
+	}
+	for i, value := range values {
+		if len(runs) == 1 {
+			fmt.Fprintf(&b, `<circle class="s%d" cx="%.1f" cy="%.1f" r="2.5"/>`, i, x(first), y(value(runs[0])))
+			continue
+		}
+		coords := make([]string, 0, len(runs))
+		for _, st := range runs {
+			coords = append(coords, fmt.Sprintf("%.1f,%.1f", x(st.StartedAt), y(value(st))))
+		}
+		fmt.Fprintf(&b, `<polyline class="s%d" points="%s"/>`, i, strings.Join(coords, " "))
+	}
+	b.WriteString(`</svg>`)
+	// Every interpolated value is a number formatted here, so the markup is safe to emit unescaped.
+	return template.HTML(b.String())
+}
+
+func appendUnique(list []string, v string) []string {
+	if v == "" || slices.Contains(list, v) {
+		return list
+	}
+	return append(list, v)
+}
@@ -2,29 +2,73 @@
 package datadog
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
 	datadogV1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	datadogV2 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
 	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
 )
 
 // Client is a Datadog SLO API client.
 type Client struct {
 	api                  *datadogV1.ServiceLevelObjectivesApi
-	ctx                  context.Context
+	services             *datadogV2.ServiceDefinitionApi
+	metrics              *datadogV1.MetricsApi
+	timeseries           *datadogV2.MetricsApi
+	limit                *rateLimit
+	downtimesAPI         *datadogV1.DowntimesApi
+	monitorsAPI          *datadogV1.MonitorsApi
+	auth                 *datadogV1.AuthenticationApi
+	site                 string
 	ErrorBudgetThreshold float64
 	Window               time.Duration
+	// Tags limits the SLOs to the ones with all of these tags, e.g. team:payments.
+	Tags []string
+	// Groups also reports each group of grouped SLOs as its own SLO.
+	Groups bool
+	// Downtimes reports the share of the bad time of monitor SLOs spent in scheduled downtimes.
+	Downtimes bool
+
+	// downPeriods holds the periods the monitors of each monitor SLO were down, by downPeriodsKey, for
+	// BadTimeInDowntime.
+	downPeriods sync.Map
+
+	// monitors holds a *monitorLookup by monitor ID, for MonitorIssues.
+	monitors sync.Map
+
+	downtimesOnce sync.Once
+	downtimes     []datadogV1.Downtime
+	downtimesErr  error
 }
 
-// NewClient creates a new Datadog client. Requires DD_API_KEY and DD_APP_KEY environment variables.
-func NewClient(ctx context.Context, ddSite string, errorBudgetThreshold float64, window time.Duration) (*Client, error) {
+// Sites are the Datadog sites the API client knows.
+var Sites = []string{
+	"datadoghq.com",
+	"us3.datadoghq.com",
+	"us5.datadoghq.com",
+	"ap1.datadoghq.com",
+	"ap2.datadoghq.com",
+	"datadoghq.eu",
+	"ddog-gov.com",
+}
+
+// NewClient creates a new Datadog client sending at most concurrency requests at once. Requires DD_API_KEY and
+// DD_APP_KEY environment variables. ddSite defaults to the DD_SITE environment variable, then to datadoghq.com. The
+// API key is validated against the site first, so a wrong key or site fails before any SLO is listed. transport sends
+// the API requests, http.DefaultTransport when nil.
+func NewClient(ctx context.Context, ddSite string, errorBudgetThreshold float64, window time.Duration, concurrency int, transport http.RoundTripper) (*Client, error) {
 	if _, ok := os.LookupEnv("DD_API_KEY"); !ok {
 		return nil, errors.New("DD_API_KEY environment variable is required")
 	}
@@ -32,21 +76,58 @@ func NewClient(ctx context.Context, ddSite string, errorBudgetThreshold float64,
 		return nil, errors.New("DD_APP_KEY environment variable is required")
 	}
 
-	ctx = datadog.NewDefaultContext(ctx)
-	if ddSite != "" {
-		ctx = context.WithValue(ctx, datadog.ContextServerVariables, map[string]string{"site": ddSite})
-	}
-
 	cfg := datadog.NewConfiguration()
+	if transport != nil {
+		cfg.HTTPClient = &http.Client{Transport: transport}
+	}
 	apiClient := datadog.NewAPIClient(cfg)
 	api := datadogV1.NewServiceLevelObjectivesApi(apiClient)
 
-	return &Client{
+	c := &Client{
 		api:                  api,
-		ctx:                  ctx,
+		services:             datadogV2.NewServiceDefinitionApi(apiClient),
+		metrics:              datadogV1.NewMetricsApi(apiClient),
+		timeseries:           datadogV2.NewMetricsApi(apiClient),
+		limit:                newRateLimit(concurrency),
+		downtimesAPI:         datadogV1.NewDowntimesApi(apiClient),
+		monitorsAPI:          datadogV1.NewMonitorsApi(apiClient),
+		auth:                 datadogV1.NewAuthenticationApi(apiClient),
+		site:                 cmp.Or(ddSite, os.Getenv("DD_SITE")),
 		ErrorBudgetThreshold: errorBudgetThreshold,
 		Window:               window,
-	}, nil
+	}
+	if err := c.validateAPIKey(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validateAPIKey checks DD_API_KEY with the validate endpoint of the site, a single cheap call.
+func (c *Client) validateAPIKey(ctx context.Context) error {
+	site := cmp.Or(c.site, "datadoghq.com")
+	forbidden := false
+	err := c.retry(ctx, func() (*http.Response, error) {
+		_, httpResp, err := c.auth.Validate(c.apiContext(ctx))
+		forbidden = httpResp != nil && httpResp.StatusCode == http.StatusForbidden
+		return httpResp, err
+	})
+	if forbidden {
+		return fmt.Errorf("DD_API_KEY is not valid for %s. check the key and --dd-site", site)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to validate DD_API_KEY with %s: %w", site, err)
+	}
+	return nil
+}
+
+// apiContext adds the API keys of the environment and the site to ctx, the values the Datadog API client reads from
+// the context of each request, so the cancellation and deadline of ctx still apply to it.
+func (c *Client) apiContext(ctx context.Context) context.Context {
+	ctx = datadog.NewDefaultContext(ctx)
+	if c.site != "" {
+		ctx = context.WithValue(ctx, datadog.ContextServerVariables, map[string]string{"site": c.site})
+	}
+	return ctx
 }
 
 // GetProvider returns the Datadog cloud provider identifier.
@@ -54,11 +135,24 @@ func (c *Client) GetProvider() model.CloudProvider {
 	return model.CloudProviderDD
 }
 
-// GetSLOs retrieves all SLOs from the Datadog API with pagination.
-func (c *Client) GetSLOs(_ context.Context) ([]*model.SLO, error) {
-	var slos []*model.SLO
+// Close is a no-op; the Datadog API client holds no long-lived connections.
+func (c *Client) Close() error {
+	return nil
+}
+
+// GetSLOs retrieves all SLOs from the Datadog API with pagination. With Groups, the groups of grouped SLOs follow
+// them; SLOs whose groups cannot be listed are reported in a provider.PartialError.
+func (c *Client) GetSLOs(ctx context.Context) ([]*model.SLO, error) {
+	var (
+		slos    []*model.SLO
+		skipped []string
+	)
 
-	ch, cancel := c.api.ListSLOsWithPagination(c.ctx, *datadogV1.NewListSLOsOptionalParameters().WithLimit(100))
+	params := datadogV1.NewListSLOsOptionalParameters().WithLimit(100)
+	if len(c.Tags) > 0 {
+		params.WithTagsQuery(strings.Join(c.Tags, ","))
+	}
+	ch, cancel := c.api.ListSLOsWithPagination(c.apiContext(ctx), *params)
 	defer cancel()
 
 	for result := range ch {
@@ -66,100 +160,219 @@ func (c *Client) GetSLOs(_ context.Context) ([]*model.SLO, error) {
 			return nil, fmt.Errorf("failed to list SLOs: %w", result.Error)
 		}
 		slo := result.Item
+		// The tags query is checked again, so SLOs need every tag whether the API ANDs or ORs them.
+		if !hasTags(slo.GetTags(), c.Tags) {
+			continue
+		}
 
-		thresholds := slo.GetThresholds()
 		var goal float64
-		if len(thresholds) > 0 {
-			goal = thresholds[0].GetTarget() / 100.0
+		if threshold, ok := windowThreshold(slo.GetThresholds(), c.Window); ok {
+			goal = threshold.GetTarget() / 100.0
 		}
 
-		slos = append(slos, &model.SLO{
+		labels := tagLabels(slo.GetTags())
+		s := &model.SLO{
 			Name:        slo.GetId(),
 			DisplayName: slo.GetName(),
 			Goal:        goal,
+			Service:     labels["service"],
+			Labels:      labels,
+			ConsoleURL:  c.consoleURL(slo.GetId()),
 			SLI:         slo,
-		})
+		}
+		slos = append(slos, s)
+
+		if c.Groups {
+			groups, err := c.groupSLOs(ctx, s, slo)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("groups of %s: %v", s.DisplayName, err))
+			}
+			slos = append(slos, groups...)
+		}
 	}
 
+	if len(skipped) > 0 {
+		return slos, &provider.PartialError{Skipped: skipped}
+	}
 	return slos, nil
 }
 
-// GetErrorBudgetTimeSeries fetches error budget time series data for a given SLO.
-// It retries up to 5 times on HTTP 429 Too Many Requests with exponential backoff.
-func (c *Client) GetErrorBudgetTimeSeries(_ context.Context, slo *model.SLO) (string, string, []float64, error) {
-	ddSLO, ok := slo.SLI.(datadogV1.ServiceLevelObjective)
-	if !ok {
-		return "", "", nil, fmt.Errorf("SLI is not of expected type: %T", slo.SLI)
+// timeframes are the lengths of the threshold timeframes of Datadog SLOs.
+var timeframes = map[datadogV1.SLOTimeframe]time.Duration{
+	datadogV1.SLOTIMEFRAME_SEVEN_DAYS:  7 * 24 * time.Hour,
+	datadogV1.SLOTIMEFRAME_THIRTY_DAYS: 30 * 24 * time.Hour,
+	datadogV1.SLOTIMEFRAME_NINETY_DAYS: 90 * 24 * time.Hour,
+}
+
+// windowThreshold returns the threshold an SLO is evaluated against over window: the one whose timeframe matches it,
+// otherwise the one with the closest timeframe, so a 7d and 30d SLO is not judged against its 7d target over 30 days.
+// The first threshold is taken when none has a fixed timeframe.
+func windowThreshold(thresholds []datadogV1.SLOThreshold, window time.Duration) (datadogV1.SLOThreshold, bool) {
+	if len(thresholds) == 0 {
+		return datadogV1.SLOThreshold{}, false
 	}
 
-	fromTs := time.Now().UTC().Add(c.Window * -1).Unix()
-	toTs := time.Now().UTC().Unix()
+	best, bestDiff := 0, time.Duration(-1)
+	for i, t := range thresholds {
+		length, ok := timeframes[t.GetTimeframe()]
+		if !ok {
+			continue
+		}
+		diff := length - window
+		if diff < 0 {
+			diff = -diff
+		}
+		if bestDiff < 0 || diff < bestDiff {
+			best, bestDiff = i, diff
+		}
+	}
+	return thresholds[best], true
+}
 
-	const maxRetries = 5
+// GetServices lists the services of the Software Catalog. SLOs belong to the one named by their service tag.
+func (c *Client) GetServices(ctx context.Context) ([]*model.Service, error) {
+	var services []*model.Service
 
-	var (
-		resp datadogV1.SLOHistoryResponse
-		err  error
-	)
+	ch, cancel := c.services.ListServiceDefinitionsWithPagination(c.apiContext(ctx), *datadogV2.NewListServiceDefinitionsOptionalParameters().WithPageSize(100))
+	defer cancel()
+
+	for result := range ch {
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to list service definitions: %w", result.Error)
+		}
+		if name := serviceName(result.Item.GetAttributes().Schema); name != "" {
+			services = append(services, &model.Service{Name: name})
+		}
+	}
 
-	opts := datadogV1.NewGetSLOHistoryOptionalParameters().WithApplyCorrection(true)
+	return services, nil
+}
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		var httpResp *http.Response
-		resp, httpResp, err = c.api.GetSLOHistory(c.ctx, slo.Name, fromTs, toTs, *opts)
+// serviceName returns the dd-service of a service definition of any schema version.
+func serviceName(schema *datadogV2.ServiceDefinitionSchema) string {
+	switch {
+	case schema == nil:
+		return ""
+	case schema.ServiceDefinitionV2Dot2 != nil:
+		return schema.ServiceDefinitionV2Dot2.GetDdService()
+	case schema.ServiceDefinitionV2Dot1 != nil:
+		return schema.ServiceDefinitionV2Dot1.GetDdService()
+	case schema.ServiceDefinitionV2 != nil:
+		return schema.ServiceDefinitionV2.GetDdService()
+	case schema.ServiceDefinitionV1 != nil:
+		return schema.ServiceDefinitionV1.Info.GetDdService()
+	default:
+		return ""
+	}
+}
+
+// GetErrorBudgetTimeSeries fetches error budget time series data for a given SLO.
+func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, error) {
+	good, total, points, _, err := c.GetWeightedErrorBudgetTimeSeries(ctx, slo)
+	return good, total, points, err
+}
+
+// GetWeightedErrorBudgetTimeSeries is GetErrorBudgetTimeSeries that also returns the denominator of each point of
+// metric SLOs, the total events behind it. Monitor and time slice SLOs have no weights. Time slice SLOs are evaluated
+// from their slices rather than from their SLO history.
+func (c *Client) GetWeightedErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, []float64, error) {
+	fromTs := time.Now().UTC().Add(cmp.Or(slo.Window, c.Window) * -1).Unix()
+	toTs := time.Now().UTC().Unix()
+
+	if group, ok := slo.SLI.(groupSLI); ok {
+		return c.groupErrorBudget(ctx, slo, group, fromTs, toTs)
+	}
+	ddSLO, ok := slo.SLI.(datadogV1.ServiceLevelObjective)
+	if !ok {
+		return "", "", nil, nil, fmt.Errorf("SLI is not of expected type: %T", slo.SLI)
+	}
+	if ddSLO.GetType() == datadogV1.SLOTYPE_TIME_SLICE {
+		good, total, points, err := c.processTimeSliceSLO(ctx, ddSLO, fromTs, toTs)
 		if err != nil {
-			is429 := httpResp != nil && httpResp.StatusCode == http.StatusTooManyRequests
-			if httpResp != nil {
-				if closeErr := httpResp.Body.Close(); closeErr != nil {
-					log.Printf("Failed to close response body: %v", closeErr)
-				}
-			}
-			if !is429 {
-				return "", "", nil, fmt.Errorf("failed to get SLO history: %w", err)
-			}
-			delay := time.Duration(1<<attempt) * time.Second
-			log.Printf("Rate limited by Datadog API (429), retrying in %v (attempt %d/%d)...", delay, attempt+1, maxRetries)
-			time.Sleep(delay)
-			continue
+			return "", "", nil, nil, err
 		}
-		if httpResp != nil {
-			if closeErr := httpResp.Body.Close(); closeErr != nil {
-				log.Printf("Failed to close response body: %v", closeErr)
-			}
+		if len(points) == 0 {
+			return good, total, nil, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
 		}
-		break
+		return good, total, points, nil, nil
 	}
 
+	data, err := c.sloHistory(ctx, ddSLO.GetId(), fromTs, toTs)
 	if err != nil {
-		return "", "", nil, fmt.Errorf("failed to get SLO history: %w", err)
+		return "", "", nil, nil, err
 	}
 
-	data := resp.GetData()
-
 	var (
-		good   string
-		total  string
-		points []float64
+		good    string
+		total   string
+		points  []model.Point
+		weights []float64
+		down    []interval
 	)
 
 	sloType := ddSLO.GetType()
 	switch sloType {
 	case datadogV1.SLOTYPE_METRIC:
-		good, total, points = processMetricSLO(data, ddSLO)
-	case datadogV1.SLOTYPE_MONITOR, datadogV1.SLOTYPE_TIME_SLICE:
-		good, total, points = processMonitorSLO(data, ddSLO)
+		good, total, points, weights = processMetricSLO(data, ddSLO)
+	case datadogV1.SLOTYPE_MONITOR:
+		good, total, points, down = processMonitorSLO(data, ddSLO, time.Unix(toTs, 0).UTC())
+		c.storeDownPeriods(slo, down)
 	default:
-		return "", "", nil, fmt.Errorf("unsupported SLO type: %s", sloType)
+		return "", "", nil, nil, fmt.Errorf("unsupported SLO type: %s", sloType)
 	}
 
 	if len(points) == 0 {
-		return "", "", nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
+		return good, total, nil, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
 	}
 
-	return good, total, points, nil
+	return good, total, points, weights, nil
 }
 
-func processMetricSLO(data datadogV1.SLOHistoryResponseData, ddSLO datadogV1.ServiceLevelObjective) (string, string, []float64) {
+// maxRetries is the number of attempts of a request rate limited by the Datadog API.
+const maxRetries = 8
+
+// retry calls do under the rate limit of the client until it succeeds or fails with another error than HTTP 429 Too
+// Many Requests, retrying up to maxRetries times. Rejected requests wait for the X-RateLimit-Reset of the response, or
+// back off exponentially without it. It closes the response body do returns.
+func (c *Client) retry(ctx context.Context, do func() (*http.Response, error)) error {
+	var err error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if err := c.limit.acquire(ctx); err != nil {
+			return err
+		}
+		var httpResp *http.Response
+		httpResp, err = do()
+		reset := c.limit.observe(httpResp)
+		c.limit.release()
+
+		is429 := httpResp != nil && httpResp.StatusCode == http.StatusTooManyRequests
+		if httpResp != nil {
+			if closeErr := httpResp.Body.Close(); closeErr != nil {
+				log.Printf("Failed to close response body: %v", closeErr)
+			}
+		}
+		if err == nil || !is429 {
+			return err
+		}
+
+		// With a reset, the next acquire waits for it, along with every other request of the client.
+		if reset > 0 {
+			log.Printf("Rate limited by Datadog API (429), retrying in %v when the rate limit resets (attempt %d/%d)...", reset, attempt+1, maxRetries)
+			continue
+		}
+		delay := time.Duration(1<<attempt) * time.Second
+		log.Printf("Rate limited by Datadog API (429), retrying in %v (attempt %d/%d)...", delay, attempt+1, maxRetries)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+	return err
+}
+
+// processMetricSLO returns the good ratio of each interval of a metric SLO along with its denominator.
+func processMetricSLO(data datadogV1.SLOHistoryResponseData, ddSLO datadogV1.ServiceLevelObjective) (string, string, []model.Point, []float64) {
 	query := ddSLO.GetQuery()
 	good := query.GetNumerator()
 	total := query.GetDenominator()
@@ -170,51 +383,115 @@ func processMetricSLO(data datadogV1.SLOHistoryResponseData, ddSLO datadogV1.Ser
 
 	numValues := numerator.GetValues()
 	denValues := denominator.GetValues()
+	times := series.GetTimes()
 
-	var points []float64
+	var (
+		points  []model.Point
+		weights []float64
+	)
 	for i := range numValues {
-		if i >= len(denValues) {
+		if i >= len(denValues) || i >= len(times) {
 			break
 		}
 		if denValues[i] == 0 {
 			continue
 		}
-		points = append(points, numValues[i]/denValues[i])
+		points = append(points, model.Point{Time: time.UnixMilli(int64(times[i])).UTC(), Value: numValues[i] / denValues[i]})
+		weights = append(weights, denValues[i])
 	}
 
-	return good, total, points
+	return good, total, points, weights
 }
 
-func processMonitorSLO(data datadogV1.SLOHistoryResponseData, ddSLO datadogV1.ServiceLevelObjective) (string, string, []float64) {
+// processMonitorSLO returns the uptime ratio of a monitor SLO over its history, which ends at end, along
+// with the periods it was down.
+func processMonitorSLO(data datadogV1.SLOHistoryResponseData, ddSLO datadogV1.ServiceLevelObjective, end time.Time) (string, string, []model.Point, []interval) {
 	good := fmt.Sprintf("monitor_ids: %v", ddSLO.GetMonitorIds())
 	total := fmt.Sprintf("type: %s", ddSLO.GetType())
 
 	overall := data.GetOverall()
-	history := overall.GetHistory()
+	points, down := monitorPoints(overall.GetHistory(), end)
+	return good, total, points, down
+}
 
+// monitorPoints turns the state transitions of a monitor into its uptime ratio at the end of each state: the time
+// spent up over the time with data since the start of the history. Each state lasts until the next transition, the
+// last one until end, and periods without data count neither way. It also returns the periods the monitor was down.
+func monitorPoints(history [][]float64, end time.Time) ([]model.Point, []interval) {
 	var (
-		points      []float64
-		uptimeCount float64
-		totalCount  float64
+		points        []model.Point
+		down          []interval
+		uptime, total time.Duration
 	)
 
-	for _, entry := range history {
+	for i, entry := range history {
 		if len(entry) < 2 {
 			continue
 		}
-		state := entry[1]
-		if state == 2 { // no-data, skip
+		start, stop := time.Unix(int64(entry[0]), 0).UTC(), end
+		if i+1 < len(history) && len(history[i+1]) > 0 {
+			stop = time.Unix(int64(history[i+1][0]), 0).UTC()
+		}
+		if !stop.After(start) {
 			continue
 		}
-		totalCount++
-		if state == 0 { // uptime
-			uptimeCount++
+
+		switch entry[1] {
+		case 0: // uptime
+			uptime += stop.Sub(start)
+			total += stop.Sub(start)
+		case 1: // downtime
+			total += stop.Sub(start)
+			down = append(down, interval{start: start, end: stop})
+		default: // no data
+			continue
 		}
-		// state == 1: downtime
-		if totalCount > 0 {
-			points = append(points, uptimeCount/totalCount)
+		points = append(points, model.Point{Time: stop, Value: uptime.Seconds() / total.Seconds()})
+	}
+
+	return points, down
+}
+
+// consoleURL links to the SLO detail page. The web app of the US1 and EU sites lives on an "app." subdomain,
+// while the other sites (us3, us5, ap1, ...) serve it from the site host itself.
+func (c *Client) consoleURL(id string) string {
+	site := c.site
+	if site == "" {
+		site = "datadoghq.com"
+	}
+	host := site
+	if strings.Count(site, ".") == 1 {
+		host = "app." + site
+	}
+	return fmt.Sprintf("https://%s/slo?slo_id=%s", host, url.QueryEscape(id))
+}
+
+// hasTags reports whether tags has every tag of want. A tag without a value, e.g. team, matches any value.
+func hasTags(tags, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, t := range tags {
+			if t == w || (!strings.Contains(w, ":") && strings.HasPrefix(t, w+":")) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
 		}
 	}
+	return true
+}
 
-	return good, total, points
+// tagLabels turns "key:value" tags into labels. Tags without a value map to an empty value.
+func tagLabels(tags []string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		key, value, _ := strings.Cut(tag, ":")
+		labels[key] = value
+	}
+	return labels
 }
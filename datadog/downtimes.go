@@ -0,0 +1,145 @@
+package datadog
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	datadogV1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/rluisr/vigil/model"
+)
+
+// interval is a period of time, such as one a monitor was down.
+type interval struct {
+	start, end time.Time
+}
+
+// downPeriodsKey identifies the error budget series of an SLO over its window, since the extra windows of --window
+// fetch the same SLO again.
+func (c *Client) downPeriodsKey(slo *model.SLO) string {
+	return slo.Name + "@" + cmp.Or(slo.Window, c.Window).String()
+}
+
+// storeDownPeriods keeps the periods the monitors of slo were down for BadTimeInDowntime.
+func (c *Client) storeDownPeriods(slo *model.SLO, down []interval) {
+	if c.Downtimes {
+		c.downPeriods.Store(c.downPeriodsKey(slo), down)
+	}
+}
+
+// BadTimeInDowntime returns the share of the time the monitors of a monitor SLO were down that fell inside a scheduled
+// downtime of one of them, or of every monitor. ok is false for other SLOs, SLOs that were never down, and without
+// Downtimes. Downtimes are listed once, with their current or last occurrence for recurring ones.
+func (c *Client) BadTimeInDowntime(ctx context.Context, slo *model.SLO) (float64, bool, error) {
+	stored, ok := c.downPeriods.Load(c.downPeriodsKey(slo))
+	if !ok {
+		return 0, false, nil
+	}
+	down := stored.([]interval)
+	if len(down) == 0 {
+		return 0, false, nil
+	}
+
+	var monitorIDs []int64
+	switch sli := slo.SLI.(type) {
+	case datadogV1.ServiceLevelObjective:
+		monitorIDs = sli.GetMonitorIds()
+	case groupSLI:
+		monitorIDs = sli.slo.GetMonitorIds()
+	}
+
+	downtimes, err := c.listDowntimes(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	scheduled := mergeIntervals(monitorDowntimes(downtimes, monitorIDs))
+
+	var total, inside time.Duration
+	for _, d := range down {
+		total += d.end.Sub(d.start)
+		for _, s := range scheduled {
+			if start, end := maxTime(d.start, s.start), minTime(d.end, s.end); end.After(start) {
+				inside += end.Sub(start)
+			}
+		}
+	}
+	return inside.Seconds() / total.Seconds(), true, nil
+}
+
+// monitorDowntimes returns the periods of the downtimes that silence one of the monitors, or every monitor with the
+// "*" monitor tag. Canceled downtimes end when they were canceled, and downtimes without an end are still going on.
+func monitorDowntimes(downtimes []datadogV1.Downtime, monitorIDs []int64) []interval {
+	var periods []interval
+	for _, d := range downtimes {
+		id, _ := d.GetMonitorIdOk()
+		switch {
+		case id != nil && slices.Contains(monitorIDs, *id):
+		case id == nil && slices.Contains(d.GetMonitorTags(), "*"):
+		default:
+			continue
+		}
+
+		end := time.Now().UTC()
+		if e, _ := d.GetEndOk(); e != nil {
+			end = time.Unix(*e, 0).UTC()
+		}
+		if canceled, _ := d.GetCanceledOk(); canceled != nil {
+			end = minTime(end, time.Unix(*canceled, 0).UTC())
+		}
+		periods = append(periods, interval{start: time.Unix(d.GetStart(), 0).UTC(), end: end})
+	}
+	return periods
+}
+
+// mergeIntervals sorts periods and merges the overlapping ones, so time silenced by two downtimes counts once.
+func mergeIntervals(periods []interval) []interval {
+	slices.SortFunc(periods, func(a, b interval) int { return a.start.Compare(b.start) })
+	var merged []interval
+	for _, p := range periods {
+		if !p.end.After(p.start) {
+			continue
+		}
+		if n := len(merged); n > 0 && !p.start.After(merged[n-1].end) {
+			merged[n-1].end = maxTime(merged[n-1].end, p.end)
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// listDowntimes lists the downtimes of the organization, past ones included, on the first call only.
+func (c *Client) listDowntimes(ctx context.Context) ([]datadogV1.Downtime, error) {
+	c.downtimesOnce.Do(func() {
+		opts := datadogV1.NewListDowntimesOptionalParameters().WithCurrentOnly(false)
+		c.downtimesErr = c.retry(ctx, func() (*http.Response, error) {
+			var (
+				httpResp *http.Response
+				err      error
+			)
+			c.downtimes, httpResp, err = c.downtimesAPI.ListDowntimes(c.apiContext(ctx), *opts)
+			return httpResp, err
+		})
+		if c.downtimesErr != nil {
+			c.downtimesErr = fmt.Errorf("failed to list downtimes: %w", c.downtimesErr)
+		}
+	})
+	return c.downtimes, c.downtimesErr
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package datadog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	datadogV1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/rluisr/vigil/model"
+)
+
+// ExportGoals writes the SLOs with their recommended goal as the JSON bodies of the Datadog update SLO API, one
+// datadog_{id}.json file per SLO to send with PUT /api/v1/slo/{id}. Groups of grouped SLOs share the goal of their
+// SLO and are left out.
+func (c *Client) ExportGoals(ctx context.Context, dir string, changes []model.GoalChange) ([]string, error) {
+	var paths []string
+	for _, change := range changes {
+		if _, ok := change.SLO.SLI.(groupSLI); ok {
+			log.Printf("Skipping the goal of %s: groups share the goal of their SLO", change.SLO.DisplayName)
+			continue
+		}
+		slo, _, err := c.sloWithGoal(ctx, change.SLO.Name, change.Goal)
+		if err != nil {
+			return paths, err
+		}
+		data, err := json.MarshalIndent(slo, "", "  ")
+		if err != nil {
+			return paths, fmt.Errorf("failed to encode SLO %s: %w", change.SLO.Name, err)
+		}
+
+		p := filepath.Join(dir, "datadog_"+change.SLO.Name+".json")
+		if err := os.WriteFile(p, append(data, '\n'), 0o644); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %w", p, err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// UpdateGoal sets the target of the threshold of an SLO evaluated over the window, leaving the rest of its definition
+// as it is. It refuses to update the SLO when that target changed since it was listed, so a concurrent edit is not
+// overwritten, and refuses the groups of grouped SLOs, whose goal is the goal of their SLO.
+func (c *Client) UpdateGoal(ctx context.Context, slo *model.SLO, goal float64) error {
+	if _, ok := slo.SLI.(groupSLI); ok {
+		return fmt.Errorf("%s is a group of an SLO and shares its goal", slo.DisplayName)
+	}
+	updated, previous, err := c.sloWithGoal(ctx, slo.Name, goal)
+	if err != nil {
+		return err
+	}
+	if previous != slo.Goal {
+		return fmt.Errorf("the goal of %s changed to %s%% since it was scanned", slo.Name, strconv.FormatFloat(previous*100, 'f', -1, 64))
+	}
+
+	err = c.retry(ctx, func() (*http.Response, error) {
+		_, httpResp, err := c.api.UpdateSLO(c.apiContext(ctx), slo.Name, updated)
+		return httpResp, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update SLO %s: %w", slo.Name, err)
+	}
+	return nil
+}
+
+// sloWithGoal fetches the current definition of an SLO and sets the target of its threshold evaluated over the
+// window to goal, returning the goal it had. A warning no longer above the new target is removed, as Datadog rejects
+// it.
+func (c *Client) sloWithGoal(ctx context.Context, id string, goal float64) (datadogV1.ServiceLevelObjective, float64, error) {
+	var resp datadogV1.SLOResponse
+	err := c.retry(ctx, func() (*http.Response, error) {
+		var (
+			httpResp *http.Response
+			err      error
+		)
+		resp, httpResp, err = c.api.GetSLO(c.apiContext(ctx), id)
+		return httpResp, err
+	})
+	if err != nil {
+		return datadogV1.ServiceLevelObjective{}, 0, fmt.Errorf("failed to get SLO %s: %w", id, err)
+	}
+
+	// The SLO of the get API has the fields of the update API, so its JSON converts as it is.
+	var slo datadogV1.ServiceLevelObjective
+	data, err := json.Marshal(resp.GetData())
+	if err == nil {
+		err = json.Unmarshal(data, &slo)
+	}
+	if err != nil {
+		return slo, 0, fmt.Errorf("failed to convert SLO %s: %w", id, err)
+	}
+
+	current, ok := windowThreshold(slo.GetThresholds(), c.Window)
+	if !ok {
+		return slo, 0, fmt.Errorf("SLO %s has no threshold", id)
+	}
+	thresholds := slo.GetThresholds()
+	target := math.Round(goal*1e8) / 1e6
+	for i := range thresholds {
+		t := &thresholds[i]
+		if t.GetTimeframe() != current.GetTimeframe() {
+			continue
+		}
+		t.Target = target
+		t.TargetDisplay = nil
+		if t.Warning != nil && *t.Warning <= target {
+			log.Printf("Removing the warning %g%% of %s, which is not above its new target %g%%", *t.Warning, slo.GetName(), target)
+			t.Warning = nil
+			t.WarningDisplay = nil
+		}
+	}
+	slo.SetThresholds(thresholds)
+	return slo, current.GetTarget() / 100.0, nil
+}
@@ -0,0 +1,175 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	datadogV1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/rluisr/vigil/model"
+)
+
+// groupBy matches the group-by clause of a metric query, e.g. "by {shard}".
+var groupBy = regexp.MustCompile(`\bby\s*\{[^}]+\}`)
+
+// groupSLI is the SLI of one group of a grouped SLO, reported as its own row with --dd-groups.
+type groupSLI struct {
+	slo datadogV1.ServiceLevelObjective
+	// group is the tags of the group, e.g. shard:3 or env:prod,region:us1.
+	group string
+}
+
+// groupSLOs returns an SLO per group of a grouped SLO: each group of a metric SLO whose queries have a group-by
+// clause, found by querying its total events over the window, and each monitor group a monitor SLO is scoped to.
+// Other SLOs have no groups.
+func (c *Client) groupSLOs(ctx context.Context, slo *model.SLO, ddSLO datadogV1.ServiceLevelObjective) ([]*model.SLO, error) {
+	var groups []string
+	switch ddSLO.GetType() {
+	case datadogV1.SLOTYPE_METRIC:
+		query := ddSLO.GetQuery()
+		if !groupBy.MatchString(query.GetDenominator()) {
+			return nil, nil
+		}
+		now := time.Now().UTC()
+		series, err := c.queryMetrics(ctx, query.GetDenominator(), now.Add(-c.Window).Unix(), now.Unix())
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			groups = append(groups, groupName(s))
+		}
+	case datadogV1.SLOTYPE_MONITOR:
+		groups = ddSLO.GetGroups()
+	}
+	slices.Sort(groups)
+	groups = slices.Compact(groups)
+
+	var slos []*model.SLO
+	for _, group := range groups {
+		labels := make(map[string]string, len(slo.Labels))
+		maps.Copy(labels, slo.Labels)
+		maps.Copy(labels, tagLabels(strings.Split(group, ",")))
+		slos = append(slos, &model.SLO{
+			Name:        slo.Name + "/" + group,
+			DisplayName: fmt.Sprintf("%s [%s]", slo.DisplayName, group),
+			Goal:        slo.Goal,
+			Service:     slo.Service,
+			Labels:      labels,
+			ConsoleURL:  slo.ConsoleURL,
+			SLI:         groupSLI{slo: ddSLO, group: group},
+		})
+	}
+	return slos, nil
+}
+
+// groupErrorBudget returns the good ratio of one group of a grouped SLO: for metric SLOs from the numerator and
+// denominator queries of the group, for monitor SLOs from the history of the group.
+func (c *Client) groupErrorBudget(ctx context.Context, slo *model.SLO, g groupSLI, fromTs, toTs int64) (string, string, []model.Point, []float64, error) {
+	var (
+		good, total string
+		points      []model.Point
+		weights     []float64
+	)
+	switch g.slo.GetType() {
+	case datadogV1.SLOTYPE_METRIC:
+		query := g.slo.GetQuery()
+		good, total = query.GetNumerator(), query.GetDenominator()
+		numerator, err := c.queryMetrics(ctx, good, fromTs, toTs)
+		if err != nil {
+			return "", "", nil, nil, err
+		}
+		denominator, err := c.queryMetrics(ctx, total, fromTs, toTs)
+		if err != nil {
+			return "", "", nil, nil, err
+		}
+		points, weights = groupRatio(numerator, denominator, g.group)
+	case datadogV1.SLOTYPE_MONITOR:
+		data, err := c.sloHistory(ctx, g.slo.GetId(), fromTs, toTs)
+		if err != nil {
+			return "", "", nil, nil, err
+		}
+		good = fmt.Sprintf("monitor_ids: %v, group: %s", g.slo.GetMonitorIds(), g.group)
+		total = fmt.Sprintf("type: %s", g.slo.GetType())
+		for _, m := range data.GetGroups() {
+			if m.GetGroup() == g.group || m.GetName() == g.group {
+				var down []interval
+				points, down = monitorPoints(m.GetHistory(), time.Unix(toTs, 0).UTC())
+				c.storeDownPeriods(slo, down)
+				break
+			}
+		}
+	default:
+		return "", "", nil, nil, fmt.Errorf("unsupported SLO type: %s", g.slo.GetType())
+	}
+
+	if len(points) == 0 {
+		return good, total, nil, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
+	}
+	return good, total, points, weights, nil
+}
+
+// groupRatio returns the ratio of the numerator to the denominator of group at each time both have a point, with the
+// denominator as weight. Points without events are left out.
+func groupRatio(numerator, denominator []datadogV1.MetricsQueryMetadata, group string) ([]model.Point, []float64) {
+	good := make(map[float64]float64)
+	for _, s := range numerator {
+		if groupName(s) == group {
+			for _, p := range s.GetPointlist() {
+				if len(p) == 2 && p[0] != nil && p[1] != nil {
+					good[*p[0]] = *p[1]
+				}
+			}
+		}
+	}
+
+	var (
+		points  []model.Point
+		weights []float64
+	)
+	for _, s := range denominator {
+		if groupName(s) != group {
+			continue
+		}
+		for _, p := range s.GetPointlist() {
+			if len(p) != 2 || p[0] == nil || p[1] == nil || *p[1] == 0 {
+				continue
+			}
+			// A group without good events at a time has no numerator point there.
+			points = append(points, model.Point{Time: time.UnixMilli(int64(*p[0])).UTC(), Value: good[*p[0]] / *p[1]})
+			weights = append(weights, *p[1])
+		}
+	}
+	return points, weights
+}
+
+// groupName identifies the group of a metric series by its tags, e.g. shard:3.
+func groupName(s datadogV1.MetricsQueryMetadata) string {
+	tags := slices.Clone(s.GetTagSet())
+	slices.Sort(tags)
+	if len(tags) == 0 {
+		return s.GetScope()
+	}
+	return strings.Join(tags, ",")
+}
+
+// queryMetrics runs a metric query over a period, returning one series per group of its group-by clause.
+func (c *Client) queryMetrics(ctx context.Context, query string, fromTs, toTs int64) ([]datadogV1.MetricsQueryMetadata, error) {
+	var resp datadogV1.MetricsQueryResponse
+	err := c.retry(ctx, func() (*http.Response, error) {
+		var (
+			httpResp *http.Response
+			err      error
+		)
+		resp, httpResp, err = c.metrics.QueryMetrics(c.apiContext(ctx), fromTs, toTs, query)
+		return httpResp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics %s: %w", query, err)
+	}
+	return resp.GetSeries(), nil
+}
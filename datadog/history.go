@@ -0,0 +1,163 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	datadogV1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+// historyChunk is the longest period fetched from the SLO history endpoint at once. Longer windows are split, since
+// the endpoint lowers the resolution of long periods and can reject them.
+const historyChunk = 30 * 24 * time.Hour
+
+// sloHistory fetches the history of an SLO with its corrections applied. Periods longer than historyChunk are fetched
+// in chunks, in parallel under the rate limit of the client, and stitched together.
+func (c *Client) sloHistory(ctx context.Context, id string, fromTs, toTs int64) (datadogV1.SLOHistoryResponseData, error) {
+	var chunks [][2]int64
+	for from := fromTs; from < toTs; from += int64(historyChunk.Seconds()) {
+		chunks = append(chunks, [2]int64{from, min(from+int64(historyChunk.Seconds()), toTs)})
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, [2]int64{fromTs, toTs})
+	}
+
+	data := make([]datadogV1.SLOHistoryResponseData, len(chunks))
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data[i], errs[i] = c.sloHistoryChunk(ctx, id, chunk[0], chunk[1])
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return datadogV1.SLOHistoryResponseData{}, err
+		}
+	}
+	return mergeHistory(data), nil
+}
+
+// sloHistoryChunk fetches the history of an SLO over a single period.
+func (c *Client) sloHistoryChunk(ctx context.Context, id string, fromTs, toTs int64) (datadogV1.SLOHistoryResponseData, error) {
+	opts := datadogV1.NewGetSLOHistoryOptionalParameters().WithApplyCorrection(true)
+	var resp datadogV1.SLOHistoryResponse
+	err := c.retry(ctx, func() (*http.Response, error) {
+		var (
+			httpResp *http.Response
+			err      error
+		)
+		resp, httpResp, err = c.api.GetSLOHistory(c.apiContext(ctx), id, fromTs, toTs, *opts)
+		return httpResp, err
+	})
+	if err != nil {
+		return datadogV1.SLOHistoryResponseData{}, fmt.Errorf("failed to get SLO history: %w", err)
+	}
+	return resp.GetData(), nil
+}
+
+// mergeHistory stitches the histories of consecutive periods into one: the metric series and the state transitions of
+// the SLO, its monitors and its groups are concatenated, leaving out the points a chunk repeats from the previous one.
+// The other fields are those of the last chunk.
+func mergeHistory(chunks []datadogV1.SLOHistoryResponseData) datadogV1.SLOHistoryResponseData {
+	merged := chunks[len(chunks)-1]
+	if len(chunks) == 1 {
+		return merged
+	}
+	merged.FromTs = chunks[0].FromTs
+
+	var series *datadogV1.SLOHistoryMetrics
+	var history [][]float64
+	groups := mergeMonitors(chunks, (*datadogV1.SLOHistoryResponseData).GetGroups)
+	monitors := mergeMonitors(chunks, (*datadogV1.SLOHistoryResponseData).GetMonitors)
+	for _, chunk := range chunks {
+		if s, ok := chunk.GetSeriesOk(); ok {
+			if series == nil {
+				series = new(datadogV1.SLOHistoryMetrics)
+				*series = *s
+				series.Times, series.Numerator.Values, series.Denominator.Values = nil, nil, nil
+			}
+			appendSeries(series, s)
+		}
+		if o, ok := chunk.GetOverallOk(); ok {
+			history = appendHistory(history, o.GetHistory())
+		}
+	}
+	if series != nil {
+		series.Numerator.Count, series.Denominator.Count = int64(len(series.Numerator.Values)), int64(len(series.Denominator.Values))
+		series.Numerator.Sum, series.Denominator.Sum = sum(series.Numerator.Values), sum(series.Denominator.Values)
+	}
+	merged.Series = series
+	if o, ok := merged.GetOverallOk(); ok {
+		overall := *o
+		overall.History = history
+		merged.Overall = &overall
+	}
+	merged.Groups = groups
+	merged.Monitors = monitors
+	return merged
+}
+
+// appendSeries appends the points of s after the last time of series.
+func appendSeries(series, s *datadogV1.SLOHistoryMetrics) {
+	for i, t := range s.Times {
+		if n := len(series.Times); n > 0 && t <= series.Times[n-1] {
+			continue
+		}
+		if i >= len(s.Numerator.Values) || i >= len(s.Denominator.Values) {
+			break
+		}
+		series.Times = append(series.Times, t)
+		series.Numerator.Values = append(series.Numerator.Values, s.Numerator.Values[i])
+		series.Denominator.Values = append(series.Denominator.Values, s.Denominator.Values[i])
+	}
+}
+
+// appendHistory appends the state transitions of next after the last one of history.
+func appendHistory(history, next [][]float64) [][]float64 {
+	for _, entry := range next {
+		if len(entry) == 0 {
+			continue
+		}
+		if n := len(history); n > 0 && len(history[n-1]) > 0 && entry[0] <= history[n-1][0] {
+			continue
+		}
+		history = append(history, entry)
+	}
+	return history
+}
+
+// mergeMonitors concatenates the histories of the monitors or groups of each chunk by name, in the order they first
+// appear.
+func mergeMonitors(chunks []datadogV1.SLOHistoryResponseData, get func(*datadogV1.SLOHistoryResponseData) []datadogV1.SLOHistoryMonitor) []datadogV1.SLOHistoryMonitor {
+	var merged []datadogV1.SLOHistoryMonitor
+	index := make(map[string]int)
+	for i := range chunks {
+		for _, m := range get(&chunks[i]) {
+			key := m.GetGroup() + "\x00" + m.GetName()
+			j, ok := index[key]
+			if !ok {
+				index[key] = len(merged)
+				m.History = appendHistory(nil, m.GetHistory())
+				merged = append(merged, m)
+				continue
+			}
+			merged[j].History = appendHistory(merged[j].History, m.GetHistory())
+		}
+	}
+	return merged
+}
+
+func sum(values []float64) float64 {
+	var s float64
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	datadogV1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"github.com/rluisr/vigil/model"
+)
+
+// monitorLookup is a monitor fetched once for every SLO it backs.
+type monitorLookup struct {
+	once    sync.Once
+	monitor datadogV1.Monitor
+	found   bool
+	err     error
+}
+
+// MonitorIssues returns the monitors of a monitor SLO that keep it from seeing bad time: muted, in whole or for some
+// of their groups, in "No Data", or deleted. Each monitor is fetched once. Other SLOs have no issues.
+func (c *Client) MonitorIssues(ctx context.Context, slo *model.SLO) ([]string, error) {
+	var ddSLO datadogV1.ServiceLevelObjective
+	switch sli := slo.SLI.(type) {
+	case datadogV1.ServiceLevelObjective:
+		ddSLO = sli
+	case groupSLI:
+		ddSLO = sli.slo
+	}
+	if ddSLO.GetType() != datadogV1.SLOTYPE_MONITOR {
+		return nil, nil
+	}
+
+	var issues []string
+	now := time.Now()
+	for _, id := range ddSLO.GetMonitorIds() {
+		monitor, found, err := c.monitor(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			issues = append(issues, fmt.Sprintf("monitor %d deleted", id))
+			continue
+		}
+		if muted, whole := monitorMuted(monitor, now); muted && whole {
+			issues = append(issues, fmt.Sprintf("monitor %d muted", id))
+		} else if muted {
+			issues = append(issues, fmt.Sprintf("monitor %d partly muted", id))
+		}
+		if monitor.GetOverallState() == datadogV1.MONITOROVERALLSTATES_NO_DATA {
+			issues = append(issues, fmt.Sprintf("monitor %d no data", id))
+		}
+	}
+	return issues, nil
+}
+
+// monitorMuted reports whether a monitor is muted at now, by a downtime or by its legacy silenced option, and whether
+// the mute covers the whole monitor rather than some of its groups.
+func monitorMuted(monitor datadogV1.Monitor, now time.Time) (muted, whole bool) {
+	options := monitor.GetOptions()
+	for scope, end := range options.GetSilenced() {
+		if end != 0 && time.Unix(end, 0).Before(now) {
+			continue
+		}
+		muted = true
+		whole = whole || scope == "*"
+	}
+	for _, d := range monitor.GetMatchingDowntimes() {
+		if d.Start != nil && time.Unix(*d.Start, 0).After(now) {
+			continue
+		}
+		if end := d.End.Get(); end != nil && time.Unix(*end, 0).Before(now) {
+			continue
+		}
+		muted = true
+		whole = whole || len(d.Scope) == 0 || slices.Contains(d.Scope, "*")
+	}
+	return muted, whole
+}
+
+// monitor fetches a monitor with its matching downtimes once. found is false for deleted monitors.
+func (c *Client) monitor(ctx context.Context, id int64) (datadogV1.Monitor, bool, error) {
+	stored, _ := c.monitors.LoadOrStore(id, &monitorLookup{})
+	lookup := stored.(*monitorLookup)
+	lookup.once.Do(func() {
+		opts := datadogV1.NewGetMonitorOptionalParameters().WithWithDowntimes(true)
+		notFound := false
+		lookup.err = c.retry(ctx, func() (*http.Response, error) {
+			var (
+				httpResp *http.Response
+				err      error
+			)
+			lookup.monitor, httpResp, err = c.monitorsAPI.GetMonitor(c.apiContext(ctx), id, *opts)
+			notFound = httpResp != nil && httpResp.StatusCode == http.StatusNotFound
+			return httpResp, err
+		})
+		if notFound {
+			lookup.err = nil
+			return
+		}
+		if lookup.err != nil {
+			lookup.err = fmt.Errorf("failed to get monitor %d: %w", id, lookup.err)
+			return
+		}
+		lookup.found = true
+	})
+	return lookup.monitor, lookup.found, lookup.err
+}
@@ -0,0 +1,72 @@
+package datadog
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimit throttles the requests of a client to the Datadog API: at most a fixed number run at once, and none are
+// sent until a rate limit window that a response reported as exhausted resets.
+type rateLimit struct {
+	sem chan struct{}
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func newRateLimit(concurrency int) *rateLimit {
+	return &rateLimit{sem: make(chan struct{}, max(concurrency, 1))}
+}
+
+// acquire waits for a free request slot and for the end of an exhausted rate limit window.
+func (r *rateLimit) acquire(ctx context.Context) error {
+	select {
+	case r.sem <- struct{}{}:
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+
+	r.mu.Lock()
+	wait := time.Until(r.until)
+	r.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		r.release()
+		return context.Cause(ctx)
+	}
+}
+
+func (r *rateLimit) release() {
+	<-r.sem
+}
+
+// observe reads the X-RateLimit headers of a response. When the response used up the window or was rejected with 429
+// Too Many Requests, the next requests wait until the window resets, and observe returns that wait; otherwise 0.
+func (r *rateLimit) observe(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0
+	}
+	reset, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Reset"))
+	if err != nil || reset <= 0 {
+		return 0
+	}
+
+	wait := time.Duration(reset) * time.Second
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if until := time.Now().Add(wait); until.After(r.until) {
+		r.until = until
+	}
+	return wait
+}
@@ -0,0 +1,84 @@
+package datadog
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+func init() {
+	provider.Register(model.CloudProviderDD, &factory{})
+}
+
+type factory struct {
+	site        string
+	tags        string
+	groups      bool
+	downtimes   bool
+	concurrency int
+}
+
+func (f *factory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.site, "dd-site", "", "datadog site. e.g. datadoghq.com, ap1.datadoghq.com, datadoghq.eu. defaults to DD_SITE")
+	fs.BoolVar(&f.groups, "dd-groups", false, "also report each group of grouped SLOs, metric SLOs with a group-by and monitor SLOs scoped to groups, as its own row")
+	fs.StringVar(&f.tags, "dd-tags", "", "only audit the SLOs with all of these tags, comma separated. e.g. team:payments,env:prod")
+	fs.IntVar(&f.concurrency, "dd-concurrency", 4, "maximum concurrent requests to the Datadog API. the SLO history endpoint has a low rate limit")
+	fs.BoolVar(&f.downtimes, "dd-downtimes", false, "report the share of the bad time of monitor SLOs that fell inside a scheduled downtime of their monitors")
+}
+
+func (f *factory) Validate() error {
+	if _, ok := os.LookupEnv("DD_API_KEY"); !ok {
+		return errors.New("DD_API_KEY environment variable is required for Datadog")
+	}
+	if _, ok := os.LookupEnv("DD_APP_KEY"); !ok {
+		return errors.New("DD_APP_KEY environment variable is required for Datadog")
+	}
+	if site := cmp.Or(f.site, os.Getenv("DD_SITE")); site != "" && !slices.Contains(Sites, site) {
+		return fmt.Errorf("--dd-site must be one of %s, got %q", strings.Join(Sites, ", "), site)
+	}
+	if f.concurrency < 1 {
+		return errors.New("--dd-concurrency must be at least 1")
+	}
+	return nil
+}
+
+func (f *factory) New(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+	var transport http.RoundTripper
+	if opts.Cassette != nil {
+		transport = opts.Cassette.Transport(http.DefaultTransport)
+	}
+	client, err := NewClient(ctx, f.site, opts.ErrorBudgetThreshold, opts.Window, f.concurrency, transport)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range strings.Split(f.tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			client.Tags = append(client.Tags, tag)
+		}
+	}
+	client.Groups = f.groups
+	client.Downtimes = f.downtimes
+	return client, nil
+}
+
+func (f *factory) Target() string {
+	var scope []string
+	for _, s := range []string{cmp.Or(f.site, os.Getenv("DD_SITE")), f.tags} {
+		if s != "" {
+			scope = append(scope, s)
+		}
+	}
+	if len(scope) > 0 {
+		return fmt.Sprintf("Datadog (%s)", strings.Join(scope, ", "))
+	}
+	return "Datadog"
+}
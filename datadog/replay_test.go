@@ -0,0 +1,136 @@
+package datadog
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil"
+	"github.com/rluisr/vigil/replay"
+)
+
+// testdata/slo.json holds the API calls of a 90 day scan: two metric SLOs with several thresholds and a monitor SLO
+// whose monitor reports no data. The window is longer than the 30 days a history request may span, so each history
+// comes in three chunks, each repeating the last day of the previous one.
+func TestReplay(t *testing.T) {
+	cassette, err := replay.Load("testdata/slo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DD_API_KEY", "replay")
+	t.Setenv("DD_APP_KEY", "replay")
+	ctx := context.Background()
+	const window = 90 * 24 * time.Hour
+	client, err := NewClient(ctx, "", 0.9, window, 4, cassette.Transport(http.DefaultTransport))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := vigil.Run(ctx, client, vigil.Options{Window: window, ContinueOnError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Failures) > 0 || len(result.Warnings) > 0 {
+		t.Fatalf("got failures %v and warnings %v", result.Failures, result.Warnings)
+	}
+
+	tests := []struct {
+		key           string
+		name          string
+		goal          float64
+		category      model.Category
+		points        int
+		minBudget     float64
+		good, total   string
+		team          string
+		monitorIssues []string
+	}{
+		{
+			// The 90d threshold matches the window, over the 7d and 30d ones.
+			key:       "8f1d7a3c2b5e4f6a9c0d1e2f3a4b5c6d",
+			name:      "checkout availability",
+			goal:      0.99,
+			category:  model.CategoryLax,
+			points:    91,
+			minBudget: 0.97,
+			good:      "sum:trace.http.request.hits{service:checkout,!http.status_class:5xx}.as_count()",
+			total:     "sum:trace.http.request.hits{service:checkout}.as_count()",
+			team:      "payments",
+		},
+		{
+			// Without a 90d threshold, the 30d one is the closest to the window.
+			key:       "1a2b3c4d5e6f708192a3b4c5d6e7f809",
+			name:      "search latency",
+			goal:      0.995,
+			category:  model.CategoryLax,
+			points:    91,
+			minBudget: 0.9982,
+			good:      "sum:search.requests.fast{env:prod}.as_count()",
+			total:     "sum:search.requests{env:prod}.as_count()",
+			team:      "discovery",
+		},
+		{
+			// The uptime of the monitor runs up to the time of the scan, so only the shape of its points is checked.
+			key:           "f0e1d2c3b4a5968778695a4b3c2d1e0f",
+			name:          "api uptime",
+			goal:          0.999,
+			category:      model.CategoryNoData,
+			points:        5,
+			good:          "monitor_ids: [1001]",
+			total:         "type: monitor",
+			team:          "platform",
+			monitorIssues: []string{"monitor 1001 no data"},
+		},
+	}
+
+	if len(result.SLOs) != len(tests) {
+		t.Errorf("got %d SLOs, want %d", len(result.SLOs), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := result.SLOs[tt.key]
+			if !ok {
+				t.Fatalf("no SLO %s", tt.key)
+			}
+			if v.DisplayName != tt.name {
+				t.Errorf("DisplayName = %q, want %q", v.DisplayName, tt.name)
+			}
+			if !near(v.SLO, tt.goal) {
+				t.Errorf("SLO = %g, want %g", v.SLO, tt.goal)
+			}
+			if v.Category != tt.category {
+				t.Errorf("Category = %s, want %s", v.Category, tt.category)
+			}
+			if len(v.Points) != tt.points {
+				t.Errorf("got %d points, want %d", len(v.Points), tt.points)
+			}
+			if tt.minBudget != 0 && !near(v.MinBudget, tt.minBudget) {
+				t.Errorf("MinBudget = %g, want %g", v.MinBudget, tt.minBudget)
+			}
+			for i := 1; i < len(v.Timestamps); i++ {
+				if !v.Timestamps[i].After(v.Timestamps[i-1]) {
+					t.Fatalf("points of the chunks are not merged in order: %s after %s", v.Timestamps[i], v.Timestamps[i-1])
+				}
+			}
+			if v.GoodQuery != tt.good {
+				t.Errorf("GoodQuery = %q, want %q", v.GoodQuery, tt.good)
+			}
+			if v.TotalQuery != tt.total {
+				t.Errorf("TotalQuery = %q, want %q", v.TotalQuery, tt.total)
+			}
+			if v.Team != tt.team {
+				t.Errorf("Team = %q, want %q", v.Team, tt.team)
+			}
+			if !slices.Equal(v.MonitorIssues, tt.monitorIssues) {
+				t.Errorf("MonitorIssues = %v, want %v", v.MonitorIssues, tt.monitorIssues)
+			}
+		})
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
@@ -0,0 +1,177 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	datadogV1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	datadogV2 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+	"github.com/rluisr/vigil/model"
+)
+
+// maxSlicesPerQuery is the number of slices asked of the timeseries API at once, a day of one minute slices. Longer
+// windows are queried in several requests, so the API does not roll the slices up into coarser points.
+const maxSlicesPerQuery = 1440
+
+// defaultSliceInterval is the slice length of time slice SLOs that do not set query_interval_seconds.
+const defaultSliceInterval = 5 * time.Minute
+
+// timeSliceCondition returns the condition of a time slice SLO and the length of its slices.
+func timeSliceCondition(ddSLO datadogV1.ServiceLevelObjective) (datadogV1.SLOTimeSliceCondition, time.Duration, bool) {
+	spec := ddSLO.GetSliSpecification()
+	if spec.SLOTimeSliceSpec == nil {
+		return datadogV1.SLOTimeSliceCondition{}, 0, false
+	}
+	cond := spec.SLOTimeSliceSpec.TimeSlice
+	slice := defaultSliceInterval
+	if cond.QueryIntervalSeconds != nil {
+		slice = time.Duration(*cond.QueryIntervalSeconds) * time.Second
+	}
+	return cond, slice, true
+}
+
+// describeTimeSlice renders the condition of a time slice SLO as its good and total queries, e.g.
+// "query1 < 0.5 per 5m0s slice, query1 = p95:trace.http.request{service:web}" and "every 5m0s slice".
+func describeTimeSlice(cond datadogV1.SLOTimeSliceCondition, slice time.Duration) (string, string) {
+	formula := ""
+	if formulas := cond.Query.Formulas; len(formulas) > 0 {
+		formula = formulas[0].Formula
+	}
+	var queries []string
+	for _, q := range cond.Query.Queries {
+		if m := q.FormulaAndFunctionMetricQueryDefinition; m != nil {
+			queries = append(queries, fmt.Sprintf("%s = %s", m.Name, m.Query))
+			if formula == "" {
+				formula = m.Name
+			}
+		}
+	}
+	good := fmt.Sprintf("%s %s %g per %s slice", formula, cond.Comparator, cond.Threshold, slice)
+	if len(queries) > 0 {
+		good += ", " + strings.Join(queries, ", ")
+	}
+	return good, fmt.Sprintf("every %s slice", slice)
+}
+
+// processTimeSliceSLO evaluates the condition of a time slice SLO on each slice of its window, from fromTs to toTs,
+// and returns the share of good slices since the start of the window at the end of each slice. Slices without data
+// are good, as Datadog counts them as uptime.
+func (c *Client) processTimeSliceSLO(ctx context.Context, ddSLO datadogV1.ServiceLevelObjective, fromTs, toTs int64) (string, string, []model.Point, error) {
+	cond, slice, ok := timeSliceCondition(ddSLO)
+	if !ok {
+		return "", "", nil, fmt.Errorf("time slice SLO %s has no time slice condition", ddSLO.GetName())
+	}
+	good, total := describeTimeSlice(cond, slice)
+
+	from := time.Unix(fromTs, 0).UTC().Truncate(slice)
+	to := time.Unix(toTs, 0).UTC()
+	var (
+		points []model.Point
+		bad    int
+	)
+	for start := from; start.Before(to); start = start.Add(maxSlicesPerQuery * slice) {
+		end := start.Add(maxSlicesPerQuery * slice)
+		if end.After(to) {
+			end = to
+		}
+		attrs, err := c.queryTimeSlices(ctx, cond, slice, start, end)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		for i, t := range attrs.GetTimes() {
+			sliceEnd := time.UnixMilli(t).UTC().Add(slice)
+			if sliceEnd.After(to) {
+				sliceEnd = to
+			}
+			if badSlice(attrs.GetValues(), i, cond) {
+				bad++
+			}
+			elapsed := sliceEnd.Sub(from).Seconds() / slice.Seconds()
+			if elapsed <= 0 {
+				continue
+			}
+			points = append(points, model.Point{Time: sliceEnd, Value: max(0, 1-float64(bad)/elapsed)})
+		}
+	}
+	return good, total, points, nil
+}
+
+// badSlice reports whether the slice at index i fails the condition in any of the series of values. Missing values
+// are no data, which never fails.
+func badSlice(values [][]*float64, i int, cond datadogV1.SLOTimeSliceCondition) bool {
+	for _, series := range values {
+		if i >= len(series) || series[i] == nil {
+			continue
+		}
+		v := *series[i]
+		var ok bool
+		switch cond.Comparator {
+		case datadogV1.SLOTIMESLICECOMPARATOR_GREATER:
+			ok = v > cond.Threshold
+		case datadogV1.SLOTIMESLICECOMPARATOR_GREATER_EQUAL:
+			ok = v >= cond.Threshold
+		case datadogV1.SLOTIMESLICECOMPARATOR_LESS:
+			ok = v < cond.Threshold
+		case datadogV1.SLOTIMESLICECOMPARATOR_LESS_EQUAL:
+			ok = v <= cond.Threshold
+		}
+		if !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// queryTimeSlices runs the query of a time slice condition from start to end with one point per slice.
+func (c *Client) queryTimeSlices(ctx context.Context, cond datadogV1.SLOTimeSliceCondition, slice time.Duration, start, end time.Time) (datadogV2.TimeseriesResponseAttributes, error) {
+	var queries []datadogV2.TimeseriesQuery
+	for _, q := range cond.Query.Queries {
+		if m := q.FormulaAndFunctionMetricQueryDefinition; m != nil {
+			name := m.Name
+			queries = append(queries, datadogV2.MetricsTimeseriesQueryAsTimeseriesQuery(&datadogV2.MetricsTimeseriesQuery{
+				DataSource: datadogV2.METRICSDATASOURCE_METRICS,
+				Name:       &name,
+				Query:      m.Query,
+			}))
+		}
+	}
+	var formulas []datadogV2.QueryFormula
+	for _, f := range cond.Query.Formulas {
+		formulas = append(formulas, datadogV2.QueryFormula{Formula: f.Formula})
+	}
+	interval := slice.Milliseconds()
+	body := datadogV2.TimeseriesFormulaQueryRequest{
+		Data: datadogV2.TimeseriesFormulaRequest{
+			Attributes: datadogV2.TimeseriesFormulaRequestAttributes{
+				Formulas: formulas,
+				From:     start.UnixMilli(),
+				Interval: &interval,
+				Queries:  queries,
+				To:       end.UnixMilli(),
+			},
+			Type: datadogV2.TIMESERIESFORMULAREQUESTTYPE_TIMESERIES_REQUEST,
+		},
+	}
+
+	var resp datadogV2.TimeseriesFormulaQueryResponse
+	err := c.retry(ctx, func() (*http.Response, error) {
+		var (
+			httpResp *http.Response
+			err      error
+		)
+		resp, httpResp, err = c.timeseries.QueryTimeseriesData(c.apiContext(ctx), body)
+		return httpResp, err
+	})
+	if err != nil {
+		return datadogV2.TimeseriesResponseAttributes{}, fmt.Errorf("failed to query time slices: %w", err)
+	}
+	if resp.Errors != nil && *resp.Errors != "" {
+		return datadogV2.TimeseriesResponseAttributes{}, fmt.Errorf("failed to query time slices: %s", *resp.Errors)
+	}
+	data := resp.GetData()
+	return data.GetAttributes(), nil
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/store"
+)
+
+//go:embed templates/diff.html
+var diffTemplate string
+
+// defaultDiffOutput keeps the diff report apart from the scan report when --output is not given.
+const defaultDiffOutput = "slo_diff.{{.Format}}"
+
+// Kinds of change reported by vigil diff.
+const (
+	changeFlagged  = "flagged"
+	changeResolved = "resolved"
+	changeAdded    = "added"
+	changeDeleted  = "deleted"
+	changeStats    = "changed"
+)
+
+// changeOrder is the order the kinds of change are listed in, the ones a review cares most about first.
+var changeOrder = []string{changeFlagged, changeResolved, changeAdded, changeDeleted, changeStats}
+
+// diffRun is one side of vigil diff: a run of the --store database or a JSON report.
+type diffRun struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"startedAt"`
+	stats     map[string]store.SLOStats
+}
+
+// sloChange is an SLO that changed between the two runs. Before is nil for added SLOs and After for deleted ones.
+// The changes of the stats are set for SLOs in both runs evaluated over the same window.
+type sloChange struct {
+	Key               string              `json:"key"`
+	DisplayName       string              `json:"displayName"`
+	Project           string              `json:"project,omitempty"`
+	Provider          model.CloudProvider `json:"provider"`
+	Change            string              `json:"change"`
+	Before            *store.SLOStats     `json:"before,omitempty"`
+	After             *store.SLOStats     `json:"after,omitempty"`
+	MinBudgetChange   *float64            `json:"minBudgetChange,omitempty"`
+	AvgBudgetChange   *float64            `json:"avgBudgetChange,omitempty"`
+	HealthScoreChange *float64            `json:"healthScoreChange,omitempty"`
+}
+
+// diffReport is the JSON diff report.
+type diffReport struct {
+	GeneratedAt time.Time   `json:"generatedAt"`
+	From        diffRun     `json:"from"`
+	To          diffRun     `json:"to"`
+	Changes     []sloChange `json:"changes"`
+}
+
+// runDiff compares two runs instead of scanning: newly flagged, resolved, added and deleted SLOs, and the SLOs whose
+// category changed or whose budget moved by at least --min-change. It returns exitFlagged when an SLO got flagged
+// with --fail-on-flag.
+func runDiff(args []string) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(runtime.Error); ok {
+				panic(r)
+			}
+			code = exitError
+		}
+	}()
+
+	_ = flag.CommandLine.Parse(args)
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if err := cfg.applyFlags(); err != nil {
+		log.Panicf("%v", err)
+	}
+	switch *format {
+	case formatXLSX, formatJSON, formatHTML, formatPDF, formatTable:
+	default:
+		log.Panicf("vigil %s supports --format %s, %s, %s, %s or %s", cmdDiff, formatXLSX, formatJSON, formatHTML, formatPDF, formatTable)
+	}
+	if *minChange < 0 || *minChange > 1 {
+		log.Panicf("--min-change must be between 0 and 1")
+	}
+	if !i18n.Supported(i18n.Lang(*lang)) {
+		log.Panicf("--lang must be 'en' or 'ja'")
+	}
+	if !isFlagSet("output") {
+		*output = defaultDiffOutput
+	}
+
+	from, to := loadDiffRuns(context.Background(), flag.CommandLine.Args())
+	changes := diffRuns(from, to, *minChange)
+
+	msgs := i18n.Get(i18n.Lang(*lang))
+	switch *format {
+	case formatTable:
+		printDiff(from, to, changes, msgs)
+	default:
+		path, err := outputPath(nil, time.Now())
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		switch *format {
+		case formatJSON:
+			generateDiffJSON(from, to, changes, path)
+		case formatHTML:
+			generateDiffHTML(from, to, changes, msgs, path)
+		case formatPDF:
+			// The standard PDF fonts have no CJK glyphs, so the PDF is always rendered in English.
+			generateDiffPDF(from, to, changes, i18n.Get(i18n.LangEN), path)
+		default:
+			generateDiffExcel(from, to, changes, msgs, path)
+		}
+		infof("Diff report written to %s", path)
+	}
+
+	if *failOnFlag && countChanges(changes)[changeFlagged] > 0 {
+		return exitFlagged
+	}
+	return exitOK
+}
+
+// loadDiffRuns loads the runs named by the arguments of vigil diff, each a run ID of the --store database or the
+// path of a JSON report. Without arguments the last two stored runs are compared, and with one the run is compared
+// to the last stored run. Those are the runs of --target when it is given.
+func loadDiffRuns(ctx context.Context, args []string) (diffRun, diffRun) {
+	if len(args) > 2 {
+		log.Panicf("Usage: vigil %s [flags] [run-a [run-b]]", cmdDiff)
+	}
+
+	var (
+		st   *store.Store
+		runs []store.Run
+		// latest are the runs the last ones are taken from.
+		latest []store.Run
+	)
+	if *storePath != "" {
+		var err error
+		st, err = store.Open(*storePath)
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		defer func() {
+			if err := st.Close(); err != nil {
+				log.Printf("Failed to close %s: %v", *storePath, err)
+			}
+		}()
+		runs, err = st.Runs(ctx)
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		latest = runs
+		if *targetName != "" {
+			latest = slices.DeleteFunc(slices.Clone(runs), func(r store.Run) bool { return r.Target != *targetName })
+		}
+	}
+
+	stored := func(run store.Run) diffRun {
+		stats, err := st.Stats(ctx, run.ID)
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		return diffRun{Name: fmt.Sprintf("run %d", run.ID), StartedAt: run.StartedAt, stats: stats}
+	}
+	load := func(arg string) diffRun {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return loadJSONRun(arg)
+		}
+		if st == nil {
+			log.Panicf("%s is a stored run: give its database with --store", arg)
+		}
+		i := slices.IndexFunc(runs, func(r store.Run) bool { return r.ID == id })
+		if i < 0 {
+			log.Panicf("run %d is not in %s", id, *storePath)
+		}
+		return stored(runs[i])
+	}
+
+	if len(args) == 2 {
+		return load(args[0]), load(args[1])
+	}
+	if st == nil {
+		log.Panicf("vigil %s needs two runs, or --store to compare with the stored runs", cmdDiff)
+	}
+	if len(args) == 1 {
+		if len(latest) == 0 {
+			log.Panicf("%s has no stored runs", *storePath)
+		}
+		return load(args[0]), stored(latest[len(latest)-1])
+	}
+	if len(latest) < 2 {
+		log.Panicf("%s has %d stored runs: at least two are needed", *storePath, len(latest))
+	}
+	return stored(latest[len(latest)-2]), stored(latest[len(latest)-1])
+}
+
+// loadJSONRun reads the SLOs of a JSON report written with --format json.
+func loadJSONRun(path string) diffRun {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Panicf("Failed to read %s: %v", path, err)
+	}
+	var report struct {
+		GeneratedAt time.Time        `json:"generatedAt"`
+		SLOs        []*model.SLOData `json:"slos"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		log.Panicf("%s is not a JSON report: %v", path, err)
+	}
+
+	run := diffRun{Name: path, StartedAt: report.GeneratedAt, stats: make(map[string]store.SLOStats, len(report.SLOs))}
+	for _, v := range report.SLOs {
+		run.stats[v.Key] = store.StatsOf(v)
+	}
+	return run
+}
+
+// diffRuns returns the changes of the SLOs from one run to the other, in changeOrder and then by name. SLOs in both
+// runs that kept their flag are listed when their category changed or their minimum or average budget moved by at
+// least minChange.
+func diffRuns(from, to diffRun, minChange float64) []sloChange {
+	var changes []sloChange
+	for key, after := range to.stats {
+		c := sloChange{Key: key, DisplayName: after.DisplayName, Project: after.Project, Provider: after.Provider, After: &after}
+		before, ok := from.stats[key]
+		if !ok {
+			c.Change = changeAdded
+			changes = append(changes, c)
+			continue
+		}
+		c.Before = &before
+		if before.Window == after.Window {
+			minBudget, avgBudget, score := after.MinBudget-before.MinBudget, after.AvgBudget-before.AvgBudget, after.HealthScore-before.HealthScore
+			c.MinBudgetChange, c.AvgBudgetChange, c.HealthScoreChange = &minBudget, &avgBudget, &score
+		}
+
+		moved := func(change *float64) bool { return change != nil && math.Abs(*change) >= minChange }
+		switch {
+		case after.Flag && !before.Flag:
+			c.Change = changeFlagged
+		case !after.Flag && before.Flag:
+			c.Change = changeResolved
+		case after.Category != before.Category || moved(c.MinBudgetChange) || moved(c.AvgBudgetChange):
+			c.Change = changeStats
+		default:
+			continue
+		}
+		changes = append(changes, c)
+	}
+	for key, before := range from.stats {
+		if _, ok := to.stats[key]; !ok {
+			changes = append(changes, sloChange{Key: key, DisplayName: before.DisplayName, Project: before.Project, Provider: before.Provider, Change: changeDeleted, Before: &before})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b sloChange) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(changeOrder, a.Change), slices.Index(changeOrder, b.Change)),
+			cmp.Compare(a.DisplayName, b.DisplayName),
+			cmp.Compare(a.Key, b.Key),
+		)
+	})
+	return changes
+}
+
+// countChanges returns the number of changes of each kind.
+func countChanges(changes []sloChange) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range changes {
+		counts[c.Change]++
+	}
+	return counts
+}
+
+// diffSummary is the summary line of the diff reports.
+func diffSummary(changes []sloChange, msgs *i18n.Messages) string {
+	counts := countChanges(changes)
+	return fmt.Sprintf(msgs.DiffSummary, counts[changeFlagged], counts[changeResolved], counts[changeAdded], counts[changeDeleted], counts[changeStats])
+}
+
+// diffDescription names the compared runs, e.g. "Changes of the SLOs from run 3 (2026-10-09 06:00) to run 4 (...)".
+func diffDescription(from, to diffRun, msgs *i18n.Messages) string {
+	name := func(r diffRun) string {
+		if r.StartedAt.IsZero() {
+			return r.Name
+		}
+		return fmt.Sprintf("%s (%s)", r.Name, r.StartedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return fmt.Sprintf(msgs.DiffDescription, name(from), name(to))
+}
+
+// changeLabel is the localized kind of a change.
+func changeLabel(change string, msgs *i18n.Messages) string {
+	switch change {
+	case changeFlagged:
+		return msgs.ChangeFlagged
+	case changeResolved:
+		return msgs.ChangeResolved
+	case changeAdded:
+		return msgs.ChangeAdded
+	case changeDeleted:
+		return msgs.ChangeDeleted
+	default:
+		return msgs.ChangeStats
+	}
+}
+
+// changeCategory is the category whose colors fill the row of a change: burning for newly flagged SLOs and healthy
+// for resolved ones. Other changes are not filled.
+func changeCategory(c sloChange) model.Category {
+	switch c.Change {
+	case changeFlagged:
+		return model.CategoryBurning
+	case changeResolved:
+		return model.CategoryHealthy
+	default:
+		return ""
+	}
+}
+
+// diffHeader is the header of the diff table shared by the table, HTML and PDF reports.
+func diffHeader(msgs *i18n.Messages) []string {
+	return []string{msgs.HeaderChange, msgs.HeaderName, msgs.HeaderProject, msgs.HeaderCategory, msgs.HeaderSLIMin,
+		msgs.HeaderMinBudgetChange, msgs.HeaderAvgBudgetChange, msgs.HeaderHealthScoreChange}
+}
+
+// diffCells renders a change as the cells of diffHeader. The category and minimum budget show both runs, e.g.
+// "HEALTHY -> LAX" and "95.00% -> 82.50%".
+func diffCells(c sloChange, msgs *i18n.Messages) []string {
+	percent := func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) }
+	transition := func(value func(*store.SLOStats) string) string {
+		switch {
+		case c.Before == nil:
+			return value(c.After)
+		case c.After == nil:
+			return value(c.Before)
+		}
+		before, after := value(c.Before), value(c.After)
+		if before == after {
+			return after
+		}
+		return before + " -> " + after
+	}
+	change := func(v *float64, format string) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprintf(format, *v)
+	}
+	return []string{
+		changeLabel(c.Change, msgs),
+		c.DisplayName,
+		c.Project,
+		transition(func(st *store.SLOStats) string { return categoryLabel(st.Category, msgs) }),
+		transition(func(st *store.SLOStats) string { return percent(st.MinBudget) }),
+		change(scaled(c.MinBudgetChange, 100), "%+.2f%%"),
+		change(scaled(c.AvgBudgetChange, 100), "%+.2f%%"),
+		change(c.HealthScoreChange, "%+.0f"),
+	}
+}
+
+// scaled returns v multiplied by factor, nil when v is nil.
+func scaled(v *float64, factor float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	s := *v * factor
+	return &s
+}
+
+func generateDiffJSON(from, to diffRun, changes []sloChange, output string) {
+	report := diffReport{
+		GeneratedAt: time.Now().UTC(),
+		From:        from,
+		To:          to,
+		Changes:     append([]sloChange{}, changes...),
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		log.Panicf("Failed to create file: %v", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Panicf("Failed to write JSON report: %v", err)
+	}
+}
+
+func generateDiffHTML(from, to diffRun, changes []sloChange, msgs *i18n.Messages, output string) {
+	tmpl := template.Must(template.New("diff").Funcs(template.FuncMap{
+		"categoryStyle": func(c model.Category) template.CSS {
+			colors := categoryColors[c]
+			return template.CSS(fmt.Sprintf("background: #%s; color: #%s", colors[0], colors[1]))
+		},
+	}).Parse(diffTemplate))
+
+	type htmlChange struct {
+		Cells    []string
+		Category model.Category
+	}
+	page := struct {
+		Lang        i18n.Lang
+		Msgs        *i18n.Messages
+		Description string
+		Summary     string
+		GeneratedAt string
+		Header      []string
+		Changes     []htmlChange
+	}{
+		Lang:        i18n.Lang(*lang),
+		Msgs:        msgs,
+		Description: diffDescription(from, to, msgs),
+		Summary:     diffSummary(changes, msgs),
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Header:      diffHeader(msgs),
+	}
+	for _, c := range changes {
+		page.Changes = append(page.Changes, htmlChange{Cells: diffCells(c, msgs), Category: changeCategory(c)})
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		log.Panicf("Failed to create file: %v", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	if err := tmpl.Execute(f, page); err != nil {
+		log.Panicf("Failed to write HTML page: %v", err)
+	}
+}
+
+// printDiff prints the changes as a table on stdout, newly flagged SLOs in red.
+func printDiff(from, to diffRun, changes []sloChange, msgs *i18n.Messages) {
+	rows := [][]string{diffHeader(msgs)}
+	for _, c := range changes {
+		rows = append(rows, diffCells(c, msgs))
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	out := os.Stdout
+	fmt.Fprintln(out, diffDescription(from, to, msgs))
+	fmt.Fprintln(out)
+	color := useColor(out)
+	for i, row := range rows {
+		var style string
+		switch {
+		case i == 0:
+			style = ansiBold
+		case changes[i-1].Change == changeFlagged:
+			style = ansiRed
+		case changes[i-1].Change == changeResolved:
+			style = ansiGreen
+		}
+		writeTableRow(out, row, widths, style, color)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, diffSummary(changes, msgs))
+}
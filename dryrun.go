@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+// printPlan prints the effective configuration and the SLOs a run would scan, without fetching any time series.
+func printPlan(slos []*model.SLO, sloClients map[*model.SLO]Vigil, cfg *config) {
+	w := os.Stdout
+	path := "stdout"
+	if *format != formatTable && *format != formatGitHub {
+		var err error
+		if path, err = renderOutput(slos, time.Now()); err != nil {
+			path = err.Error()
+		}
+	}
+
+	var checks []string
+	for _, c := range vigilOptions(cfg).Checks {
+		checks = append(checks, c.Name())
+	}
+	settings := [][2]string{
+		{"Targets", reportTarget()},
+		{"Error budget threshold", fmt.Sprintf("%g%%", *errorBudgetThreshold*100)},
+		{"Window", windowsFlag{window, &extraWindows}.String()},
+		{"Negative budget fraction", fmt.Sprintf("%g%%", *negativeBudgetFraction*100)},
+		{"Minimum points", strconv.Itoa(*minPoints)},
+		{"Trim", fmt.Sprintf("%g%%", *trim*100)},
+		{"Checks", strings.Join(checks, ",")},
+		{"Format", *format},
+		{"Output", path},
+		{"Include", strings.Join(includePatterns, " ")},
+		{"Exclude", strings.Join(excludePatterns, " ")},
+		{"Overrides", strconv.Itoa(len(cfg.Overrides))},
+		{"Concurrency", strconv.Itoa(maxConcurrency)},
+	}
+	fmt.Fprintln(w, "Configuration:")
+	for _, s := range settings {
+		if s[1] != "" {
+			fmt.Fprintf(w, "  %-24s%s\n", s[0]+":", s[1])
+		}
+	}
+	fmt.Fprintln(w)
+
+	rows := [][]string{{"PROVIDER", "PROJECT", "SERVICE", "SLO", "THRESHOLD", "WINDOW", "NEGATIVE"}}
+	services := make(map[string]bool)
+	calls := 0
+	for _, slo := range slos {
+		client := sloClients[slo]
+		s := cfg.settings(slo)
+		rows = append(rows, []string{
+			string(client.GetProvider()), slo.Project, slo.Service, slo.DisplayName,
+			fmt.Sprintf("%g%%", s.ErrorBudgetThreshold*100), s.Window.String(), fmt.Sprintf("%g%%", s.NegativeBudgetFraction*100),
+		})
+
+		if slo.Service != "" {
+			services[string(client.GetProvider())+"/"+slo.Project+"/"+slo.Service] = true
+		}
+		if c, ok := client.(provider.CallCounter); ok {
+			calls += c.TimeSeriesCalls(slo) * len(windows())
+		} else {
+			calls += len(windows())
+		}
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	for _, row := range rows {
+		var b strings.Builder
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(cell + strings.Repeat(" ", widths[i]-displayWidth(cell)))
+		}
+		fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d SLOs in %d services would be scanned with about %d time series API calls.\n", len(slos), len(services), calls)
+}
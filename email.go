@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+)
+
+// smtpsPort is the port of SMTP over implicit TLS. Other ports start in plain text and upgrade with STARTTLS.
+const smtpsPort = "465"
+
+// emailReport sends the summary of the run to the --email-to recipients, with the report attached when there is a
+// file. The SMTP credentials come from SMTP_USERNAME and SMTP_PASSWORD.
+func emailReport(ctx context.Context, data map[string]*model.SLOData, msgs *i18n.Messages, path string) {
+	from, err := mail.ParseAddress(*emailFrom)
+	if err != nil {
+		log.Panicf("Invalid --email-from: %v", err)
+	}
+	to, err := mail.ParseAddressList(*emailTo)
+	if err != nil {
+		log.Panicf("Invalid --email-to: %v", err)
+	}
+
+	msg, err := buildEmail(from, to, data, msgs, path)
+	if err != nil {
+		log.Panicf("Failed to build the email: %v", err)
+	}
+	var recipients []string
+	for _, a := range to {
+		recipients = append(recipients, a.Address)
+	}
+	if err := sendEmail(ctx, *smtpServer, from.Address, recipients, msg); err != nil {
+		log.Panicf("Failed to send the report to %s through %s: %v", *emailTo, *smtpServer, err)
+	}
+	infof("Report has been emailed to %s", *emailTo)
+}
+
+// buildEmail builds a multipart message whose text body is the summary and worst offenders of the run, followed by
+// the report file as an attachment when path is set.
+func buildEmail(from *mail.Address, to []*mail.Address, data map[string]*model.SLOData, msgs *i18n.Messages, path string) ([]byte, error) {
+	summary := summarize(data, time.Now())
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\n\n", reportDescription(msgs))
+	for _, r := range summary.rows(msgs) {
+		fmt.Fprintf(&body, "%s: %s\n", r[0], r[1])
+	}
+	if worst := worstOffenders(data, *top); len(worst) > 0 {
+		fmt.Fprintf(&body, "\n%s\n", fmt.Sprintf(msgs.SectionWorstOffenders, len(worst)))
+		for i, v := range worst {
+			fmt.Fprintf(&body, "%d. %s (%s, %s %.0f)\n", i+1, v.DisplayName, categoryLabel(v.Category, msgs), msgs.HeaderHealthScore, v.HealthScore)
+		}
+	}
+	fmt.Fprintf(&body, "\n%s\n", msgs.GeneratedBy)
+
+	var toList []string
+	for _, a := range to {
+		toList = append(toList, a.String())
+	}
+	subject := fmt.Sprintf("%s (%s): %s", msgs.ReportTitle, reportTarget(), fmt.Sprintf(msgs.Summary, summary.Flagged, summary.Scanned))
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n",
+		from, strings.Join(toList, ", "), mime.QEncoding.Encode("utf-8", subject), time.Now().Format(time.RFC1123Z), w.Boundary())
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(body.String())); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(path)})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(content)
+		// Lines of a base64 body are at most 76 characters long.
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendEmail sends msg through server, host:port, over implicit TLS on port 465 and with STARTTLS when the server
+// offers it on other ports. It authenticates with SMTP_USERNAME and SMTP_PASSWORD when they are set.
+func sendEmail(ctx context.Context, server, from string, to []string, msg []byte) error {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return err
+	}
+
+	var conn net.Conn
+	if port == smtpsPort {
+		conn, err = (&tls.Dialer{Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", server)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", server)
+	}
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && port != smtpsPort {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		if err := c.Auth(smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return fmt.Errorf("%s: %w", addr, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
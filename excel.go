@@ -0,0 +1,798 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/report"
+	"github.com/rluisr/vigil/utils"
+)
+
+// highlightColor marks the columns reviewers are expected to fill in, such as the new SLO.
+const highlightColor = "21CE9C"
+
+// maxSheetNameLength is the longest sheet name Excel accepts.
+const maxSheetNameLength = 31
+
+// Error budget charts. The series behind the charts live on a hidden sheet.
+const (
+	chartDataSheet = "chart_data"
+	chartMaxPoints = 500
+	chartRows      = 18
+	chartWidth     = 720
+	chartHeight    = 320
+)
+
+type excelStyles struct {
+	bold        int
+	highlight   int
+	description int
+	link        int
+	percent     int
+	goalPercent int
+	// negative is a conditional format style, so it can only be referenced from conditional formatting rules.
+	negative int
+	// categories fill the category cells with the color of each category.
+	categories map[model.Category]int
+}
+
+// generateExcelReport writes a summary sheet, one sheet per finding category and one sheet of findings per project
+// linked from the summary, followed by a sheet listing every SLO, a sheet of error budget charts of the flagged ones
+// and a sheet of their threshold breaches. SLOs of providers without projects are grouped by provider instead.
+func generateExcelReport(data map[string]*model.SLOData, msgs *i18n.Messages, spec *report.Spec, output string) error {
+	f := excelize.NewFile()
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	styles := excelStyles{
+		bold: createStyle(f, &excelize.Font{Bold: true}),
+		highlight: createStyle(f, &excelize.Font{Bold: true}, excelize.Fill{
+			Type:    "pattern",
+			Pattern: 1,
+			Color:   []string{highlightColor},
+		}),
+		description: createStyle(f, &excelize.Font{
+			Bold:  true,
+			Color: "DE3163",
+		}, excelize.Alignment{WrapText: true}),
+		link:        createStyle(f, &excelize.Font{Color: "1265BE", Underline: "single"}),
+		percent:     createNumFmtStyle(f, report.PercentFormat),
+		goalPercent: createNumFmtStyle(f, report.GoalPercentFormat),
+		negative: createConditionalStyle(f, &excelize.Style{
+			Font: &excelize.Font{Color: "9C0006"},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+		}),
+		categories: make(map[model.Category]int, len(categoryColors)),
+	}
+	// In a fixed order, so the same report gets the same style IDs every time.
+	for _, c := range append(slices.Clone(findingCategories), model.CategoryHealthy) {
+		colors := categoryColors[c]
+		styles.categories[c] = createStyle(f, &excelize.Font{Color: colors[1]}, excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{colors[0]}})
+	}
+
+	byCategory := make(map[model.Category][]*model.SLOData)
+	for _, v := range data {
+		byCategory[v.Category] = append(byCategory[v.Category], v)
+	}
+	reserved := []string{msgs.SheetSummary, msgs.SheetAllSLOs, msgs.SheetCharts, msgs.SheetBreaches, msgs.SheetErrors, chartDataSheet}
+	for _, c := range findingCategories {
+		reserved = append(reserved, categoryLabel(c, msgs))
+	}
+
+	groups := make(map[string][]*model.SLOData)
+	for _, v := range data {
+		groups[excelGroup(v, msgs)] = append(groups[excelGroup(v, msgs)], v)
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	handleError(f.SetSheetName("Sheet1", msgs.SheetSummary), "Failed to rename sheet")
+	sheets := sheetNames(keys, reserved...)
+
+	writeSummarySheet(f, msgs.SheetSummary, data, summarize(data, reportTime()), keys, groups, sheets, styles, msgs)
+	for _, c := range findingCategories {
+		if len(byCategory[c]) == 0 {
+			continue
+		}
+		sheet := categoryLabel(c, msgs)
+		_, err := f.NewSheet(sheet)
+		handleError(err, "Failed to create sheet")
+		handleError(f.SetSheetProps(sheet, &excelize.SheetPropsOptions{TabColorRGB: &[]string{categoryColors[c][0]}[0]}), "Failed to set tab color")
+		writeSLOSheet(f, sheet, byCategory[c], spec, styles, msgs)
+	}
+	for _, k := range keys {
+		_, err := f.NewSheet(sheets[k])
+		handleError(err, "Failed to create sheet")
+		writeSLOSheet(f, sheets[k], groups[k], spec, styles, msgs)
+	}
+	_, err := f.NewSheet(msgs.SheetAllSLOs)
+	handleError(err, "Failed to create sheet")
+	writeAllSLOsSheet(f, msgs.SheetAllSLOs, data, spec, styles, msgs)
+	writeChartsSheet(f, msgs.SheetCharts, data, msgs)
+	writeBreachesSheet(f, msgs.SheetBreaches, data, spec, styles, msgs)
+	writeErrorsSheet(f, msgs.SheetErrors, spec, styles, msgs)
+
+	setProperty(f, msgs)
+	f.SetActiveSheet(0)
+
+	if err := f.SaveAs(output); err != nil {
+		return fmt.Errorf("failed to save %s: %w", output, err)
+	}
+	return nil
+}
+
+// writeSummarySheet writes the headline numbers and the worst offenders followed by links to the per-group sheets.
+func writeSummarySheet(f *excelize.File, sheet string, data map[string]*model.SLOData, summary reportSummary, keys []string, groups map[string][]*model.SLOData, sheets map[string]string, styles excelStyles, msgs *i18n.Messages) {
+	setColWidth(f, sheet, map[string]float64{
+		"A": 50,
+		"B": 40,
+		"C": 12,
+	})
+	setSheetView(f, sheet)
+	setCellWithStyle(f, sheet, "A1", reportDescription(msgs), styles.description)
+	setCellWithStyle(f, sheet, "A2", msgs.GeneratedBy, styles.description)
+
+	row := 4
+	for _, r := range summary.rows(msgs) {
+		setCellWithStyle(f, sheet, fmt.Sprintf("A%d", row), r[0], styles.bold)
+		setCellValue(f, sheet, fmt.Sprintf("B%d", row), r[1])
+		row++
+	}
+	row++
+
+	if offenders := worstOffenders(data, *top); len(offenders) > 0 {
+		setCellWithStyle(f, sheet, fmt.Sprintf("A%d", row), fmt.Sprintf(msgs.SectionWorstOffenders, len(offenders)), styles.bold)
+		setCellWithStyle(f, sheet, fmt.Sprintf("B%d", row), msgs.HeaderHealthScore, styles.bold)
+		setCellWithStyle(f, sheet, fmt.Sprintf("C%d", row), msgs.HeaderCategory, styles.bold)
+		row++
+		for i, v := range offenders {
+			setCellValue(f, sheet, fmt.Sprintf("A%d", row), fmt.Sprintf("%d. %s", i+1, v.DisplayName))
+			setCellValue(f, sheet, fmt.Sprintf("B%d", row), math.Round(v.HealthScore))
+			setCellWithStyle(f, sheet, fmt.Sprintf("C%d", row), categoryLabel(v.Category, msgs), styles.categories[v.Category])
+			row++
+		}
+		row++
+	}
+
+	header := msgs.HeaderGroup
+	if *groupBy == groupByTeam {
+		header = msgs.HeaderTeam
+	}
+	setCellWithStyle(f, sheet, fmt.Sprintf("A%d", row), header, styles.bold)
+	setCellWithStyle(f, sheet, fmt.Sprintf("B%d", row), msgs.HeaderSLOCount, styles.bold)
+	setCellWithStyle(f, sheet, fmt.Sprintf("C%d", row), msgs.HeaderFlag, styles.bold)
+	row++
+
+	for _, k := range keys {
+		flagged := 0
+		for _, v := range groups[k] {
+			if v.Flag {
+				flagged++
+			}
+		}
+
+		cell := fmt.Sprintf("A%d", row)
+		setCellWithStyle(f, sheet, cell, k, styles.link)
+		handleError(f.SetCellHyperLink(sheet, cell, fmt.Sprintf("'%s'!A1", sheets[k]), "Location"), "Failed to set hyperlink")
+		setCellValue(f, sheet, fmt.Sprintf("B%d", row), len(groups[k]))
+		setCellValue(f, sheet, fmt.Sprintf("C%d", row), flagged)
+		row++
+	}
+}
+
+// writeSLOSheet writes the findings, every SLO but the healthy ones, of one group using the columns of spec.
+func writeSLOSheet(f *excelize.File, sheet string, slos []*model.SLOData, spec *report.Spec, styles excelStyles, msgs *i18n.Messages) {
+	sortSLOs(slos)
+
+	setSheetView(f, sheet)
+	setCellWithStyle(f, sheet, "A1", reportDescription(msgs), styles.description)
+	setCellWithStyle(f, sheet, "F1", msgs.GeneratedBy, styles.description)
+
+	cellStyles := make([]int, len(spec.Columns))
+	var minCol, avgCol string
+	for i, c := range spec.Columns {
+		col := columnName(i + 1)
+		if c.Width > 0 {
+			setColWidth(f, sheet, map[string]float64{col: c.Width})
+		}
+
+		headerStyle := styles.bold
+		if c.Highlight {
+			headerStyle = styles.highlight
+		}
+		setCellWithStyle(f, sheet, col+"2", c.Header, headerStyle)
+		cellStyles[i] = columnStyle(f, c, styles)
+
+		switch {
+		case c.Is("minBudget"):
+			minCol = col
+		case c.Is("avgBudget"):
+			avgCol = col
+		}
+	}
+
+	row := 3
+	for _, v := range slos {
+		if v.Category == model.CategoryHealthy {
+			continue
+		}
+		for i, c := range spec.Columns {
+			value, err := c.Value(v)
+			if err != nil {
+				log.Panicf("Failed to render report column: %v", err)
+			}
+			if value == nil && !c.Highlight {
+				continue
+			}
+			if c.Is("consoleUrl") {
+				setConsoleLink(f, sheet, cellName(i+1, row, false), v.ConsoleURL, styles, msgs)
+				continue
+			}
+			setCellWithStyle(f, sheet, cellName(i+1, row, false), value, cellStyles[i])
+		}
+		row++
+	}
+	setHeaderOptions(f, sheet, spec, 2, len(spec.Columns), row-1)
+	if row > 3 {
+		var minRange, avgRange string
+		if minCol != "" {
+			minRange = fmt.Sprintf("%s3:%s%d", minCol, minCol, row-1)
+		}
+		if avgCol != "" {
+			avgRange = fmt.Sprintf("%s3:%s%d", avgCol, avgCol, row-1)
+		}
+		setBudgetFormats(f, sheet, minRange, avgRange, styles)
+	}
+}
+
+// columnStyle returns the cell style of a report column, combining its highlight and number format.
+func columnStyle(f *excelize.File, c *report.Column, styles excelStyles) int {
+	if c.NumFmt == "" {
+		if c.Highlight {
+			return styles.highlight
+		}
+		return 0
+	}
+
+	style := &excelize.Style{CustomNumFmt: &c.NumFmt}
+	if c.Highlight {
+		style.Font = &excelize.Font{Bold: true}
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{highlightColor}}
+	}
+	styleID, err := f.NewStyle(style)
+	handleError(err, "Failed to create style")
+	return styleID
+}
+
+// writeAllSLOsSheet lists every SLO, flagged or not, so reviewers can also see what passed.
+func writeAllSLOsSheet(f *excelize.File, sheet string, data map[string]*model.SLOData, spec *report.Spec, styles excelStyles, msgs *i18n.Messages) {
+	slos := make([]*model.SLOData, 0, len(data))
+	for _, v := range data {
+		slos = append(slos, v)
+	}
+	sortSLOs(slos)
+
+	setColWidth(f, sheet, map[string]float64{
+		"A":   50,
+		"B-C": 20,
+		"D":   10,
+		"E":   14,
+		"F-I": 10,
+		"J-K": 50,
+		"L":   20,
+	})
+	setSheetView(f, sheet)
+
+	headers := []string{
+		msgs.HeaderName,
+		msgs.HeaderProject,
+		msgs.HeaderProvider,
+		msgs.HeaderFlag,
+		msgs.HeaderCategory,
+		msgs.HeaderSLO,
+		msgs.HeaderSLIMin,
+		msgs.HeaderSLIAvg,
+		msgs.HeaderNegative,
+		msgs.HeaderGoodQuery,
+		msgs.HeaderTotalQuery,
+		msgs.HeaderConsoleLink,
+	}
+	for i, h := range headers {
+		setCellWithStyle(f, sheet, fmt.Sprintf("%c1", 'A'+i), h, styles.bold)
+	}
+
+	for i, v := range slos {
+		row := i + 2
+		setCellValue(f, sheet, fmt.Sprintf("A%d", row), v.DisplayName)
+		setCellValue(f, sheet, fmt.Sprintf("B%d", row), v.Project)
+		setCellValue(f, sheet, fmt.Sprintf("C%d", row), string(v.Provider))
+		setCellValue(f, sheet, fmt.Sprintf("D%d", row), v.Flag)
+		setCellWithStyle(f, sheet, fmt.Sprintf("E%d", row), categoryLabel(v.Category, msgs), styles.categories[v.Category])
+		setCellWithStyle(f, sheet, fmt.Sprintf("F%d", row), v.SLO, styles.goalPercent)
+		setCellWithStyle(f, sheet, fmt.Sprintf("G%d", row), v.MinBudget, styles.percent)
+		setCellWithStyle(f, sheet, fmt.Sprintf("H%d", row), v.AvgBudget, styles.percent)
+		setCellWithStyle(f, sheet, fmt.Sprintf("I%d", row), v.NegativeFraction, styles.percent)
+		setCellValue(f, sheet, fmt.Sprintf("J%d", row), v.GoodQuery)
+		setCellValue(f, sheet, fmt.Sprintf("K%d", row), v.TotalQuery)
+		setConsoleLink(f, sheet, fmt.Sprintf("L%d", row), v.ConsoleURL, styles, msgs)
+	}
+	setHeaderOptions(f, sheet, spec, 1, len(headers), len(slos)+1)
+	if len(slos) > 0 {
+		setBudgetFormats(f, sheet, fmt.Sprintf("G2:G%d", len(slos)+1), fmt.Sprintf("H2:H%d", len(slos)+1), styles)
+	}
+}
+
+// writeBreachesSheet lists the periods the budget of each flagged SLO stayed below its threshold, one row per
+// breach, so they can be correlated with incidents. The sheet is left out when there are none.
+func writeBreachesSheet(f *excelize.File, sheet string, data map[string]*model.SLOData, spec *report.Spec, styles excelStyles, msgs *i18n.Messages) {
+	slos := make([]*model.SLOData, 0, len(data))
+	for _, v := range data {
+		if len(v.Breaches) > 0 {
+			slos = append(slos, v)
+		}
+	}
+	if len(slos) == 0 {
+		return
+	}
+	sortSLOs(slos)
+
+	_, err := f.NewSheet(sheet)
+	handleError(err, "Failed to create sheet")
+	setColWidth(f, sheet, map[string]float64{
+		"A":   50,
+		"B":   20,
+		"C-D": 20,
+		"E-F": 14,
+	})
+	setSheetView(f, sheet)
+
+	dateTime := createNumFmtStyle(f, report.DateTimeFormat)
+	hours := createNumFmtStyle(f, report.HoursFormat)
+	headers := []string{msgs.HeaderName, msgs.HeaderProject, msgs.HeaderBreachStart, msgs.HeaderBreachEnd, msgs.HeaderBreachHours, msgs.HeaderSLIMin}
+	for i, h := range headers {
+		setCellWithStyle(f, sheet, fmt.Sprintf("%c1", 'A'+i), h, styles.bold)
+	}
+	row := 1
+	for _, v := range slos {
+		for _, b := range v.Breaches {
+			row++
+			setCellValue(f, sheet, fmt.Sprintf("A%d", row), v.DisplayName)
+			setCellValue(f, sheet, fmt.Sprintf("B%d", row), v.Project)
+			setCellWithStyle(f, sheet, fmt.Sprintf("C%d", row), b.Start, dateTime)
+			if b.End.IsZero() {
+				setCellValue(f, sheet, fmt.Sprintf("D%d", row), msgs.BreachOngoing)
+			} else {
+				setCellWithStyle(f, sheet, fmt.Sprintf("D%d", row), b.End, dateTime)
+			}
+			setCellWithStyle(f, sheet, fmt.Sprintf("E%d", row), b.Hours, hours)
+			setCellWithStyle(f, sheet, fmt.Sprintf("F%d", row), b.MinBudget, styles.percent)
+		}
+	}
+	setHeaderOptions(f, sheet, spec, 1, len(headers), row)
+}
+
+// writeErrorsSheet lists the SLOs that failed with --continue-on-error. The sheet is left out when none failed.
+func writeErrorsSheet(f *excelize.File, sheet string, spec *report.Spec, styles excelStyles, msgs *i18n.Messages) {
+	failures := sortedFailures()
+	if len(failures) == 0 {
+		return
+	}
+
+	_, err := f.NewSheet(sheet)
+	handleError(err, "Failed to create sheet")
+	setColWidth(f, sheet, map[string]float64{
+		"A": 50,
+		"B": 20,
+		"C": 100,
+	})
+	setSheetView(f, sheet)
+
+	headers := []string{msgs.HeaderName, msgs.HeaderProvider, msgs.HeaderError}
+	for i, h := range headers {
+		setCellWithStyle(f, sheet, fmt.Sprintf("%c1", 'A'+i), h, styles.bold)
+	}
+	for i, e := range failures {
+		row := i + 2
+		setCellValue(f, sheet, fmt.Sprintf("A%d", row), e.DisplayName)
+		setCellValue(f, sheet, fmt.Sprintf("B%d", row), string(e.Provider))
+		setCellValue(f, sheet, fmt.Sprintf("C%d", row), e.Error)
+	}
+	setHeaderOptions(f, sheet, spec, 1, len(headers), len(failures)+1)
+}
+
+// setHeaderOptions freezes the rows down to headerRow and adds an auto filter over the header and the rows below it,
+// as enabled by the spec.
+func setHeaderOptions(f *excelize.File, sheet string, spec *report.Spec, headerRow, cols, lastRow int) {
+	if spec.FreezeHeader {
+		topLeft := cellName(1, headerRow+1, false)
+		handleError(f.SetPanes(sheet, &excelize.Panes{
+			Freeze:      true,
+			YSplit:      headerRow,
+			TopLeftCell: topLeft,
+			ActivePane:  "bottomLeft",
+			Selection:   []excelize.Selection{{SQRef: topLeft, ActiveCell: topLeft, Pane: "bottomLeft"}},
+		}), "Failed to freeze panes")
+	}
+	if spec.AutoFilter {
+		rangeRef := fmt.Sprintf("%s:%s", cellName(1, headerRow, false), cellName(cols, max(lastRow, headerRow), false))
+		handleError(f.AutoFilter(sheet, rangeRef, nil), "Failed to set auto filter")
+	}
+}
+
+// setConsoleLink writes a hyperlink to the SLO in the provider's console, leaving the cell empty when there is none.
+func setConsoleLink(f *excelize.File, sheet, cell, link string, styles excelStyles, msgs *i18n.Messages) {
+	if link == "" {
+		return
+	}
+	setCellWithStyle(f, sheet, cell, msgs.ConsoleLinkText, styles.link)
+	handleError(f.SetCellHyperLink(sheet, cell, link, "External"), "Failed to set hyperlink")
+}
+
+// setBudgetFormats highlights the worst offenders: a red to green color scale on the SLI Min column,
+// data bars on the SLI Avg column and a red fill on negative budgets in both. An empty range is skipped.
+func setBudgetFormats(f *excelize.File, sheet, minRange, avgRange string, styles excelStyles) {
+	negative := excelize.ConditionalFormatOptions{
+		Type:       "cell",
+		Criteria:   "<",
+		Value:      "0",
+		Format:     &styles.negative,
+		StopIfTrue: true,
+	}
+
+	if minRange != "" {
+		handleError(f.SetConditionalFormat(sheet, minRange, []excelize.ConditionalFormatOptions{
+			negative,
+			{
+				Type:     "3_color_scale",
+				Criteria: "=",
+				MinType:  "min",
+				MidType:  "percentile",
+				MidValue: "50",
+				MaxType:  "max",
+				MinColor: "#F8696B",
+				MidColor: "#FFEB84",
+				MaxColor: "#63BE7B",
+			},
+		}), "Failed to set conditional format")
+	}
+	if avgRange != "" {
+		handleError(f.SetConditionalFormat(sheet, avgRange, []excelize.ConditionalFormatOptions{
+			negative,
+			{
+				Type:     "data_bar",
+				Criteria: "=",
+				MinType:  "min",
+				MaxType:  "max",
+				BarColor: "#638EC6",
+			},
+		}), "Failed to set conditional format")
+	}
+}
+
+// writeChartsSheet embeds a line chart of the error budget time series of every flagged SLO, with the threshold for reference.
+func writeChartsSheet(f *excelize.File, sheet string, data map[string]*model.SLOData, msgs *i18n.Messages) {
+	var flagged []*model.SLOData
+	for _, v := range data {
+		if v.Flag && len(v.Points) > 0 {
+			flagged = append(flagged, v)
+		}
+	}
+	if len(flagged) == 0 {
+		return
+	}
+	sortSLOs(flagged)
+
+	_, err := f.NewSheet(sheet)
+	handleError(err, "Failed to create sheet")
+	_, err = f.NewSheet(chartDataSheet)
+	handleError(err, "Failed to create sheet")
+	handleError(f.SetSheetVisible(chartDataSheet, false), "Failed to hide sheet")
+
+	for i, v := range flagged {
+		points := utils.Downsample(v.Points, chartMaxPoints)
+		budgetCol, thresholdCol := 2*i+1, 2*i+2
+
+		setCellValue(f, chartDataSheet, cellName(budgetCol, 1, false), v.DisplayName)
+		setCellValue(f, chartDataSheet, cellName(thresholdCol, 1, false), msgs.ChartThreshold)
+		for j, p := range points {
+			setCellValue(f, chartDataSheet, cellName(budgetCol, j+2, false), p)
+			setCellValue(f, chartDataSheet, cellName(thresholdCol, j+2, false), v.ErrorBudgetThreshold)
+		}
+
+		series := func(col int) excelize.ChartSeries {
+			return excelize.ChartSeries{
+				Name:   fmt.Sprintf("'%s'!%s", chartDataSheet, cellName(col, 1, true)),
+				Values: fmt.Sprintf("'%s'!%s:%s", chartDataSheet, cellName(col, 2, true), cellName(col, len(points)+1, true)),
+				Marker: excelize.ChartMarker{Symbol: "none"},
+			}
+		}
+		handleError(f.AddChart(sheet, cellName(1, i*chartRows+1, false), &excelize.Chart{
+			Type:      excelize.Line,
+			Series:    []excelize.ChartSeries{series(budgetCol), series(thresholdCol)},
+			Title:     []excelize.RichTextRun{{Text: v.DisplayName}},
+			Legend:    excelize.ChartLegend{Position: "bottom"},
+			Dimension: excelize.ChartDimension{Width: chartWidth, Height: chartHeight},
+			XAxis:     excelize.ChartAxis{None: true},
+			YAxis:     excelize.ChartAxis{MajorGridLines: true, NumFmt: excelize.ChartNumFmt{CustomNumFmt: "0%"}},
+		}), "Failed to add chart")
+	}
+}
+
+// excelGroup returns the sheet an SLO is listed on: its team with --group-by team, otherwise its project, or its
+// provider when it has none.
+func excelGroup(v *model.SLOData, msgs *i18n.Messages) string {
+	if *groupBy == groupByTeam {
+		return teamName(v.Team, msgs)
+	}
+	if v.Project != "" {
+		return v.Project
+	}
+	return string(v.Provider)
+}
+
+// sheetNames maps every group to a unique, valid sheet name that does not clash with the reserved ones.
+// Excel compares sheet names case-insensitively, forbids []:*?/\ and limits them to 31 characters.
+func sheetNames(keys []string, reserved ...string) map[string]string {
+	used := make(map[string]bool)
+	for _, r := range reserved {
+		used[strings.ToLower(r)] = true
+	}
+	names := make(map[string]string, len(keys))
+	for _, k := range keys {
+		base := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, k)
+		base = strings.Trim(base, "'")
+		if base == "" {
+			base = "_"
+		}
+
+		name := truncateRunes(base, maxSheetNameLength)
+		for i := 2; used[strings.ToLower(name)]; i++ {
+			suffix := fmt.Sprintf(" (%d)", i)
+			name = truncateRunes(base, maxSheetNameLength-len(suffix)) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names[k] = name
+	}
+	return names
+}
+
+// columnName converts a 1-based column number to its letters, e.g. 27 to AA.
+func columnName(col int) string {
+	name, err := excelize.ColumnNumberToName(col)
+	handleError(err, "Failed to convert column number")
+	return name
+}
+
+// cellName converts 1-based column and row numbers to a cell reference, e.g. A1, or $A$1 when absolute.
+func cellName(col, row int, absolute bool) string {
+	cell, err := excelize.CoordinatesToCellName(col, row, absolute)
+	handleError(err, "Failed to convert coordinates")
+	return cell
+}
+
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+func createStyle(f *excelize.File, font *excelize.Font, opts ...interface{}) int {
+	style := &excelize.Style{Font: font}
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case excelize.Alignment:
+			style.Alignment = &v
+		case excelize.Fill:
+			style.Fill = v
+		}
+	}
+	styleID, err := f.NewStyle(style)
+	handleError(err, "Failed to create style")
+	return styleID
+}
+
+func createNumFmtStyle(f *excelize.File, numFmt string) int {
+	styleID, err := f.NewStyle(&excelize.Style{CustomNumFmt: &numFmt})
+	handleError(err, "Failed to create style")
+	return styleID
+}
+
+func createConditionalStyle(f *excelize.File, style *excelize.Style) int {
+	styleID, err := f.NewConditionalStyle(style)
+	handleError(err, "Failed to create conditional style")
+	return styleID
+}
+
+func setProperty(f *excelize.File, msgs *i18n.Messages) {
+	err := f.SetDocProps(&excelize.DocProperties{
+		Created:        reportTime().Format(time.RFC3339),
+		Creator:        "Vigil",
+		Description:    msgs.GeneratedBy,
+		Identifier:     "xlsx",
+		LastModifiedBy: "Vigil https://github.com/rluisr/vigil",
+		Modified:       reportTime().Format(time.RFC3339),
+		Revision:       "0",
+		Subject:        msgs.ReportTitle,
+		Title:          msgs.ReportTitle,
+	})
+
+	handleError(err, "Failed to set doc properties")
+}
+
+func setSheetView(f *excelize.File, sheet string) {
+	handleError(f.SetSheetView(sheet, 0, &excelize.ViewOptions{
+		ShowGridLines: &[]bool{true}[0],
+		ZoomScale:     &[]float64{150}[0],
+	}), "Failed to set sheet view")
+}
+
+func setColWidth(f *excelize.File, sheet string, columns map[string]float64) {
+	for rangeStr, width := range columns {
+		// split range e.g B-E
+		parts := strings.SplitN(rangeStr, "-", 2)
+		startCol := parts[0]
+		endCol := startCol
+		if len(parts) > 1 {
+			endCol = parts[1]
+		}
+
+		err := f.SetColWidth(sheet, startCol, endCol, width)
+		handleError(err, "Failed to set column width")
+	}
+}
+
+func setCellWithStyle(f *excelize.File, sheet, cell string, value interface{}, styleID int) {
+	handleError(f.SetCellValue(sheet, cell, value), "Failed to set cell value")
+	handleError(f.SetCellStyle(sheet, cell, cell, styleID), "Failed to set cell style")
+}
+
+func setCellValue(f *excelize.File, sheet, cell string, value interface{}) {
+	handleError(f.SetCellValue(sheet, cell, value), "Failed to set cell value")
+}
+
+func handleError(err error, message string) {
+	if err != nil {
+		log.Panicf("%s: %v", message, err)
+	}
+}
+
+// generateCoverageExcel writes the coverage gaps of vigil coverage to a single sheet. Services without any SLO are
+// filled like burning SLOs, services missing one kind of SLO like lax ones.
+func generateCoverageExcel(gaps []coverageGap, msgs *i18n.Messages, output string) {
+	f := excelize.NewFile()
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	sheet := msgs.SheetCoverageGaps
+	handleError(f.SetSheetName("Sheet1", sheet), "Failed to rename sheet")
+	setColWidth(f, sheet, map[string]float64{
+		"A":   12,
+		"B-C": 30,
+		"D":   24,
+		"E":   8,
+	})
+	setSheetView(f, sheet)
+
+	bold := createStyle(f, &excelize.Font{Bold: true})
+	fill := func(c model.Category) int {
+		colors := categoryColors[c]
+		return createStyle(f, &excelize.Font{Color: colors[1]}, excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{colors[0]}})
+	}
+	noSLOs, missingKind := fill(model.CategoryBurning), fill(model.CategoryLax)
+
+	headers := []string{msgs.HeaderProvider, msgs.HeaderProject, msgs.HeaderService, msgs.HeaderGap, msgs.HeaderSLOCount}
+	for i, h := range headers {
+		setCellWithStyle(f, sheet, cellName(i+1, 1, false), h, bold)
+	}
+	for i, g := range gaps {
+		row := i + 2
+		setCellValue(f, sheet, cellName(1, row, false), string(g.Provider))
+		setCellValue(f, sheet, cellName(2, row, false), g.Project)
+		setCellValue(f, sheet, cellName(3, row, false), g.Service)
+		style := noSLOs
+		if g.Missing != "" {
+			style = missingKind
+		}
+		setCellWithStyle(f, sheet, cellName(4, row, false), gapLabel(g, msgs), style)
+		setCellValue(f, sheet, cellName(5, row, false), g.SLOs)
+	}
+	setHeaderOptions(f, sheet, &report.Spec{FreezeHeader: true, AutoFilter: true}, 1, len(headers), len(gaps)+1)
+
+	setProperty(f, msgs)
+	if err := f.SaveAs(output); err != nil {
+		log.Panicf("Failed to save file: %v", err)
+	}
+}
+
+// generateDiffExcel writes the changes between two runs of vigil diff to a single sheet below the runs compared.
+// Newly flagged SLOs are filled like burning SLOs and resolved ones like healthy SLOs.
+func generateDiffExcel(from, to diffRun, changes []sloChange, msgs *i18n.Messages, output string) {
+	f := excelize.NewFile()
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	sheet := msgs.SheetDiff
+	handleError(f.SetSheetName("Sheet1", sheet), "Failed to rename sheet")
+	setColWidth(f, sheet, map[string]float64{
+		"A":   16,
+		"B":   50,
+		"C":   24,
+		"D-E": 22,
+		"F-H": 14,
+	})
+	setSheetView(f, sheet)
+
+	bold := createStyle(f, &excelize.Font{Bold: true})
+	change := createNumFmtStyle(f, report.ChangeFormat)
+	score := createNumFmtStyle(f, "+0;-0;0")
+	fills := make(map[model.Category]int)
+	for _, c := range []model.Category{model.CategoryBurning, model.CategoryHealthy} {
+		colors := categoryColors[c]
+		fills[c] = createStyle(f, &excelize.Font{Color: colors[1]}, excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{colors[0]}})
+	}
+
+	setCellWithStyle(f, sheet, "A1", diffDescription(from, to, msgs), bold)
+	setCellValue(f, sheet, "A2", diffSummary(changes, msgs))
+
+	const headerRow = 4
+	headers := diffHeader(msgs)
+	for i, h := range headers {
+		setCellWithStyle(f, sheet, cellName(i+1, headerRow, false), h, bold)
+	}
+	for i, c := range changes {
+		row := headerRow + 1 + i
+		cells := diffCells(c, msgs)
+		for col := 1; col <= 5; col++ {
+			setCellValue(f, sheet, cellName(col, row, false), cells[col-1])
+		}
+		if fill, ok := fills[changeCategory(c)]; ok {
+			setCellWithStyle(f, sheet, cellName(1, row, false), cells[0], fill)
+		}
+		for col, v := range map[int]*float64{6: c.MinBudgetChange, 7: c.AvgBudgetChange, 8: c.HealthScoreChange} {
+			if v == nil {
+				continue
+			}
+			style := change
+			if col == 8 {
+				style = score
+			}
+			setCellWithStyle(f, sheet, cellName(col, row, false), *v, style)
+		}
+	}
+	setHeaderOptions(f, sheet, &report.Spec{FreezeHeader: true, AutoFilter: true}, headerRow, len(headers), headerRow+len(changes))
+
+	setProperty(f, msgs)
+	if err := f.SaveAs(output); err != nil {
+		log.Panicf("Failed to save file: %v", err)
+	}
+}
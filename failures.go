@@ -0,0 +1,28 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/rluisr/vigil/pkg/vigil"
+)
+
+// sloFailure is an SLO that could not be processed with --continue-on-error.
+type sloFailure = vigil.Failure
+
+// sortedFailures returns the recorded failures ordered by provider and name, so reports are deterministic.
+func sortedFailures() []sloFailure {
+	failures := slices.Clone(sloFailures)
+	slices.SortFunc(failures, func(a, b sloFailure) int {
+		return cmp.Or(cmp.Compare(a.Provider, b.Provider), cmp.Compare(a.DisplayName, b.DisplayName), cmp.Compare(a.Key, b.Key))
+	})
+	return failures
+}
+
+// tooManyFailures reports whether the failed fraction of the SLOs exceeds --max-error-ratio.
+func tooManyFailures(total int) bool {
+	if len(sloFailures) == 0 || total == 0 {
+		return false
+	}
+	return float64(len(sloFailures))/float64(total) > *maxErrorRatio
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// patternsFlag collects the values of a flag that can be repeated. Patterns are not comma separated
+// because regular expressions commonly contain commas.
+type patternsFlag []string
+
+func (p *patternsFlag) String() string {
+	return strings.Join(*p, " ")
+}
+
+func (p *patternsFlag) Set(v string) error {
+	*p = append(*p, v)
+	return nil
+}
+
+// matcher reports whether an SLO matches a single --include or --exclude pattern.
+type matcher func(slo *model.SLO) bool
+
+// sloFilter selects the SLOs to audit. An SLO is kept when it matches any include pattern, or there are none,
+// and matches no exclude pattern.
+type sloFilter struct {
+	include []matcher
+	exclude []matcher
+}
+
+// newSLOFilter compiles the --include and --exclude patterns.
+func newSLOFilter(include, exclude []string) (*sloFilter, error) {
+	f := &sloFilter{}
+	for _, p := range include {
+		m, err := parsePattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --include %q: %w", p, err)
+		}
+		f.include = append(f.include, m)
+	}
+	for _, p := range exclude {
+		m, err := parsePattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --exclude %q: %w", p, err)
+		}
+		f.exclude = append(f.exclude, m)
+	}
+	return f, nil
+}
+
+// parsePattern compiles one pattern. Three forms are supported:
+//
+//	/regexp/     a regular expression matched against the display name and the service
+//	key=glob     a label selector matched against the labels or tags of the SLO
+//	glob         a glob, where * matches any run of characters and ? a single one, matched against the display name and the service
+func parsePattern(p string) (matcher, error) {
+	if len(p) >= 2 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+		re, err := regexp.Compile(p[1 : len(p)-1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regexp: %w", err)
+		}
+		return func(slo *model.SLO) bool {
+			return re.MatchString(slo.DisplayName) || (slo.Service != "" && re.MatchString(slo.Service))
+		}, nil
+	}
+
+	if key, value, ok := strings.Cut(p, "="); ok {
+		if key == "" {
+			return nil, errors.New("label selector has no key")
+		}
+		re := globRegexp(value)
+		return func(slo *model.SLO) bool {
+			v, ok := slo.Labels[key]
+			return ok && re.MatchString(v)
+		}, nil
+	}
+
+	re := globRegexp(p)
+	return func(slo *model.SLO) bool {
+		return re.MatchString(slo.DisplayName) || (slo.Service != "" && re.MatchString(slo.Service))
+	}, nil
+}
+
+// globRegexp converts a glob to an anchored regular expression. Unlike path.Match, * also matches "/",
+// which is common in display names.
+func globRegexp(glob string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(glob)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// apply returns the SLOs selected by the filter.
+func (f *sloFilter) apply(slos []*model.SLO) []*model.SLO {
+	if len(f.include) == 0 && len(f.exclude) == 0 {
+		return slos
+	}
+
+	var selected []*model.SLO
+	for _, slo := range slos {
+		if f.match(slo) {
+			selected = append(selected, slo)
+		}
+	}
+	return selected
+}
+
+func (f *sloFilter) match(slo *model.SLO) bool {
+	included := len(f.include) == 0
+	for _, m := range f.include {
+		if m(slo) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, m := range f.exclude {
+		if m(slo) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/rluisr/vigil/model"
+	"google.golang.org/api/iterator"
+)
+
+// burnRateSelector matches the SLOs an alert condition selects the burn rate of, e.g.
+// select_slo_burn_rate("projects/123/services/checkout/serviceLevelObjectives/latency", "60m").
+var burnRateSelector = regexp.MustCompile(`select_slo_burn_rate\(\s*"([^"]+)"`)
+
+// Alerted reports whether an enabled alert policy of the project of slo, or of the scoping project, fires on its burn
+// rate. Policies are listed once per project, or again after a failed listing.
+func (c *Client) Alerted(ctx context.Context, slo *model.SLO) (bool, error) {
+	projects := []string{slo.Project}
+	if c.ScopingProject != "" && c.ScopingProject != slo.Project {
+		projects = append(projects, c.ScopingProject)
+	}
+
+	for _, project := range projects {
+		alerted, err := c.burnRateAlerts(ctx, project)
+		if err != nil {
+			return false, err
+		}
+		if alerted[sloID(slo.Name)] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// alertPolicies are the SLOs the enabled alert policies of a project alert on, nil until they are listed.
+type alertPolicies struct {
+	mu      sync.Mutex
+	alerted map[string]bool
+}
+
+// burnRateAlerts returns the SLOs the enabled alert policies of project alert on, by sloID. Only a successful listing
+// is kept, so a call failing on a cancelled context or a transient error does not fail the later ones. The SLOs of a
+// project wait for the listing in progress instead of listing the policies again.
+func (c *Client) burnRateAlerts(ctx context.Context, project string) (map[string]bool, error) {
+	c.alertPoliciesMu.Lock()
+	if c.alertPolicies == nil {
+		c.alertPolicies = make(map[string]*alertPolicies)
+	}
+	policies, ok := c.alertPolicies[project]
+	if !ok {
+		policies = new(alertPolicies)
+		c.alertPolicies[project] = policies
+	}
+	c.alertPoliciesMu.Unlock()
+
+	policies.mu.Lock()
+	defer policies.mu.Unlock()
+	if policies.alerted == nil {
+		alerted, err := c.listBurnRateAlerts(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		policies.alerted = alerted
+	}
+	return policies.alerted, nil
+}
+
+// listBurnRateAlerts lists the SLOs the enabled alert policies of project select the burn rate of, by sloID.
+func (c *Client) listBurnRateAlerts(ctx context.Context, project string) (map[string]bool, error) {
+	alerted := make(map[string]bool)
+	policies := c.AlertPolicyClient.ListAlertPolicies(ctx, &monitoringpb.ListAlertPoliciesRequest{
+		Name: "projects/" + project,
+	}, listRetry)
+	for {
+		policy, err := policies.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("project %s: failed to list alert policies: %w", project, err)
+		}
+		if !policy.GetEnabled().GetValue() {
+			continue
+		}
+
+		for _, condition := range policy.GetConditions() {
+			query := condition.GetConditionThreshold().GetFilter() + condition.GetConditionMonitoringQueryLanguage().GetQuery()
+			for _, m := range burnRateSelector.FindAllStringSubmatch(query, -1) {
+				alerted[sloID(m[1])] = true
+			}
+		}
+	}
+	return alerted, nil
+}
+
+// sloID returns the part of an SLO resource name after its project, services/{service}/serviceLevelObjectives/{slo},
+// since alert policies may refer to the project by number or by ID.
+func sloID(name string) string {
+	if _, id, ok := strings.Cut(name, "/services/"); ok {
+		return id
+	}
+	return name
+}
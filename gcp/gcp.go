@@ -2,129 +2,458 @@
 package gcp
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	monitoring "cloud.google.com/go/monitoring/apiv3/v2"
 	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/googleapis/gax-go/v2"
 	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+	"golang.org/x/time/rate"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// maxProjectConcurrency bounds how many projects are scanned for SLOs at the same time.
+const maxProjectConcurrency = 8
+
+// statusBurnRateLookback is the lookback of the burn rate fetched with --gcp-slo-status, the one of fast burn alerts.
+const statusBurnRateLookback = time.Hour
+
+// listRetry retries the calls listing services and SLOs on transient errors with an exponential backoff, bounded by
+// the timeout of each call. The client only retries UNAVAILABLE by default.
+var listRetry = gax.WithRetry(func() gax.Retryer {
+	return gax.OnCodes([]codes.Code{codes.Unavailable, codes.DeadlineExceeded}, gax.Backoff{
+		Initial:    500 * time.Millisecond,
+		Max:        30 * time.Second,
+		Multiplier: 2,
+	})
+})
+
+// timeSeriesRetry retries reading time series on transient errors and when the quota is exhausted, with an
+// exponential backoff and jitter, bounded by the timeout of each call. The client only retries UNAVAILABLE by default.
+var timeSeriesRetry = gax.WithRetry(func() gax.Retryer {
+	return gax.OnCodes([]codes.Code{codes.Unavailable, codes.ResourceExhausted}, gax.Backoff{
+		Initial:    time.Second,
+		Max:        time.Minute,
+		Multiplier: 2,
+	})
+})
+
+// minAlignmentPeriod is the shortest alignment period Cloud Monitoring accepts.
+const minAlignmentPeriod = time.Minute
+
+// Aligners are the per-series aligners --gcp-aligner accepts. ALIGN_MIN keeps the dips of the budget that ALIGN_MEAN
+// smooths out.
+var Aligners = map[string]monitoringpb.Aggregation_Aligner{
+	"mean": monitoringpb.Aggregation_ALIGN_MEAN,
+	"min":  monitoringpb.Aggregation_ALIGN_MIN,
+}
+
 // Client is a GCP Cloud Monitoring SLO client.
 type Client struct {
 	MonitoringClient     *monitoring.ServiceMonitoringClient
 	MetricClient         *monitoring.MetricClient
-	GCPProjectID         string
+	AlertPolicyClient    *monitoring.AlertPolicyClient
+	GCPProjectIDs        []string
 	ErrorBudgetThreshold float64
 	Window               time.Duration
+	// SLOStatus enables GetSLOStatus, which costs three more API calls per SLO.
+	SLOStatus bool
+	// AlignmentPeriod aligns the error budget series to one point per period with Aligner. Zero fetches the raw points.
+	AlignmentPeriod time.Duration
+	Aligner         monitoringpb.Aggregation_Aligner
+	// ScopingProject is the scoping project of a metrics scope the time series are queried through, so SLOs whose
+	// metrics live in another project of the scope resolve. Empty queries the project of each SLO.
+	ScopingProject string
+	// PromQL renders distribution cut SLIs as PromQL queries in the GoodQuery and TotalQuery columns.
+	PromQL bool
+
+	// alertPolicies caches the burn rate alert policies of each project once listed.
+	alertPoliciesMu sync.Mutex
+	alertPolicies   map[string]*alertPolicies
 }
 
-// NewClient creates a new GCP monitoring client.
-func NewClient(ctx context.Context, gcpProjectID string, errorBudgetThreshold float64, window time.Duration) (*Client, error) {
-	monitoringClient, err := monitoring.NewServiceMonitoringClient(ctx)
+// NewClient creates a new GCP monitoring client scanning the given projects. The metric client sends at most
+// requestsPerSecond requests per second, retries included; 0 does not limit it. opts, such as credentials, apply to
+// every client.
+func NewClient(ctx context.Context, gcpProjectIDs []string, errorBudgetThreshold float64, window time.Duration, requestsPerSecond float64, opts ...option.ClientOption) (*Client, error) {
+	monitoringClient, err := monitoring.NewServiceMonitoringClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create monitoring client: %w", err)
 	}
 
-	metricClient, err := monitoring.NewMetricClient(ctx)
+	metricOpts := slices.Clone(opts)
+	if requestsPerSecond > 0 {
+		limiter := rate.NewLimiter(rate.Limit(requestsPerSecond), int(math.Ceil(requestsPerSecond)))
+		metricOpts = append(metricOpts, option.WithGRPCDialOption(grpc.WithUnaryInterceptor(rateLimit(limiter))))
+	}
+	metricClient, err := monitoring.NewMetricClient(ctx, metricOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create metric client: %w", err)
 	}
 
+	alertPolicyClient, err := monitoring.NewAlertPolicyClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alert policy client: %w", err)
+	}
+
 	return &Client{
 		MonitoringClient:     monitoringClient,
 		MetricClient:         metricClient,
-		GCPProjectID:         gcpProjectID,
+		AlertPolicyClient:    alertPolicyClient,
+		GCPProjectIDs:        gcpProjectIDs,
 		ErrorBudgetThreshold: errorBudgetThreshold,
 		Window:               window,
 	}, nil
 }
 
+// rateLimit delays every call until limiter allows it, so long scans stay under the timeSeries.list quota instead of
+// being throttled with RESOURCE_EXHAUSTED.
+func rateLimit(limiter *rate.Limiter) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
 // GetProvider returns the GCP cloud provider identifier.
 func (c *Client) GetProvider() model.CloudProvider {
 	return model.CloudProviderGCP
 }
 
-// GetSLOs retrieves all SLOs from GCP Cloud Monitoring.
+// Close releases the underlying monitoring, metric and alert policy clients.
+func (c *Client) Close() error {
+	return errors.Join(c.MonitoringClient.Close(), c.MetricClient.Close(), c.AlertPolicyClient.Close())
+}
+
+// GetSLOs retrieves all SLOs from GCP Cloud Monitoring across every configured project concurrently. Projects and
+// services that keep failing are skipped and reported in a provider.PartialError along with the other SLOs, unless
+// nothing could be listed at all.
 func (c *Client) GetSLOs(ctx context.Context) ([]*model.SLO, error) {
-	var slos []*model.SLO
+	var (
+		slos    []*model.SLO
+		errs    []error
+		skipped []string
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+	)
+	sem := make(chan struct{}, maxProjectConcurrency)
+
+	for _, projectID := range c.GCPProjectIDs {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(projectID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			projectSLOs, projectSkipped, err := c.getProjectSLOs(ctx, projectID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("project %s: %w", projectID, err))
+				return
+			}
+			slos = append(slos, projectSLOs...)
+			skipped = append(skipped, projectSkipped...)
+		}(projectID)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 && len(slos) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	for _, err := range errs {
+		skipped = append(skipped, err.Error())
+	}
+	if len(skipped) > 0 {
+		slices.Sort(skipped)
+		return slos, &provider.PartialError{Skipped: skipped}
+	}
+
+	return slos, nil
+}
+
+// getProjectSLOs lists the SLOs of every service of a project. Services whose SLOs cannot be listed are skipped and
+// described in skipped, so one broken service does not hide the others.
+func (c *Client) getProjectSLOs(ctx context.Context, projectID string) ([]*model.SLO, []string, error) {
+	var (
+		slos    []*model.SLO
+		skipped []string
+	)
 
 	services := c.MonitoringClient.ListServices(ctx, &monitoringpb.ListServicesRequest{
-		Parent: "projects/" + c.GCPProjectID,
-	})
+		Parent: "projects/" + projectID,
+	}, listRetry)
 	for {
 		service, err := services.Next()
 		if errors.Is(err, iterator.Done) {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to list services: %w", err)
+			return nil, nil, fmt.Errorf("failed to list services: %w", err)
 		}
 
-		lSLOs := c.MonitoringClient.ListServiceLevelObjectives(ctx, &monitoringpb.ListServiceLevelObjectivesRequest{
-			Parent: service.GetName(),
+		serviceSLOs, err := c.getServiceSLOs(ctx, projectID, service)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("project %s: service %s: %v", projectID, serviceName(service), err))
+			continue
+		}
+		slos = append(slos, serviceSLOs...)
+	}
+
+	return slos, skipped, nil
+}
+
+// getServiceSLOs lists the SLOs of a service.
+func (c *Client) getServiceSLOs(ctx context.Context, projectID string, service *monitoringpb.Service) ([]*model.SLO, error) {
+	var slos []*model.SLO
+
+	lSLOs := c.MonitoringClient.ListServiceLevelObjectives(ctx, &monitoringpb.ListServiceLevelObjectivesRequest{
+		Parent: service.GetName(),
+	}, listRetry)
+	for {
+		slo, err := lSLOs.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list service level objectives: %w", err)
+		}
+
+		metrics, err := c.MonitoringClient.GetServiceLevelObjective(ctx, &monitoringpb.GetServiceLevelObjectiveRequest{
+			Name: slo.GetName(),
+		}, listRetry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service level objective: %w", err)
+		}
+
+		serviceType, serviceResource := serviceIdentity(service)
+		slos = append(slos, &model.SLO{
+			Name:            metrics.GetName(),
+			DisplayName:     metrics.GetDisplayName(),
+			Project:         projectID,
+			Goal:            metrics.GetGoal(),
+			Service:         serviceName(service),
+			Labels:          metrics.GetUserLabels(),
+			ServiceLabels:   service.GetUserLabels(),
+			ServiceType:     serviceType,
+			ServiceResource: serviceResource,
+			Period:          sloPeriod(metrics),
+			ConsoleURL:      consoleURL(metrics.GetName()),
+			Kind:            sloKind(metrics.GetServiceLevelIndicator()),
+			SLI:             metrics.GetServiceLevelIndicator(),
 		})
+	}
+
+	return slos, nil
+}
+
+// GetServices lists the services of every configured project, including the ones without SLOs.
+func (c *Client) GetServices(ctx context.Context) ([]*model.Service, error) {
+	var result []*model.Service
+	for _, projectID := range c.GCPProjectIDs {
+		services := c.MonitoringClient.ListServices(ctx, &monitoringpb.ListServicesRequest{
+			Parent: "projects/" + projectID,
+		}, listRetry)
 		for {
-			slo, err := lSLOs.Next()
+			service, err := services.Next()
 			if errors.Is(err, iterator.Done) {
 				break
 			}
 			if err != nil {
-				return nil, fmt.Errorf("failed to list service level objectives: %w", err)
+				return nil, fmt.Errorf("project %s: failed to list services: %w", projectID, err)
 			}
+			result = append(result, &model.Service{Name: serviceName(service), Project: projectID})
+		}
+	}
+	return result, nil
+}
 
-			metrics, err := c.MonitoringClient.GetServiceLevelObjective(ctx, &monitoringpb.GetServiceLevelObjectiveRequest{
-				Name: slo.GetName(),
-			})
-			if err != nil {
-				return nil, fmt.Errorf("failed to get service level objective: %w", err)
-			}
+// serviceName returns the display name of a service, or its ID when it has none.
+func serviceName(service *monitoringpb.Service) string {
+	return cmp.Or(service.GetDisplayName(), path.Base(service.GetName()))
+}
 
-			slos = append(slos, &model.SLO{
-				Name:        metrics.GetName(),
-				DisplayName: metrics.GetDisplayName(),
-				Goal:        metrics.GetGoal(),
-				SLI:         metrics.GetServiceLevelIndicator(),
-			})
+// Lengths of the calendar periods of SLOs, months counted as 30 days, quarters as 90 and halves as 180.
+var calendarPeriods = map[string]time.Duration{
+	"DAY":       24 * time.Hour,
+	"WEEK":      7 * 24 * time.Hour,
+	"FORTNIGHT": 14 * 24 * time.Hour,
+	"MONTH":     30 * 24 * time.Hour,
+	"QUARTER":   90 * 24 * time.Hour,
+	"HALF":      180 * 24 * time.Hour,
+	"YEAR":      365 * 24 * time.Hour,
+}
+
+// sloPeriod returns the compliance period of an SLO: its rolling period, or the length of its calendar period.
+func sloPeriod(slo *monitoringpb.ServiceLevelObjective) time.Duration {
+	if rolling := slo.GetRollingPeriod(); rolling != nil {
+		return rolling.AsDuration()
+	}
+	return calendarPeriods[slo.GetCalendarPeriod().String()]
+}
+
+// Service types by the basic service type of the services Cloud Monitoring creates for managed workloads.
+var basicServiceTypes = map[string]string{
+	"APP_ENGINE":              "App Engine",
+	"CLOUD_ENDPOINTS":         "Cloud Endpoints",
+	"CLUSTER_ISTIO":           "Istio on GKE",
+	"ISTIO_CANONICAL_SERVICE": "Istio canonical service",
+	"CLOUD_RUN":               "Cloud Run",
+	"GKE_NAMESPACE":           "GKE namespace",
+	"GKE_WORKLOAD":            "GKE workload",
+	"GKE_SERVICE":             "GKE service",
+}
+
+// serviceIdentity returns the type of the workload behind a service, e.g. Cloud Run or GKE workload, and the resource
+// labels identifying it, such as its cluster and namespace. Custom services have no resource labels.
+func serviceIdentity(service *monitoringpb.Service) (string, map[string]string) {
+	var typ string
+	var resource map[string]string
+	switch {
+	case service.GetAppEngine() != nil:
+		typ, resource = "App Engine", map[string]string{"module_id": service.GetAppEngine().GetModuleId()}
+	case service.GetCloudEndpoints() != nil:
+		typ, resource = "Cloud Endpoints", map[string]string{"service": service.GetCloudEndpoints().GetService()}
+	case service.GetClusterIstio() != nil:
+		s := service.GetClusterIstio()
+		typ, resource = "Istio on GKE", map[string]string{
+			"location":          s.GetLocation(),
+			"cluster_name":      s.GetClusterName(),
+			"service_namespace": s.GetServiceNamespace(),
+			"service_name":      s.GetServiceName(),
+		}
+	case service.GetMeshIstio() != nil:
+		s := service.GetMeshIstio()
+		typ, resource = "Istio mesh", map[string]string{
+			"mesh_uid":          s.GetMeshUid(),
+			"service_namespace": s.GetServiceNamespace(),
+			"service_name":      s.GetServiceName(),
 		}
+	case service.GetIstioCanonicalService() != nil:
+		s := service.GetIstioCanonicalService()
+		typ, resource = "Istio canonical service", map[string]string{
+			"mesh_uid":                    s.GetMeshUid(),
+			"canonical_service_namespace": s.GetCanonicalServiceNamespace(),
+			"canonical_service":           s.GetCanonicalService(),
+		}
+	case service.GetCloudRun() != nil:
+		s := service.GetCloudRun()
+		typ, resource = "Cloud Run", map[string]string{"location": s.GetLocation(), "service_name": s.GetServiceName()}
+	case service.GetGkeNamespace() != nil:
+		s := service.GetGkeNamespace()
+		typ, resource = "GKE namespace", map[string]string{
+			"project_id":     s.GetProjectId(),
+			"location":       s.GetLocation(),
+			"cluster_name":   s.GetClusterName(),
+			"namespace_name": s.GetNamespaceName(),
+		}
+	case service.GetGkeWorkload() != nil:
+		s := service.GetGkeWorkload()
+		typ, resource = "GKE workload", map[string]string{
+			"project_id":                s.GetProjectId(),
+			"location":                  s.GetLocation(),
+			"cluster_name":              s.GetClusterName(),
+			"namespace_name":            s.GetNamespaceName(),
+			"top_level_controller_type": s.GetTopLevelControllerType(),
+			"top_level_controller_name": s.GetTopLevelControllerName(),
+		}
+	case service.GetGkeService() != nil:
+		s := service.GetGkeService()
+		typ, resource = "GKE service", map[string]string{
+			"project_id":     s.GetProjectId(),
+			"location":       s.GetLocation(),
+			"cluster_name":   s.GetClusterName(),
+			"namespace_name": s.GetNamespaceName(),
+			"service_name":   s.GetServiceName(),
+		}
+	case service.GetBasicService() != nil:
+		s := service.GetBasicService()
+		typ, resource = cmp.Or(basicServiceTypes[s.GetServiceType()], s.GetServiceType()), make(map[string]string)
+		maps.Copy(resource, s.GetServiceLabels())
+	default:
+		typ, resource = "Custom", map[string]string{}
+	}
+	if name := service.GetTelemetry().GetResourceName(); name != "" {
+		resource["resource_name"] = name
 	}
 
-	return slos, nil
+	maps.DeleteFunc(resource, func(_, v string) bool { return v == "" })
+	if len(resource) == 0 {
+		return typ, nil
+	}
+	return typ, resource
+}
+
+// sloKind tells latency SLIs, which cut a distribution or use the latency criteria of a basic SLI, from availability
+// ones. Windows based SLIs are classified by the SLI they threshold; the metric range ones are left unknown.
+func sloKind(sli *monitoringpb.ServiceLevelIndicator) model.SLOKind {
+	basic, requestBased := sli.GetBasicSli(), sli.GetRequestBased()
+	if threshold := sli.GetWindowsBased().GetGoodTotalRatioThreshold(); threshold != nil {
+		basic, requestBased = threshold.GetBasicSliPerformance(), threshold.GetPerformance()
+	}
+
+	switch {
+	case basic.GetLatency() != nil || requestBased.GetDistributionCut() != nil:
+		return model.SLOKindLatency
+	case basic.GetAvailability() != nil || requestBased.GetGoodTotalRatio() != nil:
+		return model.SLOKindAvailability
+	default:
+		return ""
+	}
 }
 
 // GetErrorBudgetTimeSeries fetches error budget time series data for a given SLO.
-func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (good string, total string, points []float64, err error) {
+func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (good string, total string, points []model.Point, err error) {
 	sli, ok := slo.SLI.(*monitoringpb.ServiceLevelIndicator)
 	if !ok {
 		return "", "", nil, fmt.Errorf("is not of expected type: %T", slo)
 	}
 
-	goodQuery := sli.GetRequestBased().GetGoodTotalRatio().GetGoodServiceFilter()
-	totalQuery := sli.GetRequestBased().GetGoodTotalRatio().GetTotalServiceFilter()
+	goodQuery, totalQuery := sliQueries(sli, c.PromQL)
 
-	// range
-	if goodQuery == "" || totalQuery == "" {
-		goodQuery = sli.GetRequestBased().GetDistributionCut().GetRange().String()
-		totalQuery = sli.GetRequestBased().GetDistributionCut().GetDistributionFilter()
-	}
-
-	startTime := time.Now().UTC().Add(c.Window * -1).Unix()
+	startTime := time.Now().UTC().Add(cmp.Or(slo.Window, c.Window) * -1).Unix()
 	endTime := time.Now().UTC().Unix()
 
 	req := &monitoringpb.ListTimeSeriesRequest{
-		Name:   "projects/" + c.GCPProjectID,
+		Name:   c.metricsProject(slo),
 		Filter: fmt.Sprintf("select_slo_budget_fraction(%s)", slo.Name),
 		Interval: &monitoringpb.TimeInterval{
 			StartTime: &timestamppb.Timestamp{Seconds: startTime},
 			EndTime:   &timestamppb.Timestamp{Seconds: endTime},
 		},
 	}
+	if c.AlignmentPeriod > 0 {
+		req.Aggregation = &monitoringpb.Aggregation{
+			AlignmentPeriod:  durationpb.New(c.AlignmentPeriod),
+			PerSeriesAligner: c.Aligner,
+		}
+	}
 
-	iter := c.MetricClient.ListTimeSeries(ctx, req)
+	iter := c.MetricClient.ListTimeSeries(ctx, req, timeSeriesRetry)
 
 	for {
 		ts, err := iter.Next()
@@ -136,14 +465,216 @@ func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (
 		}
 
 		for _, point := range ts.GetPoints() {
-			value := point.GetValue().GetDoubleValue()
-			points = append(points, value)
+			points = append(points, model.Point{
+				Time:  point.GetInterval().GetEndTime().AsTime(),
+				Value: point.GetValue().GetDoubleValue(),
+			})
 		}
 	}
 
 	if len(points) == 0 {
-		return "", "", nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
+		return goodQuery, totalQuery, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
 	}
+	// Cloud Monitoring usually returns the newest point first, but does not guarantee any order.
+	slices.SortStableFunc(points, func(a, b model.Point) int { return a.Time.Compare(b.Time) })
 
 	return goodQuery, totalQuery, points, nil
 }
+
+// metricsProject returns the project the time series of slo are queried in, the scoping project when one is set.
+func (c *Client) metricsProject(slo *model.SLO) string {
+	return "projects/" + cmp.Or(c.ScopingProject, slo.Project)
+}
+
+// GetSLOStatus fetches the compliance, remaining budget and burn rate Cloud Monitoring computes for an SLO with the
+// select_slo_compliance, select_slo_budget and select_slo_burn_rate selectors. It returns nil unless SLOStatus is set.
+func (c *Client) GetSLOStatus(ctx context.Context, slo *model.SLO) (*model.SLOStatus, error) {
+	if !c.SLOStatus {
+		return nil, nil
+	}
+
+	status := &model.SLOStatus{BurnRateLookback: statusBurnRateLookback.String()}
+	for _, s := range []struct {
+		filter string
+		value  *float64
+	}{
+		{fmt.Sprintf("select_slo_compliance(%q)", slo.Name), &status.Compliance},
+		{fmt.Sprintf("select_slo_budget(%q)", slo.Name), &status.BudgetRemaining},
+		{fmt.Sprintf("select_slo_burn_rate(%q, %q)", slo.Name, fmt.Sprintf("%dm", int(statusBurnRateLookback.Minutes()))), &status.BurnRate},
+	} {
+		value, err := c.latestValue(ctx, slo, s.filter)
+		if err != nil {
+			return nil, err
+		}
+		*s.value = value
+	}
+
+	return status, nil
+}
+
+// latestValue returns the newest value of the time series selected by filter over the window of slo.
+func (c *Client) latestValue(ctx context.Context, slo *model.SLO, filter string) (float64, error) {
+	end := time.Now().UTC()
+	start := end.Add(cmp.Or(slo.Window, c.Window) * -1)
+
+	iter := c.MetricClient.ListTimeSeries(ctx, &monitoringpb.ListTimeSeriesRequest{
+		Name:   c.metricsProject(slo),
+		Filter: filter,
+		Interval: &monitoringpb.TimeInterval{
+			StartTime: timestamppb.New(start),
+			EndTime:   timestamppb.New(end),
+		},
+	}, timeSeriesRetry)
+
+	var latest *monitoringpb.Point
+	for {
+		ts, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get %s: %w", filter, err)
+		}
+		for _, p := range ts.GetPoints() {
+			if latest == nil || p.GetInterval().GetEndTime().AsTime().After(latest.GetInterval().GetEndTime().AsTime()) {
+				latest = p
+			}
+		}
+	}
+
+	if latest == nil {
+		return 0, fmt.Errorf("no data points found for %s", filter)
+	}
+	if v, ok := latest.GetValue().GetValue().(*monitoringpb.TypedValue_Int64Value); ok {
+		return float64(v.Int64Value), nil
+	}
+	return latest.GetValue().GetDoubleValue(), nil
+}
+
+// sliQueries describes what an SLI counts as good and what it counts in total, for the GoodQuery and TotalQuery
+// columns. Windows based SLIs are described by the windows they count as good, their total being every window. With
+// promQL, distribution cuts are rendered as PromQL queries when their filter translates.
+func sliQueries(sli *monitoringpb.ServiceLevelIndicator, promQL bool) (good, total string) {
+	switch {
+	case sli.GetRequestBased() != nil:
+		return requestBasedQueries(sli.GetRequestBased(), promQL)
+	case sli.GetWindowsBased() != nil:
+		return windowsBasedQueries(sli.GetWindowsBased(), promQL)
+	case sli.GetBasicSli() != nil:
+		return basicSliQueries(sli.GetBasicSli())
+	default:
+		return "", ""
+	}
+}
+
+// requestBasedQueries describes a ratio of good (or bad) to total requests, or the cut of a distribution by the values
+// of its metric counted as good, e.g. "loadbalancing.googleapis.com/https/total_latencies <= 300".
+func requestBasedQueries(rb *monitoringpb.RequestBasedSli, promQL bool) (good, total string) {
+	if ratio := rb.GetGoodTotalRatio(); ratio != nil {
+		good = ratio.GetGoodServiceFilter()
+		if good == "" && ratio.GetBadServiceFilter() != "" {
+			good = "bad: " + ratio.GetBadServiceFilter()
+		}
+		return good, ratio.GetTotalServiceFilter()
+	}
+
+	cut := rb.GetDistributionCut()
+	if cut == nil {
+		return "", ""
+	}
+	filter, r := cut.GetDistributionFilter(), cut.GetRange()
+	if promQL {
+		if good, total, ok := distributionCutPromQL(filter, r.GetMin(), r.GetMax()); ok {
+			return good, total
+		}
+	}
+	metric := "value"
+	if m := metricType.FindStringSubmatch(filter); m != nil {
+		metric = m[1]
+	}
+	return describeRange(metric, r), filter
+}
+
+// basicSliQueries describes the criteria of a basic SLI, which Cloud Monitoring evaluates on the metrics of a managed
+// service such as App Engine or Cloud Endpoints, and the requests it applies to.
+func basicSliQueries(basic *monitoringpb.BasicSli) (good, total string) {
+	switch {
+	case basic.GetLatency() != nil:
+		good = "latency <= " + basic.GetLatency().GetThreshold().AsDuration().String()
+	default:
+		good = "availability"
+	}
+
+	var scope []string
+	for _, s := range []struct {
+		name   string
+		values []string
+	}{
+		{"method", basic.GetMethod()},
+		{"location", basic.GetLocation()},
+		{"version", basic.GetVersion()},
+	} {
+		if len(s.values) > 0 {
+			scope = append(scope, s.name+": "+strings.Join(s.values, ", "))
+		}
+	}
+	if len(scope) == 0 {
+		return good, "all requests of the service"
+	}
+	return good, strings.Join(scope, "; ")
+}
+
+// windowsBasedQueries describes the windows a windows based SLI counts as good.
+func windowsBasedQueries(wb *monitoringpb.WindowsBasedSli, promQL bool) (good, total string) {
+	period := wb.GetWindowPeriod().AsDuration()
+	windows := fmt.Sprintf("every %s window", period)
+
+	switch {
+	case wb.GetGoodBadMetricFilter() != "":
+		return wb.GetGoodBadMetricFilter(), windows
+	case wb.GetGoodTotalRatioThreshold() != nil:
+		threshold := wb.GetGoodTotalRatioThreshold()
+		if basic := threshold.GetBasicSliPerformance(); basic != nil {
+			good, total = basicSliQueries(basic)
+		} else {
+			good, total = requestBasedQueries(threshold.GetPerformance(), promQL)
+		}
+		return fmt.Sprintf("%s windows with a good ratio of %g or more: %s", period, threshold.GetThreshold(), good), total
+	case wb.GetMetricMeanInRange() != nil:
+		r := wb.GetMetricMeanInRange()
+		return fmt.Sprintf("%s windows with %s: %s", period, describeRange("a mean", r.GetRange()), r.GetTimeSeries()), windows
+	case wb.GetMetricSumInRange() != nil:
+		r := wb.GetMetricSumInRange()
+		return fmt.Sprintf("%s windows with %s: %s", period, describeRange("a sum", r.GetRange()), r.GetTimeSeries()), windows
+	default:
+		return "", windows
+	}
+}
+
+// metricType extracts the metric type of a monitoring filter.
+var metricType = regexp.MustCompile(`metric\.type\s*=\s*"([^"]*)"`)
+
+// describeRange renders what is in a range of values, e.g. "a mean in [0, 0.5]", or "latency <= 300" for ranges open
+// on one end.
+func describeRange(what string, r *monitoringpb.Range) string {
+	switch {
+	case math.IsInf(r.GetMin(), -1) && math.IsInf(r.GetMax(), 1):
+		return what
+	case math.IsInf(r.GetMin(), -1):
+		return fmt.Sprintf("%s <= %g", what, r.GetMax())
+	case math.IsInf(r.GetMax(), 1):
+		return fmt.Sprintf("%s >= %g", what, r.GetMin())
+	default:
+		return fmt.Sprintf("%s in [%g, %g]", what, r.GetMin(), r.GetMax())
+	}
+}
+
+// consoleURL links to the Cloud Monitoring page of the service an SLO belongs to.
+// name is the SLO resource name: projects/{project}/services/{service}/serviceLevelObjectives/{slo}.
+func consoleURL(name string) string {
+	parts := strings.Split(name, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "services" {
+		return ""
+	}
+	return fmt.Sprintf("https://console.cloud.google.com/monitoring/services/%s?project=%s", url.PathEscape(parts[3]), url.QueryEscape(parts[1]))
+}
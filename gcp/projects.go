@@ -0,0 +1,59 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/api/cloudresourcemanager/v3"
+	"google.golang.org/api/option"
+)
+
+// DiscoverProjects returns the IDs of all active projects under the given folder or organization,
+// e.g. "folders/123" or "organizations/456", descending into nested folders. opts carry the credentials, if any.
+func DiscoverProjects(ctx context.Context, parent string, opts ...option.ClientOption) ([]string, error) {
+	svc, err := cloudresourcemanager.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource manager client: %w", err)
+	}
+
+	return discoverProjects(ctx, svc, parent)
+}
+
+func discoverProjects(ctx context.Context, svc *cloudresourcemanager.Service, parent string) ([]string, error) {
+	var projectIDs []string
+
+	err := svc.Projects.List().Parent(parent).Pages(ctx, func(resp *cloudresourcemanager.ListProjectsResponse) error {
+		for _, p := range resp.Projects {
+			if p.State == "ACTIVE" {
+				projectIDs = append(projectIDs, p.ProjectId)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects under %s: %w", parent, err)
+	}
+
+	var folders []string
+	err = svc.Folders.List().Parent(parent).Pages(ctx, func(resp *cloudresourcemanager.ListFoldersResponse) error {
+		for _, f := range resp.Folders {
+			if f.State == "ACTIVE" {
+				folders = append(folders, f.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders under %s: %w", parent, err)
+	}
+
+	for _, folder := range folders {
+		ids, err := discoverProjects(ctx, svc, folder)
+		if err != nil {
+			return nil, err
+		}
+		projectIDs = append(projectIDs, ids...)
+	}
+
+	return projectIDs, nil
+}
@@ -0,0 +1,104 @@
+package gcp
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// promQLRateWindow is the range of the rate() of the PromQL queries rendered for --gcp-promql.
+const promQLRateWindow = "5m"
+
+// filterTerm matches the key="value" comparisons of a monitoring filter, e.g. resource.labels.zone="us-central1-a".
+var filterTerm = regexp.MustCompile(`([a-z_.]+)\s*=\s*"([^"]*)"`)
+
+// distributionCutPromQL renders a distribution cut SLI as the PromQL queries of its good and total requests, counting
+// the buckets of the metric up to the bounds of the range. ok is false when the filter uses more than AND-ed
+// equalities, which have no plain label matcher equivalent.
+func distributionCutPromQL(filter string, minValue, maxValue float64) (good, total string, ok bool) {
+	name, labels, ok := promQLSelector(filter)
+	if !ok {
+		return "", "", false
+	}
+
+	count := fmt.Sprintf("sum(rate(%s_count%s[%s]))", name, formatMatchers(labels, ""), promQLRateWindow)
+	bucket := func(le float64) string {
+		return fmt.Sprintf("sum(rate(%s_bucket%s[%s]))", name, formatMatchers(labels, strconv.FormatFloat(le, 'g', -1, 64)), promQLRateWindow)
+	}
+
+	switch {
+	case math.IsInf(maxValue, 1):
+		good = count
+	default:
+		good = bucket(maxValue)
+	}
+	// A lower bound of 0 is taken as "from the start" of metrics that cannot be negative, such as latencies, so
+	// requests of exactly 0 still count as good.
+	if !math.IsInf(minValue, -1) && minValue != 0 {
+		good += " - " + bucket(minValue)
+	}
+	return good, count, true
+}
+
+// promQLSelector translates a monitoring filter into the PromQL metric name and label matchers Cloud Monitoring
+// exposes the metric under, e.g. metric.type="run.googleapis.com/request_latencies" into
+// run_googleapis_com:request_latencies.
+func promQLSelector(filter string) (name string, labels map[string]string, ok bool) {
+	labels = make(map[string]string)
+	for _, m := range filterTerm.FindAllStringSubmatch(filter, -1) {
+		key, value := m[1], m[2]
+		switch {
+		case key == "metric.type":
+			name = promQLName(value)
+		case key == "resource.type":
+			labels["monitored_resource"] = value
+		case key == "project":
+			labels["project_id"] = value
+		default:
+			label, found := "", false
+			for _, prefix := range []string{"metric.labels.", "metric.label.", "resource.labels.", "resource.label."} {
+				if strings.HasPrefix(key, prefix) {
+					label, found = strings.TrimPrefix(key, prefix), true
+					break
+				}
+			}
+			if !found {
+				return "", nil, false
+			}
+			labels[label] = value
+		}
+	}
+
+	rest := filterTerm.ReplaceAllString(filter, "")
+	if name == "" || strings.Trim(strings.ReplaceAll(rest, "AND", ""), " \t\n") != "" {
+		return "", nil, false
+	}
+	return name, labels, true
+}
+
+// promQLName maps a Cloud Monitoring metric type to its PromQL name: the dots of the domain and the slashes and dots
+// of the path become underscores, and the first slash a colon.
+func promQLName(metricType string) string {
+	domain, path, _ := strings.Cut(metricType, "/")
+	underscores := strings.NewReplacer(".", "_", "/", "_")
+	return underscores.Replace(domain) + ":" + underscores.Replace(path)
+}
+
+// formatMatchers renders label matchers sorted by label, with an le matcher of the bucket bound when le is not empty.
+func formatMatchers(labels map[string]string, le string) string {
+	var matchers []string
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		matchers = append(matchers, fmt.Sprintf("%s=%q", k, labels[k]))
+	}
+	if le != "" {
+		matchers = append(matchers, fmt.Sprintf("le=%q", le))
+	}
+	if len(matchers) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(matchers, ",") + "}"
+}
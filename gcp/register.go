@@ -0,0 +1,181 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+func init() {
+	provider.Register(model.CloudProviderGCP, &factory{})
+}
+
+type factory struct {
+	projectIDs string
+	folder     string
+	org        string
+	sloStatus  bool
+	scoping    string
+
+	alignmentPeriod time.Duration
+	aligner         string
+	rateLimit       float64
+	promQL          bool
+
+	credentialsFile string
+	impersonate     string
+	endpoint        string
+}
+
+func (f *factory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.projectIDs, "gcp-project", "", "project id. comma separated to scan several projects")
+	fs.StringVar(&f.folder, "gcp-folder", "", "scan every project under the folder. e.g. 123456789012")
+	fs.StringVar(&f.org, "gcp-org", "", "scan every project under the organization. e.g. 123456789012")
+	fs.StringVar(&f.scoping, "gcp-scoping-project", "", "query the time series through the metrics scope of this scoping project, for SLOs whose metrics live in its monitored projects")
+	fs.BoolVar(&f.sloStatus, "gcp-slo-status", false, "also fetch the compliance, remaining budget and 1h burn rate Cloud Monitoring computes for each SLO. 3 more API calls per SLO")
+	fs.DurationVar(&f.alignmentPeriod, "gcp-alignment-period", 0, "align the error budget series to one point per period, at least 1m. e.g. 1h for a 90 day window. 0 fetches the raw points")
+	fs.StringVar(&f.aligner, "gcp-aligner", "mean", `aligner of --gcp-alignment-period: "mean" or "min"`)
+	fs.StringVar(&f.credentialsFile, "gcp-credentials-file", "", "service account key or external account (Workload Identity Federation) file to authenticate with instead of Application Default Credentials")
+	fs.StringVar(&f.impersonate, "impersonate-service-account", "", "service account to impersonate, e.g. reader@project.iam.gserviceaccount.com. needs roles/iam.serviceAccountTokenCreator on it")
+	fs.StringVar(&f.endpoint, "gcp-endpoint", "", "host:port of the Cloud Monitoring API, e.g. monitoring.me-central2.rep.googleapis.com:443 for a regional endpoint, or a Private Service Connect or proxy endpoint")
+	fs.BoolVar(&f.promQL, "gcp-promql", false, "show distribution cut SLIs as PromQL queries of their buckets, to paste into Metrics Explorer")
+	fs.Float64Var(&f.rateLimit, "gcp-rate-limit", 50, "maximum time series requests per second to Cloud Monitoring, retries included. 0 for no limit")
+}
+
+func (f *factory) Validate() error {
+	if f.projectIDs == "" && f.folder == "" && f.org == "" {
+		return errors.New("--gcp-project, --gcp-folder or --gcp-org is required for GCP")
+	}
+	if f.alignmentPeriod != 0 && f.alignmentPeriod < minAlignmentPeriod {
+		return fmt.Errorf("--gcp-alignment-period must be 0 or at least %s", minAlignmentPeriod)
+	}
+	if strings.Contains(f.endpoint, "://") {
+		return fmt.Errorf("--gcp-endpoint must be a host:port, got %q", f.endpoint)
+	}
+	if f.rateLimit < 0 {
+		return errors.New("--gcp-rate-limit must not be negative")
+	}
+	if _, ok := Aligners[f.aligner]; !ok {
+		return fmt.Errorf(`--gcp-aligner must be "mean" or "min", got %q`, f.aligner)
+	}
+	return nil
+}
+
+func (f *factory) New(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+	// Only the Monitoring API is recorded: projects discovered through Resource Manager would not be replayed.
+	if opts.Cassette != nil && (f.folder != "" || f.org != "") {
+		return nil, errors.New("--gcp-folder and --gcp-org cannot be recorded or replayed. use --gcp-project")
+	}
+	var clientOpts []option.ClientOption
+	if opts.Cassette == nil || opts.Cassette.Recording() {
+		var err error
+		clientOpts, err = f.clientOptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	projectIDs, err := f.projects(ctx, clientOpts)
+	if err != nil {
+		return nil, err
+	}
+	// The endpoint only applies to the Monitoring clients, not to the project discovery of Resource Manager.
+	if f.endpoint != "" {
+		clientOpts = append(clientOpts, option.WithEndpoint(f.endpoint))
+	}
+	if opts.Cassette != nil {
+		clientOpts = append(clientOpts, cassetteOptions(opts.Cassette)...)
+	}
+	client, err := NewClient(ctx, projectIDs, opts.ErrorBudgetThreshold, opts.Window, f.rateLimit, clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+	client.SLOStatus = f.sloStatus
+	client.ScopingProject = strings.TrimPrefix(f.scoping, "projects/")
+	client.AlignmentPeriod = f.alignmentPeriod
+	client.Aligner = Aligners[f.aligner]
+	client.PromQL = f.promQL
+	return client, nil
+}
+
+func (f *factory) Target() string {
+	var targets []string
+	for _, t := range []string{f.projectIDs, parent("folders", f.folder), parent("organizations", f.org)} {
+		if t != "" {
+			targets = append(targets, t)
+		}
+	}
+	target := strings.Join(targets, ", ")
+	if f.scoping != "" {
+		target += fmt.Sprintf(" (metrics scope %s)", f.scoping)
+	}
+	return target
+}
+
+// clientOptions returns the credentials of --gcp-credentials-file and --impersonate-service-account, none to use
+// Application Default Credentials.
+func (f *factory) clientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	if f.credentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(f.credentialsFile))
+	}
+	if f.impersonate == "" {
+		return opts, nil
+	}
+
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: f.impersonate,
+		Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate %s: %w", f.impersonate, err)
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
+
+// projects returns the projects given to --gcp-project together with the ones discovered under --gcp-folder and --gcp-org.
+func (f *factory) projects(ctx context.Context, opts []option.ClientOption) ([]string, error) {
+	var projectIDs []string
+	for _, id := range strings.Split(f.projectIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			projectIDs = append(projectIDs, id)
+		}
+	}
+
+	for _, p := range []string{parent("folders", f.folder), parent("organizations", f.org)} {
+		if p == "" {
+			continue
+		}
+		log.Printf("Discovering projects under %s...", p)
+		ids, err := DiscoverProjects(ctx, p, opts...)
+		if err != nil {
+			return nil, err
+		}
+		projectIDs = append(projectIDs, ids...)
+	}
+
+	slices.Sort(projectIDs)
+	projectIDs = slices.Compact(projectIDs)
+	if len(projectIDs) == 0 {
+		return nil, errors.New("no GCP projects found")
+	}
+
+	return projectIDs, nil
+}
+
+// parent returns the resource name for a folder or organization ID, accepting IDs with or without the prefix.
+func parent(kind, id string) string {
+	if id == "" {
+		return ""
+	}
+	return kind + "/" + strings.TrimPrefix(id, kind+"/")
+}
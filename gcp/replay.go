@@ -0,0 +1,70 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rluisr/vigil/replay"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// cassetteOptions returns the client options recording the calls of the Monitoring clients into c, or answering them
+// from c without credentials when replaying.
+func cassetteOptions(c *replay.Cassette) []option.ClientOption {
+	opts := []option.ClientOption{option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(cassetteInterceptor(c)))}
+	if !c.Recording() {
+		opts = append(opts, option.WithoutAuthentication())
+	}
+	return opts
+}
+
+// cassetteInterceptor records every call with its request and response, or its status when it fails, as protojson.
+// When replaying, the recorded response is returned without calling the API, so no connection is ever made.
+func cassetteInterceptor(c *replay.Cassette) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		reqMsg, ok := req.(proto.Message)
+		if !ok {
+			return fmt.Errorf("request of %s is not a protocol buffer: %T", method, req)
+		}
+		replyMsg, ok := reply.(proto.Message)
+		if !ok {
+			return fmt.Errorf("response of %s is not a protocol buffer: %T", method, reply)
+		}
+		body, err := protojson.Marshal(reqMsg)
+		if err != nil {
+			return fmt.Errorf("failed to encode request of %s: %w", method, err)
+		}
+
+		if !c.Recording() {
+			in, err := c.Find(method, "", string(body))
+			if err != nil {
+				return err
+			}
+			if in.Code != 0 {
+				return status.Error(codes.Code(in.Code), in.Error)
+			}
+			return protojson.Unmarshal([]byte(in.Body), replyMsg)
+		}
+
+		in := replay.Interaction{Method: method, RequestBody: string(body), Time: time.Now().UTC()}
+		if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+			s := status.Convert(err)
+			in.Code, in.Error = int(s.Code()), s.Message()
+			c.Add(in)
+			return err
+		}
+		resp, err := protojson.Marshal(replyMsg)
+		if err != nil {
+			return fmt.Errorf("failed to encode response of %s: %w", method, err)
+		}
+		in.Body = string(resp)
+		c.Add(in)
+		return nil
+	}
+}
@@ -0,0 +1,227 @@
+package gcp
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil"
+	"github.com/rluisr/vigil/replay"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testdata/monitoring.json holds the Monitoring API calls of a scan of the project shop: a request based ratio and a
+// distribution cut SLO of a custom service, a windows based and a basic SLI of a Cloud Run service, and the alert
+// policies of the project.
+func TestReplay(t *testing.T) {
+	cassette, err := replay.Load("testdata/monitoring.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	client, err := NewClient(ctx, []string{"shop"}, 0.9, 720*time.Hour, 0, cassetteOptions(cassette)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	result, err := vigil.Run(ctx, client, vigil.Options{ContinueOnError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Failures) > 0 || len(result.Warnings) > 0 {
+		t.Fatalf("got failures %v and warnings %v", result.Failures, result.Warnings)
+	}
+
+	const name = "projects/shop/services/"
+	tests := []struct {
+		key         string
+		category    model.Category
+		window      string
+		points      int
+		minBudget   float64
+		good, total string
+		team        string
+		serviceType string
+		resource    map[string]string
+		alerted     bool
+	}{
+		{
+			key:         name + "checkout/serviceLevelObjectives/availability",
+			category:    model.CategoryLax,
+			window:      "720h0m0s",
+			points:      121,
+			minBudget:   0.95,
+			good:        `metric.type="loadbalancing.googleapis.com/https/request_count" resource.type="https_lb_rule" metric.label.response_code_class="200"`,
+			total:       `metric.type="loadbalancing.googleapis.com/https/request_count" resource.type="https_lb_rule"`,
+			team:        "payments",
+			serviceType: "Custom",
+		},
+		{
+			key:         name + "checkout/serviceLevelObjectives/latency",
+			category:    model.CategoryBurning,
+			window:      "720h0m0s",
+			points:      121,
+			minBudget:   -0.9,
+			good:        "loadbalancing.googleapis.com/https/total_latencies <= 300",
+			total:       `metric.type="loadbalancing.googleapis.com/https/total_latencies" resource.type="https_lb_rule"`,
+			team:        "payments",
+			serviceType: "Custom",
+			alerted:     true,
+		},
+		{
+			// A 7 day rolling period: its series is fetched over 7 days, not over the window of the client.
+			key:         name + "frontend/serviceLevelObjectives/uptime",
+			category:    model.CategoryHealthy,
+			window:      "168h0m0s",
+			points:      29,
+			minBudget:   0.6,
+			good:        "5m0s windows with a good ratio of 0.95 or more: availability",
+			total:       "all requests of the service",
+			serviceType: "Cloud Run",
+			resource:    map[string]string{"location": "us-central1", "service_name": "frontend"},
+		},
+		{
+			key:         name + "frontend/serviceLevelObjectives/latency",
+			category:    model.CategoryNoData,
+			window:      "720h0m0s",
+			good:        "latency <= 500ms",
+			total:       "method: GET",
+			serviceType: "Cloud Run",
+			resource:    map[string]string{"location": "us-central1", "service_name": "frontend"},
+		},
+	}
+
+	if len(result.SLOs) != len(tests) {
+		t.Errorf("got %d SLOs, want %d", len(result.SLOs), len(tests))
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			v, ok := result.SLOs[tt.key]
+			if !ok {
+				t.Fatalf("no SLO %s", tt.key)
+			}
+			if v.Category != tt.category {
+				t.Errorf("Category = %s, want %s", v.Category, tt.category)
+			}
+			if v.Window != tt.window {
+				t.Errorf("Window = %s, want %s", v.Window, tt.window)
+			}
+			if len(v.Points) != tt.points {
+				t.Errorf("got %d points, want %d", len(v.Points), tt.points)
+			}
+			if tt.points > 0 && !near(v.MinBudget, tt.minBudget) {
+				t.Errorf("MinBudget = %g, want %g", v.MinBudget, tt.minBudget)
+			}
+			for i := 1; i < len(v.Timestamps); i++ {
+				if !v.Timestamps[i].After(v.Timestamps[i-1]) {
+					t.Fatalf("points are not oldest first: %s after %s", v.Timestamps[i], v.Timestamps[i-1])
+				}
+			}
+			if v.GoodQuery != tt.good {
+				t.Errorf("GoodQuery = %q, want %q", v.GoodQuery, tt.good)
+			}
+			if v.TotalQuery != tt.total {
+				t.Errorf("TotalQuery = %q, want %q", v.TotalQuery, tt.total)
+			}
+			if v.Team != tt.team {
+				t.Errorf("Team = %q, want %q", v.Team, tt.team)
+			}
+			if v.ServiceType != tt.serviceType {
+				t.Errorf("ServiceType = %q, want %q", v.ServiceType, tt.serviceType)
+			}
+			if !maps.Equal(v.ServiceResource, tt.resource) {
+				t.Errorf("ServiceResource = %v, want %v", v.ServiceResource, tt.resource)
+			}
+			if v.Alerted == nil || *v.Alerted != tt.alerted {
+				t.Errorf("Alerted = %v, want %t", v.Alerted, tt.alerted)
+			}
+		})
+	}
+}
+
+func TestReplayKinds(t *testing.T) {
+	cassette, err := replay.Load("testdata/monitoring.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	client, err := NewClient(ctx, []string{"shop"}, 0.9, 720*time.Hour, 0, cassetteOptions(cassette)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	slos, err := client.GetSLOs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct {
+		kind   model.SLOKind
+		period time.Duration
+	}{
+		"checkout availability": {model.SLOKindAvailability, 720 * time.Hour},
+		"checkout latency":      {model.SLOKindLatency, 720 * time.Hour},
+		"frontend uptime":       {model.SLOKindAvailability, 168 * time.Hour},
+		"frontend latency":      {model.SLOKindLatency, 30 * 24 * time.Hour},
+	}
+	for _, slo := range slos {
+		w, ok := want[slo.DisplayName]
+		if !ok {
+			t.Errorf("unexpected SLO %s", slo.DisplayName)
+			continue
+		}
+		if slo.Kind != w.kind {
+			t.Errorf("%s: Kind = %s, want %s", slo.DisplayName, slo.Kind, w.kind)
+		}
+		if slo.Period != w.period {
+			t.Errorf("%s: Period = %s, want %s", slo.DisplayName, slo.Period, w.period)
+		}
+	}
+}
+
+func TestAlertedAfterFailure(t *testing.T) {
+	cassette, err := replay.Load("testdata/monitoring.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first listing of the alert policies runs out of quota, before the cassette answers.
+	failed := false
+	quota := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if strings.HasSuffix(method, "/ListAlertPolicies") && !failed {
+			failed = true
+			return status.Error(codes.ResourceExhausted, "quota exceeded")
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	opts := append([]option.ClientOption{option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(quota))}, cassetteOptions(cassette)...)
+	ctx := context.Background()
+	client, err := NewClient(ctx, []string{"shop"}, 0.9, 720*time.Hour, 0, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = client.Close() }()
+
+	slo := &model.SLO{Name: "projects/shop/services/checkout/serviceLevelObjectives/latency", Project: "shop"}
+	if _, err := client.Alerted(ctx, slo); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("got error %v, want the quota error", err)
+	}
+	// The failed listing is not kept: the next SLO of the project lists the policies again.
+	alerted, err := client.Alerted(ctx, slo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !alerted {
+		t.Error("Alerted = false, want true")
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
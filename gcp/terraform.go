@@ -0,0 +1,292 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/rluisr/vigil/model"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// invalidResourceName matches the characters Terraform does not allow in resource names.
+var invalidResourceName = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ExportGoals writes the SLOs with their recommended goal as google_monitoring_slo resources, one gcp_{project}.tf file
+// per project. Each resource comes with an import block, so terraform plan shows the goal change of SLOs that are not
+// managed by Terraform yet; the resources of managed ones tell what to change in their definition.
+func (c *Client) ExportGoals(ctx context.Context, dir string, changes []model.GoalChange) ([]string, error) {
+	byProject := make(map[string][]model.GoalChange)
+	for _, change := range changes {
+		byProject[change.SLO.Project] = append(byProject[change.SLO.Project], change)
+	}
+
+	var paths []string
+	for _, project := range slices.Sorted(maps.Keys(byProject)) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# Recommended SLO goals of project %s, generated by Vigil on %s.\n", project, time.Now().Format(time.DateOnly))
+		fmt.Fprintf(&b, "# Review the goals before applying them.\n")
+
+		names := make(map[string]int)
+		for _, change := range byProject[project] {
+			slo, err := c.MonitoringClient.GetServiceLevelObjective(ctx, &monitoringpb.GetServiceLevelObjectiveRequest{
+				Name: change.SLO.Name,
+			}, listRetry)
+			if err != nil {
+				return paths, fmt.Errorf("failed to get service level objective %s: %w", change.SLO.Name, err)
+			}
+
+			name := terraformName(slo.GetName())
+			if names[name]++; names[name] > 1 {
+				name += "_" + strconv.Itoa(names[name])
+			}
+			b.WriteString("\n")
+			writeTerraformSLO(&b, project, name, slo, change)
+		}
+
+		p := filepath.Join(dir, "gcp_"+project+".tf")
+		if err := os.WriteFile(p, []byte(b.String()), 0o644); err != nil {
+			return paths, fmt.Errorf("failed to write %s: %w", p, err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// terraformName returns the resource name of an SLO from its service and SLO IDs, e.g. checkout_latency.
+func terraformName(name string) string {
+	parts := strings.Split(name, "/")
+	id := path.Base(name)
+	if len(parts) == 6 {
+		id = parts[3] + "_" + parts[5]
+	}
+	id = invalidResourceName.ReplaceAllString(id, "_")
+	if id == "" || (id[0] >= '0' && id[0] <= '9') || id[0] == '-' {
+		id = "slo_" + id
+	}
+	return id
+}
+
+// writeTerraformSLO writes an import block and the google_monitoring_slo resource of slo with the goal of change.
+func writeTerraformSLO(b *strings.Builder, project, name string, slo *monitoringpb.ServiceLevelObjective, change model.GoalChange) {
+	fmt.Fprintf(b, "# %s: %s, goal %s -> %s\n", slo.GetDisplayName(), change.Category, formatGoal(slo.GetGoal()), formatGoal(change.Goal))
+	imp := &hclBlock{header: "import"}
+	imp.attr("to", "google_monitoring_slo."+name)
+	imp.attr("id", hclString(slo.GetName()))
+	imp.write(b, 0)
+	b.WriteString("\n")
+
+	resource := &hclBlock{header: fmt.Sprintf("resource \"google_monitoring_slo\" %q", name)}
+	parts := strings.Split(slo.GetName(), "/")
+	resource.attr("project", hclString(project))
+	if len(parts) == 6 {
+		resource.attr("service", hclString(parts[3]))
+		resource.attr("slo_id", hclString(parts[5]))
+	}
+	resource.attr("display_name", hclString(slo.GetDisplayName()))
+	resource.attr("goal", hclNumber(change.Goal))
+	if rolling := slo.GetRollingPeriod(); rolling != nil {
+		resource.attr("rolling_period_days", strconv.Itoa(int(rolling.AsDuration().Hours()/24)))
+	} else {
+		resource.attr("calendar_period", hclString(slo.GetCalendarPeriod().String()))
+	}
+	if labels := slo.GetUserLabels(); len(labels) > 0 {
+		resource.attr("user_labels", hclMap(labels))
+	}
+
+	sli := slo.GetServiceLevelIndicator()
+	switch {
+	case sli.GetRequestBased() != nil:
+		resource.block(requestBasedBlock("request_based_sli", sli.GetRequestBased()))
+	case sli.GetBasicSli() != nil:
+		resource.block(basicSliBlock("basic_sli", sli.GetBasicSli()))
+	case sli.GetWindowsBased() != nil:
+		resource.block(windowsBasedBlock(sli.GetWindowsBased()))
+	}
+	resource.write(b, 0)
+}
+
+func requestBasedBlock(header string, rb *monitoringpb.RequestBasedSli) *hclBlock {
+	block := &hclBlock{header: header}
+	if ratio := rb.GetGoodTotalRatio(); ratio != nil {
+		inner := &hclBlock{header: "good_total_ratio"}
+		inner.optionalString("good_service_filter", ratio.GetGoodServiceFilter())
+		inner.optionalString("bad_service_filter", ratio.GetBadServiceFilter())
+		inner.optionalString("total_service_filter", ratio.GetTotalServiceFilter())
+		block.block(inner)
+	}
+	if cut := rb.GetDistributionCut(); cut != nil {
+		inner := &hclBlock{header: "distribution_cut"}
+		inner.attr("distribution_filter", hclString(cut.GetDistributionFilter()))
+		inner.block(rangeBlock(cut.GetRange()))
+		block.block(inner)
+	}
+	return block
+}
+
+func basicSliBlock(header string, basic *monitoringpb.BasicSli) *hclBlock {
+	block := &hclBlock{header: header}
+	for _, s := range []struct {
+		name   string
+		values []string
+	}{
+		{"method", basic.GetMethod()},
+		{"location", basic.GetLocation()},
+		{"version", basic.GetVersion()},
+	} {
+		if len(s.values) > 0 {
+			block.attr(s.name, hclList(s.values))
+		}
+	}
+	if latency := basic.GetLatency(); latency != nil {
+		inner := &hclBlock{header: "latency"}
+		inner.attr("threshold", hclDuration(latency.GetThreshold()))
+		block.block(inner)
+	} else {
+		inner := &hclBlock{header: "availability"}
+		inner.attr("enabled", "true")
+		block.block(inner)
+	}
+	return block
+}
+
+func windowsBasedBlock(wb *monitoringpb.WindowsBasedSli) *hclBlock {
+	block := &hclBlock{header: "windows_based_sli"}
+	block.attr("window_period", hclDuration(wb.GetWindowPeriod()))
+	block.optionalString("good_bad_metric_filter", wb.GetGoodBadMetricFilter())
+	if threshold := wb.GetGoodTotalRatioThreshold(); threshold != nil {
+		inner := &hclBlock{header: "good_total_ratio_threshold"}
+		inner.attr("threshold", hclNumber(threshold.GetThreshold()))
+		if basic := threshold.GetBasicSliPerformance(); basic != nil {
+			inner.block(basicSliBlock("basic_sli_performance", basic))
+		} else {
+			inner.block(requestBasedBlock("performance", threshold.GetPerformance()))
+		}
+		block.block(inner)
+	}
+	for _, r := range []struct {
+		header string
+		metric *monitoringpb.WindowsBasedSli_MetricRange
+	}{
+		{"metric_mean_in_range", wb.GetMetricMeanInRange()},
+		{"metric_sum_in_range", wb.GetMetricSumInRange()},
+	} {
+		if r.metric != nil {
+			inner := &hclBlock{header: r.header}
+			inner.attr("time_series", hclString(r.metric.GetTimeSeries()))
+			inner.block(rangeBlock(r.metric.GetRange()))
+			block.block(inner)
+		}
+	}
+	return block
+}
+
+// rangeBlock writes a range, leaving out the infinite bounds of ranges open on one end.
+func rangeBlock(r *monitoringpb.Range) *hclBlock {
+	block := &hclBlock{header: "range"}
+	if !math.IsInf(r.GetMin(), -1) {
+		block.attr("min", hclNumber(r.GetMin()))
+	}
+	if !math.IsInf(r.GetMax(), 1) {
+		block.attr("max", hclNumber(r.GetMax()))
+	}
+	return block
+}
+
+// formatGoal renders a goal as a percentage, e.g. 99.95%, rounded to hide the float error of the conversion.
+func formatGoal(goal float64) string {
+	return strconv.FormatFloat(math.Round(goal*1e8)/1e6, 'f', -1, 64) + "%"
+}
+
+// hclBlock is an HCL block of attributes and nested blocks, written in the layout of terraform fmt.
+type hclBlock struct {
+	header string
+	items  []hclItem
+}
+
+// hclItem is an attribute of a block, or a nested block when block is set.
+type hclItem struct {
+	name, value string
+	block       *hclBlock
+}
+
+func (h *hclBlock) attr(name, value string) {
+	h.items = append(h.items, hclItem{name: name, value: value})
+}
+
+func (h *hclBlock) optionalString(name, value string) {
+	if value != "" {
+		h.attr(name, hclString(value))
+	}
+}
+
+func (h *hclBlock) block(block *hclBlock) {
+	h.items = append(h.items, hclItem{block: block})
+}
+
+// write writes the block at depth, aligning the equal signs of consecutive attributes and separating nested blocks
+// with blank lines.
+func (h *hclBlock) write(b *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(b, "%s%s {\n", indent, h.header)
+	for i := 0; i < len(h.items); {
+		if h.items[i].block != nil {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			h.items[i].block.write(b, depth+1)
+			i++
+			continue
+		}
+
+		j, width := i, 0
+		for ; j < len(h.items) && h.items[j].block == nil; j++ {
+			width = max(width, len(h.items[j].name))
+		}
+		for _, item := range h.items[i:j] {
+			fmt.Fprintf(b, "%s  %-*s = %s\n", indent, width, item.name, item.value)
+		}
+		i = j
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+// hclString quotes s as an HCL string, escaping the template sequences ${ and %{.
+func hclString(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(strconv.Quote(s))
+}
+
+func hclNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func hclList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = hclString(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func hclMap(values map[string]string) string {
+	pairs := make([]string, 0, len(values))
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		pairs = append(pairs, fmt.Sprintf("%s = %s", hclString(k), hclString(values[k])))
+	}
+	return "{ " + strings.Join(pairs, ", ") + " }"
+}
+
+// hclDuration renders a duration in seconds, e.g. 0.3s, the format of the durations of google_monitoring_slo.
+func hclDuration(d *durationpb.Duration) string {
+	return strconv.FormatFloat(d.AsDuration().Seconds(), 'f', -1, 64) + "s"
+}
@@ -0,0 +1,36 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	"cloud.google.com/go/monitoring/apiv3/v2/monitoringpb"
+	"github.com/rluisr/vigil/model"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// UpdateGoal sets the goal of an SLO, leaving the rest of its definition as it is. It reads the SLO again first and
+// refuses to update it when its goal changed since it was listed, so a concurrent edit is not overwritten.
+func (c *Client) UpdateGoal(ctx context.Context, slo *model.SLO, goal float64) error {
+	current, err := c.MonitoringClient.GetServiceLevelObjective(ctx, &monitoringpb.GetServiceLevelObjectiveRequest{
+		Name: slo.Name,
+	}, listRetry)
+	if err != nil {
+		return fmt.Errorf("failed to get service level objective %s: %w", slo.Name, err)
+	}
+	if current.GetGoal() != slo.Goal {
+		return fmt.Errorf("the goal of %s changed to %s since it was scanned", slo.Name, formatGoal(current.GetGoal()))
+	}
+
+	_, err = c.MonitoringClient.UpdateServiceLevelObjective(ctx, &monitoringpb.UpdateServiceLevelObjectiveRequest{
+		ServiceLevelObjective: &monitoringpb.ServiceLevelObjective{
+			Name: slo.Name,
+			Goal: goal,
+		},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"goal"}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update service level objective %s: %w", slo.Name, err)
+	}
+	return nil
+}
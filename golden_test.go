@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil"
+	"github.com/rluisr/vigil/provider/fake"
+	"github.com/rluisr/vigil/report"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of TestGolden with the reports rendered now")
+
+// goldenTime is the time the canonical results are analyzed and reported at.
+var goldenTime = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// maxGoldenDiffLines is the number of differing lines shown for a report that does not match its golden file.
+const maxGoldenDiffLines = 20
+
+// TestGolden renders the reports of the canonical results and compares them with the golden files of testdata/golden,
+// or rewrites them with -update. Excel reports are compared through dumpExcel, as the xlsx itself cannot be diffed:
+//
+//	go test -run TestGolden -update .
+func TestGolden(t *testing.T) {
+	// The reports read a few flags, left at their defaults but for the ones depending on the environment.
+	oldLang, oldCloud, oldTime := *lang, *cloudProvider, reportTime
+	t.Cleanup(func() {
+		*lang, *cloudProvider, reportTime = oldLang, oldCloud, oldTime
+		sloFailures, auditOptions = nil, vigil.Options{}
+	})
+	*lang = string(i18n.LangEN)
+	*cloudProvider = string(fake.Name)
+	reportTime = func() time.Time { return goldenTime }
+
+	result, err := vigil.Run(context.Background(), goldenProvider(), vigil.Options{
+		ErrorBudgetThreshold: *errorBudgetThreshold,
+		Window:               *window,
+		BurnRateThreshold:    14.4,
+		FlagDegrading:        true,
+		FlagExhaustion:       true,
+		DetectAnomalies:      true,
+		ContinueOnError:      true,
+		// One SLO at a time, so the failures are in the same order every time.
+		Concurrency: 1,
+		Now:         func() time.Time { return goldenTime },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sloFailures = result.Failures
+	auditOptions = result.Options
+	spec := report.Default(i18n.Get(i18n.LangEN))
+
+	for _, f := range []string{formatXLSX, formatCSV, formatJSON} {
+		t.Run(f, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "slo_report."+f)
+			if err := newReporter(f, spec, path).Write(result.SLOs); err != nil {
+				t.Fatalf("failed to write the %s report: %v", f, err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "golden", "slo_report."+f)
+			if f == formatXLSX {
+				dump, err := dumpExcel(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, golden = []byte(dump), golden+".txt"
+			}
+
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("no golden file %s. create it with go test -run TestGolden -update", golden)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if diff := diffLines(string(want), string(got)); diff != "" {
+				t.Errorf("%s does not match the %s report rendered now, run go test -run TestGolden -update to accept the change:\n%s", golden, f, diff)
+			}
+		})
+	}
+}
+
+// goldenProvider serves the canonical SLOs: one of every category over three projects and teams, an SLO spending its
+// budget in a single incident, an SLO with traffic weights, one without a team and one failing to fetch.
+func goldenProvider() *fake.Provider {
+	const step = time.Hour
+	n := int(*window / step)
+	series := func(values []float64) fake.Series {
+		return fake.Series{Points: fake.Points(goldenTime, step, values...)}
+	}
+	slo := func(name, project, team string, goal float64) *model.SLO {
+		s := fake.NewSLO(name, goal)
+		s.Project = project
+		s.ConsoleURL = "https://console.example.com/slo/" + name
+		if team != "" {
+			s.Labels = map[string]string{"team": team}
+		}
+		return s
+	}
+
+	weighted := series(fake.Constant(0.97, n))
+	weighted.Weights = fake.Constant(1000, n)
+	for i := range weighted.Weights {
+		if i%24 < 8 {
+			weighted.Weights[i] = 100
+		}
+	}
+
+	return fake.New().
+		Add(slo("checkout-availability", "shop", "payments", 0.999), series(fake.Constant(0.95, n))).
+		Add(slo("checkout-latency", "shop", "payments", 0.99), series(fake.Linear(0.8, 0.05, n))).
+		Add(slo("cart-availability", "shop", "payments", 0.995), weighted).
+		Add(slo("inventory-availability", "shop", "", 0.99), fake.Series{}).
+		Add(slo("search-availability", "search", "discovery", 0.995), series(fake.Dip(n, n-72, 6, -0.5))).
+		Add(slo("suggest-availability", "search", "discovery", 0.999), series(append(fake.Linear(1, 0.6, n/2), fake.Linear(0.6, 0.8, n-n/2)...))).
+		Add(slo("search-latency", "search", "discovery", 0.99), series(fake.Linear(0.2, -0.9, n))).
+		Add(slo("billing-availability", "billing", "finance", 0.999), fake.Series{Err: errors.New("quota exceeded")})
+}
+
+// diffLines returns the lines of want and got that differ, line by line, up to maxGoldenDiffLines of them.
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+
+	var b strings.Builder
+	shown := 0
+	for i := range max(len(wantLines), len(gotLines)) {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		if shown == maxGoldenDiffLines {
+			b.WriteString("  ...\n")
+			break
+		}
+		fmt.Fprintf(&b, "  line %d\n    - %s\n    + %s\n", i+1, w, g)
+		shown++
+	}
+	if len(wantLines) != len(gotLines) {
+		fmt.Fprintf(&b, "  %d lines, %d expected\n", len(gotLines), len(wantLines))
+	}
+	return b.String()
+}
+
+// dumpExcel describes an xlsx report as text for TestGolden: for every sheet, its non-empty cells with their raw
+// values and styles, its column widths and merged cells, then the styles used. Charts are left out.
+func dumpExcel(path string) (string, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	var b strings.Builder
+	used := make(map[int]bool)
+	for _, sheet := range f.GetSheetList() {
+		visible, err := f.GetSheetVisible(sheet)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "sheet %q visible=%t\n", sheet, visible)
+		rows, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+		if err != nil {
+			return "", err
+		}
+		columns := 0
+		for r, row := range rows {
+			columns = max(columns, len(row))
+			for c, value := range row {
+				if value == "" {
+					continue
+				}
+				cell, err := excelize.CoordinatesToCellName(c+1, r+1)
+				if err != nil {
+					return "", err
+				}
+				style, err := f.GetCellStyle(sheet, cell)
+				if err != nil {
+					return "", err
+				}
+				used[style] = true
+				fmt.Fprintf(&b, "  %s style=%d %q\n", cell, style, value)
+			}
+		}
+		for c := 1; c <= columns; c++ {
+			name, err := excelize.ColumnNumberToName(c)
+			if err != nil {
+				return "", err
+			}
+			width, err := f.GetColWidth(sheet, name)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "  column %s width=%g\n", name, width)
+		}
+		merged, err := f.GetMergeCells(sheet)
+		if err != nil {
+			return "", err
+		}
+		for _, m := range merged {
+			fmt.Fprintf(&b, "  merged %s:%s\n", m.GetStartAxis(), m.GetEndAxis())
+		}
+	}
+
+	ids := make([]int, 0, len(used))
+	for id := range used {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		style, err := f.GetStyle(id)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "style %d numFmt=%d", id, style.NumFmt)
+		if style.CustomNumFmt != nil {
+			fmt.Fprintf(&b, " customNumFmt=%q", *style.CustomNumFmt)
+		}
+		if style.Font != nil {
+			fmt.Fprintf(&b, " bold=%t italic=%t underline=%q color=%q", style.Font.Bold, style.Font.Italic, style.Font.Underline, style.Font.Color)
+		}
+		if len(style.Fill.Color) > 0 {
+			fmt.Fprintf(&b, " fill=%s", strings.Join(style.Fill.Color, ","))
+		}
+		if style.Alignment != nil && style.Alignment.WrapText {
+			b.WriteString(" wrap=true")
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
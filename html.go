@@ -0,0 +1,137 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/report"
+	"github.com/rluisr/vigil/utils"
+)
+
+//go:embed templates/report.html
+var htmlTemplate string
+
+// Sparkline geometry in SVG user units.
+const (
+	sparklineWidth     = 160
+	sparklineHeight    = 32
+	sparklineMaxPoints = 200
+)
+
+type htmlReport struct {
+	Lang        i18n.Lang
+	Msgs        *i18n.Messages
+	Description string
+	GeneratedAt string
+	Summary     [][2]string
+	SLOs        []*model.SLOData
+	Errors      []sloFailure
+	// WorstOffenders are the --top SLOs with the lowest health score, worst first.
+	WorstOffenders []*model.SLOData
+	// Windows is set when more than one --window is given, adding the per-window column.
+	Windows bool
+}
+
+func generateHTMLReport(data map[string]*model.SLOData, msgs *i18n.Messages, output string) error {
+	tmpl := template.Must(template.New("report").Funcs(template.FuncMap{
+		"percent":  func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) },
+		"slope":    func(v float64) string { return fmt.Sprintf("%+.2f%%/d", v*100) },
+		"category": func(c model.Category) string { return categoryLabel(c, msgs) },
+		"categoryStyle": func(c model.Category) template.CSS {
+			colors := categoryColors[c]
+			return template.CSS(fmt.Sprintf("background: #%s; color: #%s", colors[0], colors[1]))
+		},
+		"sparkline": sparkline,
+		"inc":       func(i int) int { return i + 1 },
+		"windowLabel": func(w string) string {
+			d, err := time.ParseDuration(w)
+			if err != nil {
+				return w
+			}
+			return report.WindowLabel(d)
+		},
+	}).Parse(htmlTemplate))
+
+	page := htmlReport{
+		Lang:           i18n.Lang(*lang),
+		Msgs:           msgs,
+		Description:    reportDescription(msgs),
+		GeneratedAt:    reportTime().Format(time.RFC3339),
+		Summary:        summarize(data, reportTime()).rows(msgs),
+		SLOs:           make([]*model.SLOData, 0, len(data)),
+		Errors:         sortedFailures(),
+		Windows:        len(extraWindows) > 0,
+		WorstOffenders: worstOffenders(data, *top),
+	}
+	for _, v := range data {
+		page.SLOs = append(page.SLOs, v)
+	}
+	// Flagged SLOs first so the findings are on top before any column is sorted.
+	sortSLOs(page.SLOs)
+	sort.SliceStable(page.SLOs, func(i, j int) bool {
+		return page.SLOs[i].Flag && !page.SLOs[j].Flag
+	})
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	if err := tmpl.Execute(f, page); err != nil {
+		return fmt.Errorf("failed to write HTML page: %w", err)
+	}
+	return nil
+}
+
+// sparkline renders the error budget series as an inline SVG with the threshold and zero lines for reference.
+func sparkline(points []float64, threshold float64) template.HTML {
+	if len(points) == 0 {
+		return ""
+	}
+
+	sampled := utils.Downsample(points, sparklineMaxPoints)
+
+	lo, hi := math.Min(0, threshold), math.Max(1, threshold)
+	for _, p := range sampled {
+		lo = math.Min(lo, p)
+		hi = math.Max(hi, p)
+	}
+	y := func(v float64) float64 {
+		return sparklineHeight - (v-lo)/(hi-lo)*sparklineHeight
+	}
+
+	coords := make([]string, 0, len(sampled))
+	for i, p := range sampled {
+		x := 0.0
+		if len(sampled) > 1 {
+			x = float64(i) / float64(len(sampled)-1) * sparklineWidth
+		}
+		coords = append(coords, fmt.Sprintf("%.1f,%.1f", x, y(p)))
+	}
+
+	// Every interpolated value is a number formatted here, so the markup is safe to emit unescaped.
+	return template.HTML(fmt.Sprintf(
+		`<svg class="sparkline" width="%d" height="%d" viewBox="0 0 %d %d">`+
+			`<line class="threshold" x1="0" y1="%.1f" x2="%d" y2="%.1f"/>`+
+			`<line class="zero" x1="0" y1="%.1f" x2="%d" y2="%.1f"/>`+
+			`<polyline points="%s"/></svg>`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight,
+		y(threshold), sparklineWidth, y(threshold),
+		y(0), sparklineWidth, y(0),
+		strings.Join(coords, " "),
+	))
+}
@@ -1,6 +1,11 @@
-// Package i18n provides internationalization support for Excel report generation.
+// Package i18n provides internationalization support for report generation.
 package i18n
 
+import (
+	"os"
+	"strings"
+)
+
 // Lang represents a supported language for report generation.
 type Lang string
 
@@ -10,68 +15,310 @@ const (
 	LangJA Lang = "ja"
 )
 
-// Messages holds all translatable strings used in the Excel report.
+// Messages holds all translatable strings used in the reports.
 type Messages struct {
-	ReportDescription   string
-	GeneratedBy         string
-	NewSLO              string
-	HeaderName          string
-	HeaderSLO           string
-	HeaderNewSLO        string
-	HeaderSLIMin        string
-	HeaderSLIAvg        string
-	HeaderGoodQuery     string
-	HeaderTotalQuery    string
-	HeaderNewGoodQuery  string
-	HeaderNewTotalQuery string
-	ReportTitle         string
-}
-
-// Headers returns the column headers as an ordered slice.
-func (m *Messages) Headers() []string {
-	return []string{
-		m.HeaderName,
-		m.HeaderSLO,
-		m.HeaderNewSLO,
-		m.HeaderSLIMin,
-		m.HeaderSLIAvg,
-		m.HeaderGoodQuery,
-		m.HeaderTotalQuery,
-		m.HeaderNewGoodQuery,
-		m.HeaderNewTotalQuery,
-	}
+	ReportDescription     string
+	GeneratedBy           string
+	NewSLO                string
+	HeaderName            string
+	HeaderSLO             string
+	HeaderNewSLO          string
+	HeaderSLIMin          string
+	HeaderSLIAvg          string
+	HeaderGoodQuery       string
+	HeaderTotalQuery      string
+	HeaderNewGoodQuery    string
+	HeaderNewTotalQuery   string
+	HeaderProject         string
+	HeaderFlag            string
+	HeaderNegative        string
+	HeaderErrorBudget     string
+	HeaderWindows         string
+	HeaderConfidence      string
+	HeaderHealthScore     string
+	HeaderTeam            string
+	TeamUnowned           string
+	SummaryTeam           string
+	SectionWorstOffenders string
+	ReportTitle           string
+	Summary               string
+	SheetSummary          string
+	HeaderGroup           string
+	HeaderSLOCount        string
+	HeaderProvider        string
+	SheetAllSLOs          string
+	SheetCharts           string
+	ChartThreshold        string
+	HeaderConsoleLink     string
+	ConsoleLinkText       string
+	SummaryScanned        string
+	SummaryFlagged        string
+	SummaryTooLax         string
+	SummaryBurning        string
+	SummaryWorst          string
+	SummaryWindow         string
+	SummaryWindowDays     string
+	SummaryGeneratedAt    string
+	SummaryIncomplete     string
+	SummaryFailed         string
+	SheetErrors           string
+	HeaderError           string
+	// Coverage gaps of vigil coverage.
+	SheetCoverageGaps         string
+	HeaderService             string
+	HeaderGap                 string
+	CoverageNoSLOs            string
+	CoverageNoAvailability    string
+	CoverageNoLatency         string
+	CoverageSummary           string
+	HeaderPeakBurnRate        string
+	HeaderTimeToExhaustion    string
+	SummaryFastBurn           string
+	SummaryDegrading          string
+	HeaderTrend               string
+	HeaderExhaustionDate      string
+	SummaryExhaustion         string
+	HeaderCategory            string
+	CategoryLax               string
+	CategoryBurning           string
+	CategoryHealthy           string
+	CategoryNoData            string
+	SummaryHealthy            string
+	SummaryNoData             string
+	IncidentDriven            string
+	SummaryIncidentDriven     string
+	HeaderLongestBreach       string
+	HeaderBudgetConsumed      string
+	HeaderBudgetConsumedTotal string
+	HeaderAllowedDowntime     string
+	HeaderBadMinutes          string
+	HeaderRemainingDowntime   string
+	SummarySkipped            string
+	SheetBreaches             string
+	HeaderBreachStart         string
+	HeaderBreachEnd           string
+	HeaderBreachHours         string
+	BreachOngoing             string
+	HeaderServiceType         string
+	HeaderServiceResource     string
+	HeaderAlerted             string
+	HeaderMonitorIssues       string
+	HeaderMinBudgetChange     string
+	// Changes between two runs of vigil diff.
+	SheetDiff               string
+	DiffTitle               string
+	DiffDescription         string
+	DiffSummary             string
+	HeaderChange            string
+	HeaderAvgBudgetChange   string
+	HeaderHealthScoreChange string
+	ChangeFlagged           string
+	ChangeResolved          string
+	ChangeAdded             string
+	ChangeDeleted           string
+	ChangeStats             string
 }
 
 var translations = map[Lang]*Messages{
 	LangEN: {
-		ReportDescription:   "SLO Report for %s\nList of SLOs that have never been below %g%% in %g days and 50%% of the total window has a negative error budget",
-		GeneratedBy:         "Generated by Vigil https://github.com/rluisr/vigil",
-		NewSLO:              "New SLO",
-		HeaderName:          "Name",
-		HeaderSLO:           "SLO",
-		HeaderNewSLO:        "New SLO",
-		HeaderSLIMin:        "SLI Min",
-		HeaderSLIAvg:        "SLI Avg",
-		HeaderGoodQuery:     "GoodQuery",
-		HeaderTotalQuery:    "TotalQuery",
-		HeaderNewGoodQuery:  "New GoodQuery?",
-		HeaderNewTotalQuery: "New TotalQuery?",
-		ReportTitle:         "SLO Report",
+		ReportDescription:         "SLO Report for %s\nList of SLOs that have never been below %g%% in %g days and %g%% of the total window has a negative error budget",
+		GeneratedBy:               "Generated by Vigil https://github.com/rluisr/vigil",
+		NewSLO:                    "New SLO",
+		HeaderName:                "Name",
+		HeaderSLO:                 "SLO",
+		HeaderNewSLO:              "New SLO",
+		HeaderSLIMin:              "SLI Min",
+		HeaderSLIAvg:              "SLI Avg",
+		HeaderGoodQuery:           "GoodQuery",
+		HeaderTotalQuery:          "TotalQuery",
+		HeaderNewGoodQuery:        "New GoodQuery?",
+		HeaderNewTotalQuery:       "New TotalQuery?",
+		HeaderProject:             "Project",
+		HeaderFlag:                "Flagged",
+		HeaderNegative:            "Negative %",
+		HeaderErrorBudget:         "Error Budget",
+		HeaderWindows:             "Windows",
+		HeaderConfidence:          "Confidence",
+		HeaderHealthScore:         "Health Score",
+		HeaderTeam:                "Team",
+		TeamUnowned:               "(no team)",
+		SummaryTeam:               "Team %s",
+		SectionWorstOffenders:     "Worst Offenders (top %d)",
+		ReportTitle:               "SLO Report",
+		Summary:                   "%d of %d SLOs flagged",
+		SheetSummary:              "Summary",
+		HeaderGroup:               "Project / Provider",
+		HeaderSLOCount:            "SLOs",
+		HeaderProvider:            "Provider",
+		SheetAllSLOs:              "All SLOs",
+		SheetCharts:               "Error Budget Charts",
+		ChartThreshold:            "Threshold",
+		HeaderConsoleLink:         "Console Link",
+		ConsoleLinkText:           "Open",
+		SummaryScanned:            "SLOs scanned",
+		SummaryFlagged:            "Flagged",
+		SummaryTooLax:             "Too lax (never below threshold)",
+		SummaryBurning:            "Burning (negative budget for --negative-budget-fraction of the window or more)",
+		SummaryWorst:              "Worst SLO (min budget)",
+		SummaryWindow:             "Window",
+		SummaryWindowDays:         "%g days",
+		SummaryGeneratedAt:        "Generated at",
+		SummaryIncomplete:         "Incomplete report",
+		SummaryFailed:             "Failed to scan (see Errors)",
+		SheetErrors:               "Errors",
+		HeaderError:               "Error",
+		SheetCoverageGaps:         "Coverage Gaps",
+		HeaderService:             "Service",
+		HeaderGap:                 "Gap",
+		CoverageNoSLOs:            "No SLOs",
+		CoverageNoAvailability:    "No availability SLO",
+		CoverageNoLatency:         "No latency SLO",
+		CoverageSummary:           "%d coverage gaps found",
+		HeaderPeakBurnRate:        "Peak Burn Rate",
+		HeaderTimeToExhaustion:    "Time to Exhaustion",
+		SummaryFastBurn:           "Fast burn (peak burn rate above --burn-rate-threshold)",
+		SummaryDegrading:          "Degrading (trending toward exhaustion)",
+		HeaderTrend:               "Trend",
+		HeaderExhaustionDate:      "Projected Exhaustion Date",
+		SummaryExhaustion:         "Forecast to exhaust the budget within the next window",
+		HeaderCategory:            "Category",
+		CategoryLax:               "Too Lax",
+		CategoryBurning:           "Burning",
+		CategoryHealthy:           "Healthy",
+		CategoryNoData:            "No Data",
+		SummaryHealthy:            "Healthy",
+		SummaryNoData:             "No data (no error budget points)",
+		IncidentDriven:            "single incident",
+		SummaryIncidentDriven:     "Flagged because of a single incident",
+		HeaderLongestBreach:       "Longest Breach (h)",
+		HeaderBudgetConsumed:      "Budget Consumed",
+		HeaderBudgetConsumedTotal: "Budget Consumed (Total)",
+		HeaderAllowedDowntime:     "Allowed Downtime (min)",
+		HeaderBadMinutes:          "Bad Minutes",
+		HeaderRemainingDowntime:   "Remaining Downtime (min)",
+		SummarySkipped:            "%d SLOs were not scanned because the run was interrupted or timed out",
+		SheetBreaches:             "Breaches",
+		HeaderBreachStart:         "Below Threshold Since",
+		HeaderBreachEnd:           "Recovered At",
+		HeaderBreachHours:         "Duration (h)",
+		BreachOngoing:             "ongoing",
+		HeaderServiceType:         "Service Type",
+		HeaderServiceResource:     "Service Resource",
+		HeaderAlerted:             "Alerted?",
+		HeaderMonitorIssues:       "Monitor Issues",
+		HeaderMinBudgetChange:     "SLI Min Change",
+		SheetDiff:                 "Changes",
+		DiffTitle:                 "SLO Changes",
+		DiffDescription:           "Changes of the SLOs from %s to %s",
+		DiffSummary:               "%d newly flagged, %d resolved, %d added, %d deleted and %d changed SLOs",
+		HeaderChange:              "Change",
+		HeaderAvgBudgetChange:     "SLI Avg Change",
+		HeaderHealthScoreChange:   "Health Score Change",
+		ChangeFlagged:             "Newly flagged",
+		ChangeResolved:            "Resolved",
+		ChangeAdded:               "Added",
+		ChangeDeleted:             "Deleted",
+		ChangeStats:               "Stats changed",
 	},
 	LangJA: {
-		ReportDescription:   "SLO レポート: %s\nエラーバジェットが %g%% を %g 日間下回ったことがない SLO、及びウィンドウ全体の 50%% 以上でエラーバジェットが負の SLO 一覧",
-		GeneratedBy:         "Vigil により生成 https://github.com/rluisr/vigil",
-		NewSLO:              "新 SLO",
-		HeaderName:          "名前",
-		HeaderSLO:           "SLO",
-		HeaderNewSLO:        "新 SLO",
-		HeaderSLIMin:        "SLI 最小",
-		HeaderSLIAvg:        "SLI 平均",
-		HeaderGoodQuery:     "GoodQuery",
-		HeaderTotalQuery:    "TotalQuery",
-		HeaderNewGoodQuery:  "新 GoodQuery?",
-		HeaderNewTotalQuery: "新 TotalQuery?",
-		ReportTitle:         "SLO レポート",
+		ReportDescription:         "SLO レポート: %s\nエラーバジェットが %g%% を %g 日間下回ったことがない SLO、及びウィンドウ全体の %g%% 以上でエラーバジェットが負の SLO 一覧",
+		GeneratedBy:               "Vigil により生成 https://github.com/rluisr/vigil",
+		NewSLO:                    "新 SLO",
+		HeaderName:                "名前",
+		HeaderSLO:                 "SLO",
+		HeaderNewSLO:              "新 SLO",
+		HeaderSLIMin:              "SLI 最小",
+		HeaderSLIAvg:              "SLI 平均",
+		HeaderGoodQuery:           "GoodQuery",
+		HeaderTotalQuery:          "TotalQuery",
+		HeaderNewGoodQuery:        "新 GoodQuery?",
+		HeaderNewTotalQuery:       "新 TotalQuery?",
+		HeaderProject:             "プロジェクト",
+		HeaderFlag:                "検出",
+		HeaderNegative:            "負の割合",
+		HeaderErrorBudget:         "エラーバジェット",
+		HeaderWindows:             "ウィンドウ別",
+		HeaderConfidence:          "信頼度",
+		HeaderHealthScore:         "健全性スコア",
+		HeaderTeam:                "チーム",
+		TeamUnowned:               "（チームなし）",
+		SummaryTeam:               "チーム %s",
+		SectionWorstOffenders:     "ワースト SLO（上位 %d 件）",
+		ReportTitle:               "SLO レポート",
+		Summary:                   "%[2]d 件中 %[1]d 件の SLO を検出",
+		SheetSummary:              "サマリー",
+		HeaderGroup:               "プロジェクト / プロバイダー",
+		HeaderSLOCount:            "SLO 数",
+		HeaderProvider:            "プロバイダー",
+		SheetAllSLOs:              "全 SLO",
+		SheetCharts:               "エラーバジェット推移",
+		ChartThreshold:            "閾値",
+		HeaderConsoleLink:         "コンソール",
+		ConsoleLinkText:           "開く",
+		SummaryScanned:            "スキャンした SLO",
+		SummaryFlagged:            "検出",
+		SummaryTooLax:             "緩すぎる（閾値を一度も下回っていない）",
+		SummaryBurning:            "消費過多（ウィンドウの --negative-budget-fraction 以上でバジェットが負）",
+		SummaryWorst:              "最も悪い SLO（最小バジェット）",
+		SummaryWindow:             "ウィンドウ",
+		SummaryWindowDays:         "%g 日間",
+		SummaryGeneratedAt:        "生成日時",
+		SummaryIncomplete:         "不完全なレポート",
+		SummaryFailed:             "スキャン失敗（エラー一覧を参照）",
+		SheetErrors:               "エラー",
+		HeaderError:               "エラー",
+		SheetCoverageGaps:         "カバレッジの不足",
+		HeaderService:             "サービス",
+		HeaderGap:                 "不足",
+		CoverageNoSLOs:            "SLO なし",
+		CoverageNoAvailability:    "可用性の SLO なし",
+		CoverageNoLatency:         "レイテンシの SLO なし",
+		CoverageSummary:           "カバレッジの不足が %d 件見つかりました",
+		HeaderPeakBurnRate:        "最大バーンレート",
+		HeaderTimeToExhaustion:    "枯渇までの時間",
+		SummaryFastBurn:           "高速消費（最大バーンレートが --burn-rate-threshold 以上）",
+		SummaryDegrading:          "悪化傾向（枯渇に向かっている）",
+		HeaderTrend:               "傾向",
+		HeaderExhaustionDate:      "枯渇予測日",
+		SummaryExhaustion:         "次のウィンドウ内にバジェット枯渇の予測",
+		HeaderCategory:            "分類",
+		CategoryLax:               "緩すぎる",
+		CategoryBurning:           "消費過多",
+		CategoryHealthy:           "健全",
+		CategoryNoData:            "データなし",
+		SummaryHealthy:            "健全",
+		SummaryNoData:             "データなし（エラーバジェットのデータポイントなし）",
+		IncidentDriven:            "単発のインシデント",
+		SummaryIncidentDriven:     "単発のインシデントによる検出",
+		HeaderLongestBreach:       "最長違反時間 (h)",
+		HeaderBudgetConsumed:      "バジェット消費率",
+		HeaderBudgetConsumedTotal: "バジェット消費率 (累計)",
+		HeaderAllowedDowntime:     "許容ダウンタイム (分)",
+		HeaderBadMinutes:          "ダウンタイム (分)",
+		HeaderRemainingDowntime:   "残りダウンタイム (分)",
+		SummarySkipped:            "実行が中断またはタイムアウトしたため %d 件の SLO がスキャンされていません",
+		SheetBreaches:             "閾値違反",
+		HeaderBreachStart:         "閾値を下回った日時",
+		HeaderBreachEnd:           "回復日時",
+		HeaderBreachHours:         "継続時間 (h)",
+		BreachOngoing:             "継続中",
+		HeaderServiceType:         "サービスの種類",
+		HeaderServiceResource:     "サービスのリソース",
+		HeaderAlerted:             "アラート有無",
+		HeaderMonitorIssues:       "モニターの問題",
+		HeaderMinBudgetChange:     "SLI 最小の変化",
+		SheetDiff:                 "変化",
+		DiffTitle:                 "SLO の変化",
+		DiffDescription:           "%s から %s までの SLO の変化",
+		DiffSummary:               "新たに検出 %d 件、解消 %d 件、追加 %d 件、削除 %d 件、統計値の変化 %d 件",
+		HeaderChange:              "変化",
+		HeaderAvgBudgetChange:     "SLI 平均の変化",
+		HeaderHealthScoreChange:   "健全性スコアの変化",
+		ChangeFlagged:             "新たに検出",
+		ChangeResolved:            "解消",
+		ChangeAdded:               "追加",
+		ChangeDeleted:             "削除",
+		ChangeStats:               "統計値の変化",
 	},
 }
 
@@ -85,3 +332,28 @@ func Get(lang Lang) *Messages {
 
 	return msgs
 }
+
+// Detect returns the language of the user's locale from LC_ALL, LC_MESSAGES or LANG, e.g. ja_JP.UTF-8.
+// Falls back to English if the locale is unset or not translated.
+func Detect() Lang {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		parts := strings.FieldsFunc(os.Getenv(env), func(r rune) bool {
+			return r == '_' || r == '.' || r == '@' || r == '-'
+		})
+		if len(parts) == 0 {
+			continue
+		}
+		if lang := Lang(strings.ToLower(parts[0])); Supported(lang) {
+			return lang
+		}
+		return LangEN
+	}
+
+	return LangEN
+}
+
+// Supported reports whether lang has a translation.
+func Supported(lang Lang) bool {
+	_, ok := translations[lang]
+	return ok
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/notify"
+)
+
+// Trackers --issues can open issues in.
+const (
+	issuesGitHub = "github"
+	issuesJira   = "jira"
+)
+
+// newTracker returns the tracker of --issues, with its credentials from the environment.
+func newTracker(tracker string) (notify.Tracker, error) {
+	switch tracker {
+	case issuesGitHub:
+		repo := cmp.Or(*githubRepo, os.Getenv("GITHUB_REPOSITORY"))
+		if repo == "" {
+			return nil, fmt.Errorf("--issues %s needs --github-repo owner/name", tracker)
+		}
+		token := os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("--issues %s needs GITHUB_TOKEN, a token that can write the issues of %s", tracker, repo)
+		}
+		return notify.NewGitHub(token, repo, cmp.Or(os.Getenv("GITHUB_API_URL"), notify.GitHubAPIURL)), nil
+	case issuesJira:
+		if *jiraURL == "" || *jiraProject == "" {
+			return nil, fmt.Errorf("--issues %s needs --jira-url and --jira-project", tracker)
+		}
+		token := os.Getenv("JIRA_API_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("--issues %s needs JIRA_API_TOKEN, with JIRA_EMAIL on Jira Cloud", tracker)
+		}
+		return notify.NewJira(*jiraURL, *jiraProject, *jiraIssueType, os.Getenv("JIRA_EMAIL"), token), nil
+	default:
+		return nil, fmt.Errorf("--issues must be '%s' or '%s'", issuesGitHub, issuesJira)
+	}
+}
+
+// trackIssues opens a tracking issue through --issues for every flagged SLO, or comments on the issue still open
+// from an earlier run. Every SLO is tracked even when some fail, and the run fails afterwards.
+func trackIssues(ctx context.Context, data map[string]*model.SLOData, startedAt time.Time) {
+	tracker, err := newTracker(*issues)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+
+	opened, commented, failed := 0, 0, 0
+	for _, f := range findings(data) {
+		issue := notify.Issue{
+			SLO:     f.slo,
+			Title:   fmt.Sprintf("%s: %s", f.rule, f.slo.DisplayName),
+			Summary: f.message,
+			RunAt:   startedAt,
+		}
+		if f.file != "" {
+			issue.Location = fmt.Sprintf("%s:%d", f.file, f.line)
+		}
+		url, created, err := tracker.Track(ctx, issue)
+		if err != nil {
+			log.Printf("%v", err)
+			failed++
+			continue
+		}
+		if created {
+			debugf("Opened %s for %s", url, f.slo.DisplayName)
+			opened++
+		} else {
+			debugf("Commented on %s for %s", url, f.slo.DisplayName)
+			commented++
+		}
+	}
+	if failed > 0 {
+		log.Panicf("Failed to track %d of %d flagged SLOs in %s", failed, opened+commented+failed, *issues)
+	}
+	infof("Opened %d issues and commented on %d in %s", opened, commented, *issues)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// jsonReport is the machine readable report. Unlike the Excel report it lists every SLO, flagged or not.
+type jsonReport struct {
+	GeneratedAt          time.Time        `json:"generatedAt"`
+	Target               string           `json:"target"`
+	ErrorBudgetThreshold float64          `json:"errorBudgetThreshold"`
+	Window               string           `json:"window"`
+	Summary              reportSummary    `json:"summary"`
+	SLOs                 []*model.SLOData `json:"slos"`
+	// WorstOffenders are the keys of the --top SLOs with the lowest health score, worst first.
+	WorstOffenders []string     `json:"worstOffenders"`
+	Warnings       []string     `json:"warnings"`
+	Errors         []sloFailure `json:"errors"`
+}
+
+func generateJSONReport(data map[string]*model.SLOData, output string) error {
+	report := newJSONReport(data)
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	return nil
+}
+
+// newJSONReport builds the JSON report of the run, also the payload of --webhook-url.
+func newJSONReport(data map[string]*model.SLOData) jsonReport {
+	report := jsonReport{
+		GeneratedAt:          reportTime().UTC(),
+		Target:               reportTarget(),
+		ErrorBudgetThreshold: *errorBudgetThreshold,
+		Window:               window.String(),
+		Summary:              summarize(data, reportTime().UTC()),
+		SLOs:                 make([]*model.SLOData, 0, len(data)),
+		Warnings:             append([]string{}, warnMessages...),
+		Errors:               append([]sloFailure{}, sortedFailures()...),
+	}
+	for _, v := range data {
+		report.SLOs = append(report.SLOs, v)
+	}
+	sortSLOs(report.SLOs)
+	report.WorstOffenders = []string{}
+	for _, v := range worstOffenders(data, *top) {
+		report.WorstOffenders = append(report.WorstOffenders, v.Key)
+	}
+	return report
+}
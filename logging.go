@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// infof logs progress and warnings, which --quiet suppresses. Errors are logged with log directly.
+func infof(format string, v ...interface{}) {
+	if !*quiet {
+		log.Printf(format, v...)
+	}
+}
+
+// debugf logs per-SLO details, shown only with --verbose.
+func debugf(format string, v ...interface{}) {
+	if *verbose {
+		log.Printf(format, v...)
+	}
+}
+
+// newProgressBar returns a progress bar on stderr. It is silent with --quiet, with --verbose, whose log lines
+// would be torn apart by the redrawn bar, and when stderr is not a terminal, where the redraws end up as garbage in log files.
+func newProgressBar(n int) *progressbar.ProgressBar {
+	if *quiet || *verbose || !isTerminal(os.Stderr) {
+		return progressbar.DefaultSilent(int64(n))
+	}
+	return progressbar.Default(int64(n))
+}
@@ -3,173 +3,470 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	_ "image/png"
 	"log"
+	"net/mail"
 	"os"
+	"os/signal"
+	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	"github.com/schollz/progressbar/v3"
-	"github.com/xuri/excelize/v2"
-
-	"github.com/rluisr/vigil/datadog"
-	"github.com/rluisr/vigil/gcp"
 	"github.com/rluisr/vigil/i18n"
 	"github.com/rluisr/vigil/model"
-	"github.com/rluisr/vigil/utils"
+	"github.com/rluisr/vigil/pkg/vigil"
+	"github.com/rluisr/vigil/pkg/vigil/analysis"
+	"github.com/rluisr/vigil/plugin"
+	"github.com/rluisr/vigil/provider"
+	"github.com/rluisr/vigil/replay"
+	"github.com/rluisr/vigil/report"
+	"github.com/rluisr/vigil/upload"
+
+	// Built-in providers register themselves with the provider registry.
+	_ "github.com/rluisr/vigil/datadog"
+	_ "github.com/rluisr/vigil/gcp"
+	_ "github.com/rluisr/vigil/nobl9"
+	_ "github.com/rluisr/vigil/openslo"
+	_ "github.com/rluisr/vigil/prometheus"
 )
 
 const maxConcurrency = 16
 
+// Supported report formats.
+const (
+	formatXLSX   = "xlsx"
+	formatCSV    = "csv"
+	formatJSON   = "json"
+	formatHTML   = "html"
+	formatPDF    = "pdf"
+	formatTable  = "table"
+	formatSARIF  = "sarif"
+	formatGitHub = "github"
+)
+
+// Exit codes, so CI pipelines can gate on the result.
+const (
+	exitOK      = 0
+	exitFlagged = 1 // only with --fail-on-flag
+	exitError   = 2
+)
+
 var (
-	cloudProvider        = flag.String("cloud", string(model.CloudProviderGCP), "cloud provider. gcp or datadog")
-	gcpProjectID         = flag.String("gcp-project", "", "project id")
-	errorBudgetThreshold = flag.Float64("error-budget-threshold", 0.9, "error budget threshold. 0 ~ 1") // Error budget threshold
-	window               = flag.Duration("window", 720*time.Hour, "target window. use \"h\" suffix")
-	ddSite               = flag.String("dd-site", "", "datadog site. e.g. datadoghq.com, ap1.datadoghq.com, datadoghq.eu")
-	lang                 = flag.String("lang", "en", "report language. en or ja")
-	warnMessages         = []string{}
-	warnMutex            sync.Mutex
+	cloudProvider          = flag.String("cloud", string(model.CloudProviderGCP), fmt.Sprintf("cloud provider. one of %v. comma separated to scan several at once", provider.Names()))
+	errorBudgetThreshold   = flag.Float64("error-budget-threshold", 0.9, "error budget threshold. 0 ~ 1. an SLO whose remaining error budget never dropped below it during --window is flagged as too lax, e.g. 0.9 flags SLOs that never spent more than 10% of their budget")
+	window                 = &[]time.Duration{720 * time.Hour}[0]
+	lang                   = flag.String("lang", string(i18n.Detect()), "report language. en or ja. defaults to the locale of LC_ALL, LC_MESSAGES or LANG")
+	format                 = flag.String("format", formatXLSX, "report format. xlsx, csv, json, html, pdf, sarif, table or github. table and github (Actions annotations) are printed to stdout")
+	providerPlugins        = flag.String("provider-plugin", "", "path to a provider plugin binary. comma separated to load several")
+	output                 = flag.String("output", defaultOutput, "report file path. a Go template with .Project, .Provider, .Date, .Time and .Format")
+	sortOrder              = flag.String("sort", sortName, "order of report rows. name, min-budget or avg-budget")
+	reportSpec             = flag.String("report-spec", "", "path to a YAML file describing the columns of the Excel SLO sheets")
+	configPath             = flag.String("config", "", "path to a YAML config file with per-SLO error budget threshold, window and negative budget fraction overrides")
+	sourceDir              = flag.String("source-dir", "", "directory of SLO definitions (.tf, .yaml, .yml, .json) used to locate findings in sarif and github output")
+	force                  = flag.Bool("force", false, "overwrite the report file if it already exists")
+	quiet                  = flag.Bool("quiet", false, "only log errors. hides the progress bar, info logs and warnings")
+	verbose                = flag.Bool("verbose", false, "log the fetch time and number of points of every SLO. hides the progress bar")
+	timeout                = flag.Duration("timeout", 0, "cancel the run after this duration and write a partial report. 0 disables it")
+	continueOnError        = flag.Bool("continue-on-error", false, "record SLOs that fail to process in the report instead of aborting the run")
+	maxErrorRatio          = flag.Float64("max-error-ratio", 0, "with --continue-on-error, exit with status 2 only when more than this fraction of the SLOs failed. 0 ~ 1")
+	failOnFlag             = flag.Bool("fail-on-flag", false, "exit with status 1 when any SLO is flagged")
+	burnRateThreshold      = flag.Float64("burn-rate-threshold", 0, "flag SLOs whose peak multiwindow burn rate reached this multiple of the rate that exactly exhausts the budget over --window, e.g. 14.4. 0 disables it")
+	negativeBudgetFraction = flag.Float64("negative-budget-fraction", 0.5, "fraction of --window the error budget must be negative for an SLO to be flagged as burning. 0 ~ 1")
+	flagDegrading          = flag.Bool("flag-degrading", false, "also flag SLOs whose budget is still positive but trending toward exhaustion within one more --window")
+	flagExhaustion         = flag.Bool("flag-exhaustion", false, "also flag SLOs whose budget is forecast to hit zero within the next --window at its current consumption rate")
+	checks                 = flag.String("checks", strings.Join(analysis.Names, ","), "comma separated checks that categorize the SLOs. no-data, negative-budget, burn-rate, trend, forecast or too-lax. burn-rate, trend and forecast also need --burn-rate-threshold, --flag-degrading and --flag-exhaustion")
+	detectAnomalies        = flag.Bool("detect-anomalies", false, "find anomalous budget consumption and mark SLOs whose spending is driven by a single incident rather than chronic behavior")
+	teamLabel              = flag.String("team-label", "team", "label or tag naming the team that owns an SLO, e.g. team:payments in Datadog. GCP SLOs fall back to the user labels of their service")
+	groupBy                = flag.String("group-by", groupByProject, "how the Excel report splits the SLOs into sheets. project (or provider when there is none) or team")
+	top                    = flag.Int("top", 10, "number of SLOs with the lowest health score listed as worst offenders. 0 disables the list")
+	trim                   = flag.Float64("trim", 0, "ignore this fraction of the lowest error budget points, e.g. 0.01, in the minimum and average budget and the negative fraction, so a monitoring pipeline hiccup does not flag a healthy SLO. 0 ~ 0.5")
+	minPoints              = flag.Int("min-points", 10, "SLOs with fewer error budget points are categorized as NO_DATA instead of getting a recommendation")
+	dryRun                 = flag.Bool("dry-run", false, "list the SLOs that would be scanned and the effective configuration without fetching any time series")
+	exportGoalsDir         = flag.String("export-goals", "", "directory to write the recommended goals of flagged SLOs to, as Terraform for GCP")
+	approve                = flag.Bool("approve", false, "with vigil apply, update the goals after confirmation instead of only listing them")
+	rollbackPath           = flag.String("rollback-file", "", "with vigil apply, file recording the previous goals. defaults to slo_rollback_{date}_{time}.json")
+	minChange              = flag.Float64("min-change", 0.05, "with vigil diff, also list the SLOs whose minimum or average budget moved by at least this much between the runs, e.g. 0.05 for 5 points. 0 ~ 1")
+	storePath              = flag.String("store", "", "SQLite database to save the stats of every SLO of the run into, e.g. vigil.db. adds the change of the minimum budget since the last stored run to the report. with vigil diff, the database of the compared runs")
+	targetName             = flag.String("target", "", "name of the target the run is stored under in --store, so the runs of several targets can share it, e.g. prod. set by vigil serve. with vigil diff, compare the last runs of this target")
+	uploadDest             = flag.String("upload", "", "upload the report to object storage under the date and time of the run, e.g. gs://bucket/reports/ or s3://bucket/reports/")
+	pushgateway            = flag.String("pushgateway", "", "Prometheus Pushgateway to push the stats of every SLO to as metrics, e.g. http://pushgateway:9091. pushed to the vigil job unless the URL has a /metrics/job/<job> path")
+	emailTo                = flag.String("email-to", "", "comma separated addresses to email the summary of the run to, with the report attached, e.g. sre@example.com")
+	emailFrom              = flag.String("email-from", "", "sender address of --email-to")
+	smtpServer             = flag.String("smtp-server", "localhost:25", "SMTP server of --email-to as host:port. port 465 uses TLS, others STARTTLS when offered. authenticates with SMTP_USERNAME and SMTP_PASSWORD")
+	page                   = flag.String("page", "", "page the owning team of critical SLOs. pagerduty (with PAGERDUTY_ROUTING_KEY) or opsgenie (with OPSGENIE_API_KEY). needs --page-negative-fraction or --page-exhaustion-days")
+	pageNegativeFraction   = flag.Float64("page-negative-fraction", 0, "with --page, page SLOs whose error budget was negative for more than this fraction of the window. 0 ~ 1, 0 disables it")
+	pageExhaustionDays     = flag.Int("page-exhaustion-days", 0, "with --page, page SLOs whose error budget is projected to be exhausted within this many days. 0 disables it")
+	issues                 = flag.String("issues", "", "open a tracking issue for every flagged SLO, or comment on the one still open. github (with GITHUB_TOKEN) or jira (with JIRA_API_TOKEN)")
+	githubRepo             = flag.String("github-repo", "", "with --issues github, repository to open the issues in, owner/name. defaults to GITHUB_REPOSITORY")
+	jiraURL                = flag.String("jira-url", "", "with --issues jira, URL of the Jira site, e.g. https://example.atlassian.net")
+	jiraProject            = flag.String("jira-project", "", "with --issues jira, key of the project to open the issues in, e.g. SRE")
+	jiraIssueType          = flag.String("jira-issue-type", "Task", "with --issues jira, type of the issues opened")
+	webhookURL             = flag.String("webhook-url", "", "URL to POST the JSON results of the run to, signed with VIGIL_WEBHOOK_SECRET in the X-Vigil-Signature-256 header")
+	teamsWebhook           = flag.String("teams-webhook", "", "Microsoft Teams Workflows webhook to post the summary of the run to as an Adaptive Card")
+	googleChatWebhook      = flag.String("google-chat-webhook", "", "Google Chat incoming webhook to post the summary of the run to as a card")
+	listenAddr             = flag.String("listen", ":8080", "with vigil serve, address the HTTP server listens on")
+	reportsDir             = flag.String("reports-dir", "reports", "with vigil serve, directory the reports are written into, a subdirectory per target")
+	recordPath             = flag.String("record", "", "record the API calls of the gcp and datadog providers into this cassette file, e.g. testdata/datadog.json, to replay them with --replay. request headers, and so credentials, are left out")
+	replayPath             = flag.String("replay", "", "answer the API calls of the gcp and datadog providers from a cassette file written by --record, without credentials or network access")
+	includePatterns        patternsFlag
+	// extraWindows are the windows after the first one of --window.
+	extraWindows    []time.Duration
+	excludePatterns patternsFlag
+	warnMessages    = []string{}
+	warnMutex       sync.Mutex
+	// skippedSLOs is the number of SLOs left out of an incomplete report because the run was cancelled.
+	skippedSLOs int
+	// sloFailures are the SLOs that failed with --continue-on-error.
+	sloFailures []sloFailure
+	// auditOptions are the options the SLOs were audited with, which decide the checks and flags the summary counts.
+	auditOptions vigil.Options
+	// interactive is set by the tui subcommand, which browses the results instead of writing a report.
+	interactive bool
+	// coverageMode is set by the coverage subcommand, which reports the services missing SLOs instead of scanning them.
+	coverageMode bool
+	// applyMode is set by the apply subcommand, which updates the recommended goals instead of writing a report.
+	applyMode bool
 )
 
 func main() {
-	flag.Parse()
+	flag.Var(windowsFlag{window, &extraWindows}, "window", "target window the error budget is replayed over. use \"h\" suffix, e.g. 720h for 30 days. comma separated, e.g. 168h,720h,2160h, to also summarize the SLOs over more windows")
+	flag.Var(&includePatterns, "include", "only audit SLOs matching a `pattern`: a glob or /regexp/ over the display name and service, or a key=glob label selector. repeat to give several")
+	flag.Var(&excludePatterns, "exclude", "skip SLOs matching a `pattern`. same syntax as --include. repeat to give several")
+	provider.RegisterFlags(flag.CommandLine)
+	flag.Usage = usage
+
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case cmdCompletion:
+			os.Exit(runCompletion(args[1:]))
+		case cmdDiff:
+			os.Exit(runDiff(args[1:]))
+		case cmdServe:
+			os.Exit(runServe(args[1:]))
+		case cmdScan:
+			args = args[1:]
+		case cmdTUI:
+			interactive = true
+			args = args[1:]
+		case cmdCoverage:
+			coverageMode = true
+			args = args[1:]
+		case cmdApply:
+			applyMode = true
+			args = args[1:]
+		}
+	}
+	os.Exit(run(args))
+}
+
+// run scans with the given flags. It is split out of main so that deferred cleanups happen before os.Exit.
+// Fatal errors are logged with log.Panicf and recovered here into exitError; runtime errors still crash with a stack trace.
+func run(args []string) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(runtime.Error); ok {
+				panic(r)
+			}
+			code = exitError
+		}
+	}()
+
+	// The flag set exits on parse errors, like flag.Parse.
+	_ = flag.CommandLine.Parse(args)
+	// The config is read before the flags are validated, since its flags section sets some of them.
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if err := cfg.applyFlags(); err != nil {
+		log.Panicf("%v", err)
+	}
 	validateFlags()
+	if interactive && (!isTerminal(os.Stdin) || !isTerminal(os.Stdout)) {
+		log.Panicf("vigil %s needs a terminal", cmdTUI)
+	}
 
-	ctx := context.Background()
+	filter, err := newSLOFilter(includePatterns, excludePatterns)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
 
-	var vigil Vigil
-	switch model.CloudProvider(*cloudProvider) {
-	case model.CloudProviderGCP:
-		gcpClient, err := gcp.NewClient(ctx, *gcpProjectID, *errorBudgetThreshold, *window)
+	spec := report.Default(i18n.Get(i18n.Lang(*lang)))
+	if len(extraWindows) > 0 {
+		spec.AddWindows(i18n.Get(i18n.Lang(*lang)), windows())
+	}
+	if slices.Contains(cloudProviders(), model.CloudProviderGCP) {
+		spec.AddService(i18n.Get(i18n.Lang(*lang)))
+		spec.AddAlerted(i18n.Get(i18n.Lang(*lang)))
+	}
+	if slices.Contains(cloudProviders(), model.CloudProviderDD) {
+		spec.AddMonitorIssues(i18n.Get(i18n.Lang(*lang)))
+	}
+	if *storePath != "" {
+		spec.AddChange(i18n.Get(i18n.Lang(*lang)))
+	}
+	if *reportSpec != "" {
+		spec, err = report.Load(*reportSpec)
 		if err != nil {
-			log.Panicf("Failed to create GCP client: %v", err)
+			log.Panicf("%v", err)
 		}
+	}
+
+	// The first SIGINT or SIGTERM cancels the run and a partial report is written. Once cancelled,
+	// the default signal handling is restored so a second Ctrl-C exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	opts := provider.Options{
+		ErrorBudgetThreshold: *errorBudgetThreshold,
+		Window:               *window,
+	}
+	if *recordPath != "" {
+		opts.Cassette = replay.Record(*recordPath)
+		// Deferred before the clients are closed, so the cassette is saved after them, even when the run fails.
 		defer func() {
-			if err := gcpClient.MonitoringClient.Close(); err != nil {
-				log.Printf("Failed to close MonitoringClient: %v", err)
-			}
-			if err := gcpClient.MetricClient.Close(); err != nil {
-				log.Printf("Failed to close MetricClient: %v", err)
+			if err := opts.Cassette.Save(); err != nil {
+				log.Printf("Failed to save the recorded API calls: %v", err)
+				return
 			}
+			infof("Recorded the API calls in %s", *recordPath)
 		}()
-		vigil = gcpClient
-	case model.CloudProviderDD:
-		ddClient, err := datadog.NewClient(ctx, *ddSite, *errorBudgetThreshold, *window)
+	} else if *replayPath != "" {
+		opts.Cassette, err = replay.Load(*replayPath)
 		if err != nil {
-			log.Panicf("Failed to create Datadog client: %v", err)
+			log.Panicf("%v", err)
 		}
-		vigil = ddClient
-	default:
-		log.Panicf("not supported cloud provider: %s", *cloudProvider)
 	}
 
-	log.Println("Getting SLOs...")
+	var clients []Vigil
+	for _, p := range cloudProviders() {
+		client, err := provider.New(ctx, p, opts)
+		if err != nil {
+			log.Panicf("Failed to create %s client: %v", p, err)
+		}
+		clients = append(clients, client)
+	}
+	for _, path := range pluginPaths() {
+		client, err := plugin.Open(ctx, path, opts)
+		if err != nil {
+			log.Panicf("Failed to load provider plugin: %v", err)
+		}
+		clients = append(clients, client)
+	}
+	defer func() {
+		for _, client := range clients {
+			if err := client.Close(); err != nil {
+				log.Printf("Failed to close %s client: %v", client.GetProvider(), err)
+			}
+		}
+	}()
+
+	startedAt := time.Now()
+	infof("Getting SLOs...")
 
-	slos, err := vigil.GetSLOs(ctx)
-	if err != nil {
-		log.Panicf("Failed to list SLOs: %v", err)
+	var slos []*model.SLO
+	sloClients := make(map[*model.SLO]Vigil)
+	for _, client := range clients {
+		providerSLOs, err := client.GetSLOs(ctx)
+		var partial *provider.PartialError
+		if errors.As(err, &partial) {
+			warnMutex.Lock()
+			for _, skipped := range partial.Skipped {
+				warnMessages = append(warnMessages, fmt.Sprintf("%s SLOs skipped: %s", client.GetProvider(), skipped))
+			}
+			warnMutex.Unlock()
+		} else if err != nil {
+			log.Panicf("Failed to list %s SLOs: %v", client.GetProvider(), err)
+		}
+		if selected := filter.apply(providerSLOs); len(selected) != len(providerSLOs) {
+			infof("%d of %d %s SLOs match --include and --exclude", len(selected), len(providerSLOs), client.GetProvider())
+			providerSLOs = selected
+		}
+		for _, slo := range providerSLOs {
+			// Prefix with the provider so identically named SLOs from different providers don't collide.
+			if len(clients) > 1 {
+				slo.DisplayName = fmt.Sprintf("[%s] %s", client.GetProvider(), slo.DisplayName)
+			}
+			sloClients[slo] = client
+		}
+		slos = append(slos, providerSLOs...)
 	}
 
-	bar := progressbar.Default(int64(len(slos)))
+	if *dryRun {
+		printPlan(slos, sloClients, cfg)
+		return exitOK
+	}
+	if coverageMode {
+		return runCoverage(ctx, clients, slos, sloClients)
+	}
 
-	var sloData = make(map[string]*model.SLOData)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, maxConcurrency)
-	errChan := make(chan error, len(slos))
+	// The TUI writes files only when rows are exported, so there is nothing to check up front.
+	var path string
+	if !interactive && !applyMode && *format != formatTable && *format != formatGitHub {
+		var err error
+		path, err = outputPath(slos, time.Now())
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+	}
 
-	for _, slo := range slos {
-		wg.Add(1)
+	bar := newProgressBar(len(slos))
+	auditOpts := vigilOptions(cfg)
+	auditOpts.Progress = func(*model.SLO) {
+		if err := bar.Add(1); err != nil {
+			log.Printf("Failed to update progress bar: %v", err)
+		}
+	}
+	result, err := vigil.Audit(ctx, slos, sloClients, auditOpts)
+	if err := bar.Finish(); err != nil {
+		log.Printf("Failed to finish progress bar: %v", err)
+	}
+	// Only a cancelled run has a result along with its error.
+	if result == nil {
+		log.Panicf("Error in processing SLOs: %v", err)
+	}
+	sloData := result.SLOs
+	sloFailures = result.Failures
+	auditOptions = result.Options
+	for _, f := range sloFailures {
+		log.Printf("Failed to process SLO %s: %v", f.DisplayName, f.Error)
+	}
 
-		sem <- struct{}{}
+	if ctx.Err() != nil {
+		skippedSLOs = result.Skipped
+		log.Printf("Run cancelled (%v): writing an incomplete report without %d of %d SLOs", context.Cause(ctx), skippedSLOs, len(slos))
+	}
 
-		go func(s *model.SLO) {
-			defer wg.Done()
-			defer func() { <-sem }()
+	if applyMode {
+		if ctx.Err() != nil {
+			log.Panicf("Run cancelled before every SLO was scanned: no goals updated")
+		}
+		for _, msg := range warnMessages {
+			infof("%s", msg)
+		}
+		return runApply(ctx, slos, sloClients, sloData)
+	}
 
-			data, err := processSLO(ctx, vigil, s)
-			if err != nil {
-				errChan <- fmt.Errorf("failed to process SLO %s: %w", s.DisplayName, err)
-				return
-			}
-			if data != nil {
-				mu.Lock()
-				for k, v := range data {
-					sloData[k] = v
-				}
-				err := bar.Add(1)
-				if err != nil {
-					log.Printf("Failed to update progress bar: %v", err)
-				}
-				mu.Unlock()
-			}
-		}(slo)
+	// An incomplete run is not stored, so the next one is not compared against the SLOs it missed.
+	if *storePath != "" {
+		if ctx.Err() != nil {
+			infof("Run cancelled: not stored in %s", *storePath)
+		} else {
+			storeRun(ctx, *storePath, startedAt, sloData)
+		}
+	}
+	// Nor pushed, since the push replaces the metrics of every SLO.
+	if *pushgateway != "" {
+		if ctx.Err() != nil {
+			infof("Run cancelled: metrics not pushed to %s", *pushgateway)
+		} else {
+			pushMetrics(ctx, *pushgateway, startedAt, sloData)
+		}
 	}
 
-	wg.Wait()
-	close(errChan)
-	err = bar.Finish()
-	if err != nil {
-		log.Printf("Failed to finish progress bar: %v", err)
+	if interactive {
+		runTUI(sloData, spec)
+		path = ""
+	} else if err := newReporter(*format, spec, path).Write(sloData); err != nil {
+		log.Panicf("Failed to write the report: %v", err)
 	}
 
-	if len(errChan) > 0 {
-		err = <-errChan
-		log.Panicf("Error in processing SLOs: %v", err)
+	// A partial report of a cancelled run is still uploaded, marked as incomplete like the local file.
+	if *uploadDest != "" && path != "" {
+		uploadReport(context.WithoutCancel(ctx), *uploadDest, path, startedAt)
 	}
 
-	msgs := i18n.Get(i18n.Lang(*lang))
-	generateExcelReport(sloData, msgs)
+	if *emailTo != "" {
+		emailReport(context.WithoutCancel(ctx), sloData, i18n.Get(i18n.Lang(*lang)), path)
+	}
 
-	for _, msg := range warnMessages {
-		log.Println(msg)
+	// Pages are deduplicated per SLO, so the critical SLOs of a cancelled run are paged too.
+	if *page != "" {
+		pageCritical(context.WithoutCancel(ctx), sloData)
 	}
 
-	log.Println("Report has been written to slo_report.xlsx")
-}
+	if *teamsWebhook != "" || *googleChatWebhook != "" {
+		notifyChats(context.WithoutCancel(ctx), sloData, i18n.Get(i18n.Lang(*lang)))
+	}
 
-func processSLO(ctx context.Context, client Vigil, slo *model.SLO) (map[string]*model.SLOData, error) {
-	var (
-		data = make(map[string]*model.SLOData)
-	)
+	// The payload of a cancelled run is marked as incomplete in its summary.
+	if *webhookURL != "" {
+		sendWebhook(context.WithoutCancel(ctx), *webhookURL, sloData)
+	}
 
-	goodQuery, totalQuery, points, err := client.GetErrorBudgetTimeSeries(ctx, slo)
-	if err != nil {
-		if strings.Contains(err.Error(), "no data points found") {
-			warnMutex.Lock()
-			warnMessages = append(warnMessages, err.Error())
-			warnMutex.Unlock()
-			return nil, nil
-		}
-		return nil, err
+	if *issues != "" {
+		trackIssues(context.WithoutCancel(ctx), sloData, startedAt)
 	}
 
-	flagBelowThreshold := true // The error budget has never been below n% for m days
-	for _, point := range points {
-		if point < *errorBudgetThreshold {
-			flagBelowThreshold = false
-			break
-		}
+	if *exportGoalsDir != "" {
+		exportGoals(context.WithoutCancel(ctx), *exportGoalsDir, slos, sloClients, sloData)
 	}
 
-	flagNegative := utils.IsPercentNegative(points, 0.5) // Error budget is a negative throughout the window
+	for _, msg := range warnMessages {
+		infof("%s", msg)
+	}
+
+	if path != "" {
+		infof("Report has been written to %s", path)
+	}
 
-	minBudget, avgBudget := utils.GetMinAvgErrorBudget(points)
+	if skippedSLOs > 0 {
+		return exitError
+	}
+	if tooManyFailures(len(slos)) {
+		log.Printf("%d of %d SLOs failed, more than --max-error-ratio %g", len(sloFailures), len(slos), *maxErrorRatio)
+		return exitError
+	}
 
-	data[slo.DisplayName] = &model.SLOData{
-		Flag:       flagBelowThreshold || flagNegative,
-		SLO:        slo.Goal,
-		GoodQuery:  goodQuery,
-		TotalQuery: totalQuery,
-		AvgBudget:  avgBudget,
-		MinBudget:  minBudget,
+	if *failOnFlag {
+		flagged := 0
+		for _, v := range sloData {
+			if v.Flag {
+				flagged++
+			}
+		}
+		if flagged > 0 {
+			infof("%d of %d SLOs are flagged", flagged, len(sloData))
+			return exitFlagged
+		}
 	}
 
-	return data, nil
+	return exitOK
+}
+
+// vigilOptions returns the options of the analysis from the flags and the overrides of cfg.
+func vigilOptions(cfg *config) vigil.Options {
+	opts := vigil.Options{
+		Window:            *window,
+		Windows:           extraWindows,
+		BurnRateThreshold: *burnRateThreshold,
+		FlagDegrading:     *flagDegrading,
+		FlagExhaustion:    *flagExhaustion,
+		DetectAnomalies:   *detectAnomalies,
+		TeamLabel:         *teamLabel,
+		Trim:              *trim,
+		// --min-points 0 still needs a point to categorize an SLO, as the zero value is the default.
+		MinPoints:       max(*minPoints, 1),
+		Concurrency:     maxConcurrency,
+		ContinueOnError: *continueOnError,
+		Settings:        cfg.settings,
+		Debugf:          debugf,
+	}
+	opts.Checks = analysisChecks(opts)
+	return opts
 }
 
 func validateFlags() {
@@ -179,156 +476,193 @@ func validateFlags() {
 	if *window <= 0 {
 		log.Panicf("--window must be positive duration")
 	}
+	if *timeout < 0 {
+		log.Panicf("--timeout must not be negative")
+	}
+	if *negativeBudgetFraction <= 0 || *negativeBudgetFraction > 1 {
+		log.Panicf("--negative-budget-fraction must be greater than 0 and at most 1")
+	}
+	if *burnRateThreshold < 0 {
+		log.Panicf("--burn-rate-threshold must not be negative")
+	}
+	if *groupBy != groupByProject && *groupBy != groupByTeam {
+		log.Panicf("--group-by must be %s or %s", groupByProject, groupByTeam)
+	}
+	if *top < 0 {
+		log.Panicf("--top must not be negative")
+	}
+	if *trim < 0 || *trim >= 0.5 {
+		log.Panicf("--trim must be at least 0 and less than 0.5")
+	}
+	if *minPoints < 0 {
+		log.Panicf("--min-points must not be negative")
+	}
+	if *maxErrorRatio < 0 || *maxErrorRatio > 1 {
+		log.Panicf("--max-error-ratio must be between 0 and 1")
+	}
+	for _, name := range checkNames() {
+		if !slices.Contains(analysis.Names, name) {
+			log.Panicf("--checks: unknown check %q. one of %s", name, strings.Join(analysis.Names, ", "))
+		}
+	}
 
-	switch model.CloudProvider(*cloudProvider) {
-	case model.CloudProviderGCP:
-		if *gcpProjectID == "" {
-			log.Panicf("--gcp-project is required for GCP")
+	if len(cloudProviders()) == 0 && len(pluginPaths()) == 0 {
+		log.Panicf("--cloud or --provider-plugin is required")
+	}
+	for _, p := range cloudProviders() {
+		if err := provider.Validate(p); err != nil {
+			log.Panicf("%v", err)
 		}
-	case model.CloudProviderDD:
-		if _, ok := os.LookupEnv("DD_API_KEY"); !ok {
-			log.Panicf("DD_API_KEY environment variable is required for Datadog")
+	}
+
+	if (*approve || *rollbackPath != "") && !applyMode {
+		log.Panicf("--approve and --rollback-file are only used by vigil %s", cmdApply)
+	}
+
+	if *uploadDest != "" {
+		if _, err := upload.Parse(*uploadDest); err != nil {
+			log.Panicf("%v", err)
 		}
-		if _, ok := os.LookupEnv("DD_APP_KEY"); !ok {
-			log.Panicf("DD_APP_KEY environment variable is required for Datadog")
+		if *format == formatTable || *format == formatGitHub {
+			log.Panicf("--upload needs a report file, not --format %s", *format)
 		}
-	default:
-		log.Panicf("not supported cloud provider: %s. use 'gcp' or 'datadog'", *cloudProvider)
 	}
 
-	switch i18n.Lang(*lang) {
-	case i18n.LangEN, i18n.LangJA:
-		// valid
-	default:
-		log.Panicf("--lang must be 'en' or 'ja'")
+	if *emailTo != "" {
+		if _, err := mail.ParseAddressList(*emailTo); err != nil {
+			log.Panicf("Invalid --email-to: %v", err)
+		}
+		if _, err := mail.ParseAddress(*emailFrom); err != nil {
+			log.Panicf("--email-to needs a valid --email-from: %v", err)
+		}
 	}
-}
-func generateExcelReport(data map[string]*model.SLOData, msgs *i18n.Messages) {
-	f := excelize.NewFile()
-	defer func() {
-		err := f.Close()
-		if err != nil {
-			log.Printf("Failed to close file: %v", err)
+	if *pushgateway != "" {
+		if _, err := pushgatewayURL(*pushgateway); err != nil {
+			log.Panicf("%v", err)
 		}
-	}()
-
-	boldStyle := createStyle(f, &excelize.Font{Bold: true})
-	highlightStyle := createStyle(f, &excelize.Font{Bold: true}, excelize.Fill{
-		Type:    "pattern",
-		Pattern: 1,
-		Color:   []string{"21CE9C"},
-	})
-	descriptionStyle := createStyle(f, &excelize.Font{
-		Bold:  true,
-		Color: "DE3163",
-	}, excelize.Alignment{WrapText: true})
-
-	setColWidth(f, "Sheet1", map[string]float64{
-		"A":   50,
-		"B-E": 10,
-		"F-I": 50,
-	})
-	setSheetView(f)
-	setProperty(f, msgs)
-	providerInfo := *cloudProvider
-	if *cloudProvider == string(model.CloudProviderGCP) {
-		providerInfo = *gcpProjectID
 	}
-	setCellWithStyle(f, "A1", fmt.Sprintf(msgs.ReportDescription, providerInfo, *errorBudgetThreshold*100, window.Hours()/24), descriptionStyle)
-	setCellWithStyle(f, "F1", msgs.GeneratedBy, descriptionStyle)
-	setCellWithStyle(f, "C2", msgs.NewSLO, highlightStyle)
 
-	for i, h := range msgs.Headers() {
-		setCellWithStyle(f, fmt.Sprintf("%c2", 'A'+i), h, boldStyle)
+	if *page != "" {
+		if _, err := newPager(*page); err != nil {
+			log.Panicf("%v", err)
+		}
+		if *pageNegativeFraction <= 0 && *pageExhaustionDays <= 0 {
+			log.Panicf("--page needs --page-negative-fraction or --page-exhaustion-days")
+		}
+	}
+	for name, webhook := range map[string]string{"teams-webhook": *teamsWebhook, "google-chat-webhook": *googleChatWebhook} {
+		if webhook == "" {
+			continue
+		}
+		if err := validateChatWebhook(name, webhook); err != nil {
+			log.Panicf("%v", err)
+		}
+	}
+	if *webhookURL != "" {
+		if err := validateWebhookURL(*webhookURL); err != nil {
+			log.Panicf("%v", err)
+		}
+	}
+	if *issues != "" {
+		if _, err := newTracker(*issues); err != nil {
+			log.Panicf("%v", err)
+		}
+	}
+	if *pageNegativeFraction < 0 || *pageNegativeFraction > 1 {
+		log.Panicf("--page-negative-fraction must be between 0 and 1")
+	}
+	if *pageExhaustionDays < 0 {
+		log.Panicf("--page-exhaustion-days must not be negative")
 	}
 
-	row := 3
-	for k, v := range data {
-		if v.Flag {
-			setCellValue(f, fmt.Sprintf("A%d", row), k)
-			setCellValue(f, fmt.Sprintf("B%d", row), v.SLO*100)
-			setCellWithStyle(f, fmt.Sprintf("C%d", row), 0, highlightStyle)
-			setCellValue(f, fmt.Sprintf("D%d", row), v.MinBudget*100)
-			setCellValue(f, fmt.Sprintf("E%d", row), v.AvgBudget*100)
-			setCellValue(f, fmt.Sprintf("F%d", row), v.GoodQuery)
-			setCellValue(f, fmt.Sprintf("G%d", row), v.TotalQuery)
-			row++
+	if *quiet && *verbose {
+		log.Panicf("--quiet and --verbose are mutually exclusive")
+	}
+	if *recordPath != "" || *replayPath != "" {
+		if *recordPath != "" && *replayPath != "" {
+			log.Panicf("--record and --replay are mutually exclusive")
+		}
+		for _, p := range cloudProviders() {
+			if p != model.CloudProviderGCP && p != model.CloudProviderDD {
+				log.Panicf("--record and --replay only support the gcp and datadog providers, got %s", p)
+			}
+		}
+		if len(pluginPaths()) > 0 {
+			log.Panicf("--record and --replay do not support provider plugins")
 		}
 	}
 
-	setCellWithStyle(f, "C2", msgs.NewSLO, highlightStyle)
+	if !i18n.Supported(i18n.Lang(*lang)) {
+		log.Panicf("--lang must be 'en' or 'ja'")
+	}
 
-	err := f.SaveAs("slo_report.xlsx")
-	if err != nil {
-		log.Panicf("Failed to save file: %v", err)
+	switch *sortOrder {
+	case sortName, sortMinBudget, sortAvgBudget:
+		// valid
+	default:
+		log.Panicf("--sort must be 'name', 'min-budget' or 'avg-budget'")
 	}
-}
 
-func createStyle(f *excelize.File, font *excelize.Font, opts ...interface{}) int {
-	style := &excelize.Style{Font: font}
-	for _, opt := range opts {
-		switch v := opt.(type) {
-		case excelize.Alignment:
-			style.Alignment = &v
-		case excelize.Fill:
-			style.Fill = v
-		}
+	switch *format {
+	case formatXLSX, formatCSV, formatJSON, formatHTML, formatPDF, formatSARIF, formatTable, formatGitHub:
+		// valid
+	default:
+		log.Panicf("--format must be 'xlsx', 'csv', 'json', 'html', 'pdf', 'sarif', 'table' or 'github'")
 	}
-	styleID, err := f.NewStyle(style)
-	handleError(err, "Failed to create style")
-	return styleID
 }
 
-func setProperty(f *excelize.File, msgs *i18n.Messages) {
-	err := f.SetDocProps(&excelize.DocProperties{
-		Created:        time.Now().Format(time.RFC3339),
-		Creator:        "Vigil",
-		Description:    msgs.GeneratedBy,
-		Identifier:     "xlsx",
-		LastModifiedBy: "Vigil https://github.com/rluisr/vigil",
-		Modified:       time.Now().Format(time.RFC3339),
-		Revision:       "0",
-		Subject:        msgs.ReportTitle,
-		Title:          msgs.ReportTitle,
-	})
-
-	handleError(err, "Failed to set doc properties")
-}
+// cloudProviders returns the providers given to --cloud as a comma separated list, without duplicates.
+// The default provider is dropped when only plugins were requested.
+func cloudProviders() []model.CloudProvider {
+	if len(pluginPaths()) > 0 && !isFlagSet("cloud") {
+		return nil
+	}
 
-func setSheetView(f *excelize.File) {
-	handleError(f.SetSheetView("Sheet1", 0, &excelize.ViewOptions{
-		ShowGridLines: &[]bool{true}[0],
-		ZoomScale:     &[]float64{150}[0],
-	}), "Failed to set sheet view")
-	f.SetActiveSheet(0)
+	var providers []model.CloudProvider
+	seen := make(map[model.CloudProvider]bool)
+	for _, p := range strings.Split(*cloudProvider, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[model.CloudProvider(p)] {
+			continue
+		}
+		seen[model.CloudProvider(p)] = true
+		providers = append(providers, model.CloudProvider(p))
+	}
+	return providers
 }
 
-func setColWidth(f *excelize.File, sheet string, columns map[string]float64) {
-	for rangeStr, width := range columns {
-		// split range e.g B-E
-		parts := strings.SplitN(rangeStr, "-", 2)
-		startCol := parts[0]
-		endCol := startCol
-		if len(parts) > 1 {
-			endCol = parts[1]
+// pluginPaths returns the plugin binaries given to --provider-plugin.
+func pluginPaths() []string {
+	var paths []string
+	for _, p := range strings.Split(*providerPlugins, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
 		}
-
-		err := f.SetColWidth(sheet, startCol, endCol, width)
-		handleError(err, "Failed to set column width")
 	}
+	return paths
 }
 
-func setCellWithStyle(f *excelize.File, cell string, value interface{}, styleID int) {
-	handleError(f.SetCellValue("Sheet1", cell, value), "Failed to set cell value")
-	handleError(f.SetCellStyle("Sheet1", cell, cell, styleID), "Failed to set cell style")
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
 }
 
-func setCellValue(f *excelize.File, cell string, value interface{}) {
-	handleError(f.SetCellValue("Sheet1", cell, value), "Failed to set cell value")
+// reportDescription returns the localized description under the report title.
+func reportDescription(msgs *i18n.Messages) string {
+	return fmt.Sprintf(msgs.ReportDescription, reportTarget(), *errorBudgetThreshold*100, window.Hours()/24, *negativeBudgetFraction*100)
 }
 
-func handleError(err error, message string) {
-	if err != nil {
-		log.Fatalf("%s: %v", message, err)
+// reportTarget returns a human readable description of what was scanned for the report title.
+func reportTarget() string {
+	var targets []string
+	for _, p := range cloudProviders() {
+		targets = append(targets, provider.Target(p))
 	}
+	targets = append(targets, pluginPaths()...)
+	return strings.Join(targets, ", ")
 }
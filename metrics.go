@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/rluisr/vigil/store"
+)
+
+// sloCount is the number of SLOs of a project and team in the last run of a target, and how many are flagged.
+type sloCount struct {
+	target, project, team string
+	slos, flagged         int
+}
+
+// handleMetrics serves the metrics of vigil serve itself in the Prometheus text exposition format, so alerts can fire
+// when scans fail or stop succeeding, along with the flagged SLOs of the last run of every target by project and team.
+func (s *server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.sloCounts(r)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
+	var buf bytes.Buffer
+	s.writeServeMetrics(&buf, counts, err == nil)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// sloCounts counts the SLOs of the last stored run of every target by project and team.
+func (s *server) sloCounts(r *http.Request) ([]sloCount, error) {
+	st, err := store.Open(*storePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = st.Close() }()
+
+	var counts []sloCount
+	for _, ts := range s.targets {
+		run, ok, err := st.Latest(r.Context(), ts.target.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		stats, err := st.Stats(r.Context(), run.ID)
+		if err != nil {
+			return nil, err
+		}
+		byGroup := make(map[[2]string]*sloCount)
+		for _, v := range stats {
+			c := byGroup[[2]string{v.Project, v.Team}]
+			if c == nil {
+				c = &sloCount{target: ts.target.Name, project: v.Project, team: v.Team}
+				byGroup[[2]string{v.Project, v.Team}] = c
+			}
+			c.slos++
+			if v.Flag {
+				c.flagged++
+			}
+		}
+		for _, c := range byGroup {
+			counts = append(counts, *c)
+		}
+	}
+	slices.SortFunc(counts, func(a, b sloCount) int {
+		return cmp.Or(cmp.Compare(a.target, b.target), cmp.Compare(a.project, b.project), cmp.Compare(a.team, b.team))
+	})
+	return counts, nil
+}
+
+// writeServeMetrics writes the metrics of the scans of every target, then counts, the SLOs of their last runs.
+// storeUp is false when the history store could not be read.
+func (s *server) writeServeMetrics(w io.Writer, counts []sloCount, storeUp bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gauge := func(name, help string) { fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name) }
+	counter := func(name, help string) { fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name) }
+	target := func(ts *targetState) string { return `target="` + labelValueEscaper.Replace(ts.target.Name) + `"` }
+
+	counter("vigil_scans_total", "Scans finished since the server started, by status, succeeded or failed.")
+	for _, ts := range s.targets {
+		for _, status := range []string{scanSucceeded, scanFailed} {
+			fmt.Fprintf(w, "vigil_scans_total{%s,status=\"%s\"} %d\n", target(ts), status, ts.scans[status])
+		}
+	}
+	counter("vigil_slo_errors_total", "SLOs the scans since the server started failed to scan on errors of the provider APIs, with --continue-on-error.")
+	for _, ts := range s.targets {
+		fmt.Fprintf(w, "vigil_slo_errors_total{%s} %d\n", target(ts), ts.sloErrors)
+	}
+	gauge("vigil_scan_running", "Whether a scan of the target is running, 1 or 0.")
+	for _, ts := range s.targets {
+		running := 0
+		if ts.running != nil {
+			running = 1
+		}
+		fmt.Fprintf(w, "vigil_scan_running{%s} %d\n", target(ts), running)
+	}
+	gauge("vigil_scan_duration_seconds", "Duration of the last scan of the target.")
+	for _, ts := range s.targets {
+		if ts.last != nil {
+			fmt.Fprintf(w, "vigil_scan_duration_seconds{%s} %g\n", target(ts), ts.duration.Seconds())
+		}
+	}
+	gauge("vigil_last_success_timestamp_seconds", "Time the last successful scan of the target started.")
+	for _, ts := range s.targets {
+		if !ts.lastSuccess.IsZero() {
+			fmt.Fprintf(w, "vigil_last_success_timestamp_seconds{%s} %d\n", target(ts), ts.lastSuccess.Unix())
+		}
+	}
+	gauge("vigil_next_scan_timestamp_seconds", "Time the next scheduled scan of the target starts.")
+	for _, ts := range s.targets {
+		if !ts.next.IsZero() {
+			fmt.Fprintf(w, "vigil_next_scan_timestamp_seconds{%s} %d\n", target(ts), ts.next.Unix())
+		}
+	}
+
+	up := 0
+	if storeUp {
+		up = 1
+	}
+	gauge("vigil_store_up", "Whether the history store could be read for the SLO metrics, 1 or 0.")
+	fmt.Fprintf(w, "vigil_store_up %d\n", up)
+	labels := func(c sloCount) string {
+		return fmt.Sprintf(`target="%s",project="%s",team="%s"`,
+			labelValueEscaper.Replace(c.target), labelValueEscaper.Replace(c.project), labelValueEscaper.Replace(c.team))
+	}
+	gauge("vigil_slos", "Number of SLOs scanned by the last run of the target, by project and team.")
+	for _, c := range counts {
+		fmt.Fprintf(w, "vigil_slos{%s} %d\n", labels(c), c.slos)
+	}
+	gauge("vigil_slos_flagged", "Number of SLOs flagged by the last run of the target, by project and team.")
+	for _, c := range counts {
+		fmt.Fprintf(w, "vigil_slos_flagged{%s} %d\n", labels(c), c.flagged)
+	}
+}
@@ -1,22 +1,241 @@
 // Package model defines domain types for SLO analysis.
 package model
 
+import "time"
+
 // SLO represents a service level objective from a cloud provider.
 type SLO struct {
 	Name        string
 	DisplayName string
+	Project     string
 	Goal        float64
-	SLI         interface{}
+	// Service is the service the SLO belongs to, empty when the provider has no such concept.
+	Service string
+	// Labels are the provider's labels or tags of the SLO, used by label selectors in --include and --exclude.
+	Labels map[string]string
+	// ServiceLabels are the labels of the service the SLO belongs to, where ownership is often recorded. Empty when
+	// the provider has no such concept.
+	ServiceLabels map[string]string
+	// ServiceType and ServiceResource identify the workload behind Service: its type, e.g. Cloud Run or GKE
+	// workload, and the resource labels that locate it, such as its cluster and namespace. Empty when the provider
+	// does not know it.
+	ServiceType     string
+	ServiceResource map[string]string
+	// Window overrides the window the provider was created with when non-zero. Providers must honor it.
+	Window time.Duration
+	// Period is the compliance period the SLO is configured with in the provider, e.g. its rolling window. It is
+	// the default window of the SLO, zero when the provider does not tell.
+	Period time.Duration
+	// ConsoleURL deep-links to the SLO in the provider's web console, empty when the provider has none.
+	ConsoleURL string
+	// Kind is what the SLO measures, empty when the provider cannot tell from its SLI.
+	Kind SLOKind
+	SLI  interface{}
+}
+
+// SLOKind is what an SLO measures. A service is usually expected to have an SLO of each kind.
+type SLOKind string
+
+// Kinds of SLOs.
+const (
+	SLOKindAvailability SLOKind = "availability"
+	SLOKindLatency      SLOKind = "latency"
+)
+
+// Service is a service of a provider that SLOs can be defined for, listed to find services without any.
+type Service struct {
+	Name    string
+	Project string
+}
+
+// Point is a data point of an error budget series.
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Category is the finding of an SLO. Each one calls for a different action, so the reports keep them apart.
+type Category string
+
+// Categories of SLOs.
+const (
+	// CategoryLax SLOs never came close to spending their budget: the objective can likely be tightened.
+	CategoryLax Category = "LAX"
+	// CategoryBurning SLOs spend their budget faster than they should: the objective is likely too strict or the
+	// service needs attention.
+	CategoryBurning Category = "BURNING"
+	// CategoryHealthy SLOs need no action.
+	CategoryHealthy Category = "HEALTHY"
+	// CategoryNoData SLOs have no or too few error budget data points for a recommendation, usually because their SLI
+	// pipeline is broken.
+	CategoryNoData Category = "NO_DATA"
+)
+
+// Finding is what a check of the analysis found about an SLO: the category it puts the SLO in and why.
+type Finding struct {
+	Check    string   `json:"check"`
+	Category Category `json:"category"`
+	Reason   string   `json:"reason"`
 }
 
-// SLOData holds computed metrics for an SLO used in the Excel report.
+// Trends of the error budget series.
+const (
+	TrendDegrading = "degrading"
+	TrendStable    = "stable"
+	TrendImproving = "improving"
+)
+
+// SLOData holds computed metrics for an SLO used in the report.
 type SLOData struct {
-	Key        string
-	Flag       bool
-	TargetSLO  float64
-	SLO        float64
-	GoodQuery  string
-	TotalQuery string
-	AvgBudget  float64
-	MinBudget  float64
+	Key         string        `json:"key"`
+	DisplayName string        `json:"displayName"`
+	Project     string        `json:"project,omitempty"`
+	Service     string        `json:"service,omitempty"`
+	Team        string        `json:"team,omitempty"`
+	Provider    CloudProvider `json:"provider"`
+	Flag        bool          `json:"flag"`
+	Category    Category      `json:"category"`
+	TargetSLO   float64       `json:"targetSlo,omitempty"`
+	SLO         float64       `json:"slo"`
+	GoodQuery   string        `json:"goodQuery"`
+	TotalQuery  string        `json:"totalQuery"`
+	ConsoleURL  string        `json:"consoleUrl,omitempty"`
+	// Findings are the findings of every check of the analysis, the first one deciding Category. An SLO without any
+	// is HEALTHY.
+	Findings []Finding `json:"findings,omitempty"`
+	// Labels are the labels or tags of the SLO in the provider.
+	Labels map[string]string `json:"labels,omitempty"`
+	// ServiceType and ServiceResource identify the workload behind Service, see model.SLO.
+	ServiceType     string            `json:"serviceType,omitempty"`
+	ServiceResource map[string]string `json:"serviceResource,omitempty"`
+	// Points is the error budget series, oldest point first, and Timestamps the time of each point as reported by
+	// the provider.
+	Points     []float64   `json:"points"`
+	Timestamps []time.Time `json:"timestamps,omitempty"`
+	// BudgetStats summarizes Points. Its fields are promoted, so they read and serialize as fields of SLOData.
+	BudgetStats
+	// ErrorBudgetThreshold, Window and NegativeBudgetFraction are the settings the SLO was evaluated with, which
+	// differ from the report-wide ones when a --config override matched it.
+	ErrorBudgetThreshold   float64 `json:"errorBudgetThreshold"`
+	Window                 string  `json:"window"`
+	NegativeBudgetFraction float64 `json:"negativeBudgetFraction"`
+	// BurnRate1h to BurnRate72h are the peak burn rates over each lookback, where 1 consumes the budget in exactly
+	// one window. PeakBurnRate is the highest one sustained over both a lookback and the next longer one.
+	BurnRate1h   float64 `json:"burnRate1h"`
+	BurnRate6h   float64 `json:"burnRate6h"`
+	BurnRate24h  float64 `json:"burnRate24h"`
+	BurnRate72h  float64 `json:"burnRate72h"`
+	PeakBurnRate float64 `json:"peakBurnRate"`
+	// TimeToExhaustion is how long the remaining budget lasts at the burn rate of the last 24 hours, e.g. 36h.
+	// It is 0s when the budget is already exhausted and empty when it is not being consumed, or too slowly to tell.
+	TimeToExhaustion string `json:"timeToExhaustion,omitempty"`
+	// Slope is the change of the budget per day of the line fitted through the points, Trend its classification:
+	// degrading, stable or improving. ProjectedBudget extends the line one more window past the last point.
+	Slope           float64 `json:"slope"`
+	Trend           string  `json:"trend"`
+	ProjectedBudget float64 `json:"projectedBudget"`
+	// ExhaustionDate is when the budget is projected to hit zero at its current consumption rate, zero when it is
+	// not being consumed or not within one window from the run. ExhaustsWithinWindow is set when it is not zero.
+	ExhaustionDate       time.Time `json:"exhaustionDate,omitzero"`
+	ExhaustsWithinWindow bool      `json:"exhaustsWithinWindow"`
+	// Anomalies is the number of incidents, runs of anomalous budget consumption, found with --detect-anomalies.
+	// AnomalyShare is the share of the consumed budget they account for. IncidentDriven is set when a single
+	// incident accounts for most of it.
+	Anomalies      int     `json:"anomalies"`
+	AnomalyShare   float64 `json:"anomalyShare"`
+	IncidentDriven bool    `json:"incidentDriven"`
+	// LongestBreachHours is the longest contiguous period the budget stayed below ErrorBudgetThreshold, in hours.
+	// A single point counts for the spacing of the points, so a short dip is never reported as 0.
+	LongestBreachHours float64 `json:"longestBreachHours"`
+	// BudgetConsumed is the share of the budget consumed at the worst point of the window, 1 - MinBudget, above 1 once
+	// the budget went negative. BudgetConsumedTotal integrates the consumption over the window instead, so an SLO that
+	// kept spending while old errors left its rolling window is not mistaken for an idle one.
+	BudgetConsumed      float64 `json:"budgetConsumed"`
+	BudgetConsumedTotal float64 `json:"budgetConsumedTotal"`
+	// AllowedDowntimeMinutes is the downtime the goal allows over the window, BadMinutes the downtime spent at the
+	// last point and RemainingDowntimeMinutes what is left of it, negative once the budget is overspent.
+	AllowedDowntimeMinutes   float64 `json:"allowedDowntimeMinutes"`
+	BadMinutes               float64 `json:"badMinutes"`
+	RemainingDowntimeMinutes float64 `json:"remainingDowntimeMinutes"`
+	// Confidence scores the category, and so the recommendation, from the number of points and their spread: 0 ~ 1.
+	Confidence float64 `json:"confidence"`
+	// HealthScore combines the minimum budget, negative fraction, peak burn rate and trend: 0 ~ 100, 100 is healthy.
+	HealthScore float64 `json:"healthScore"`
+	// Breaches are the periods the budget stayed below ErrorBudgetThreshold, oldest first, listed for flagged SLOs
+	// so they can be correlated with incidents.
+	Breaches []Breach `json:"breaches,omitempty"`
+	// Status is the status of the SLO as computed by its provider, nil unless the provider computes one.
+	Status *SLOStatus `json:"status,omitempty"`
+	// Alerted tells whether an alert fires on the burn rate of the SLO, nil when the provider does not know.
+	Alerted *bool `json:"alerted,omitempty"`
+	// BadTimeInDowntime is the share of the bad time that fell inside a scheduled downtime, nil when the provider does
+	// not know.
+	BadTimeInDowntime *float64 `json:"badTimeInDowntime,omitempty"`
+	// MonitorIssues describes the monitors of the SLO that keep it from seeing bad time, e.g. "monitor 123 muted".
+	MonitorIssues []string `json:"monitorIssues,omitempty"`
+	// MinBudgetChange is the change of the minimum budget since the last run stored with --store, nil when that run did
+	// not have the SLO over the same window.
+	MinBudgetChange *float64 `json:"minBudgetChange,omitempty"`
+	// Windows summarizes the budget over every --window when more than one is given, the primary one first.
+	Windows []WindowStats `json:"windows,omitempty"`
+}
+
+// Breach is a period the error budget of an SLO stayed below its threshold. Its times are the Timestamps of the points
+// the budget crossed the threshold at.
+type Breach struct {
+	Start time.Time `json:"start"`
+	// End is when the budget got back to the threshold, zero when it is still below it at the end of the window.
+	End       time.Time `json:"end,omitzero"`
+	Hours     float64   `json:"hours"`
+	MinBudget float64   `json:"minBudget"`
+}
+
+// BudgetStats are the statistics of an error budget series that decide the category of an SLO.
+type BudgetStats struct {
+	AvgBudget        float64 `json:"avgBudget"`
+	MinBudget        float64 `json:"minBudget"`
+	NegativeFraction float64 `json:"negativeFraction"`
+	// TrafficWeighted is set when AvgBudget is weighted by the total events behind each point, for providers that
+	// know them, rather than the plain average of the points.
+	TrafficWeighted bool `json:"trafficWeighted"`
+	// TrimmedPoints is the number of the lowest points left out of MinBudget, AvgBudget and NegativeFraction with
+	// --trim. The other statistics use every point.
+	TrimmedPoints int `json:"trimmedPoints"`
+	// P50Budget to P999Budget are the budgets the series stayed at or above for 50%, 90%, 99% and 99.9% of the points.
+	// Like latency percentiles, a higher percentile looks further into the bad tail: a minimum far below P999Budget
+	// was a brief dip, one close to P90Budget a sustained one.
+	P50Budget  float64 `json:"p50Budget"`
+	P90Budget  float64 `json:"p90Budget"`
+	P99Budget  float64 `json:"p99Budget"`
+	P999Budget float64 `json:"p999Budget"`
+}
+
+// SLOStatus is the current status of an SLO as computed by its provider, beyond the error budget series.
+type SLOStatus struct {
+	// Compliance is the share of good events over the window, to compare with the goal.
+	Compliance float64 `json:"compliance"`
+	// BudgetRemaining is the remaining error budget in events, e.g. bad requests or bad windows, rather than as a
+	// fraction of the budget.
+	BudgetRemaining float64 `json:"budgetRemaining"`
+	// BurnRate is the burn rate over BurnRateLookback, e.g. 1h.
+	BurnRate         float64 `json:"burnRate"`
+	BurnRateLookback string  `json:"burnRateLookback"`
+}
+
+// GoalChange is a recommended new goal of an SLO, exported with --export-goals.
+type GoalChange struct {
+	SLO      *SLO
+	Goal     float64
+	Category Category
+}
+
+// WindowStats summarizes the error budget of an SLO over one of several windows.
+type WindowStats struct {
+	Window           string  `json:"window"`
+	MinBudget        float64 `json:"minBudget"`
+	AvgBudget        float64 `json:"avgBudget"`
+	NegativeFraction float64 `json:"negativeFraction"`
+	BudgetConsumed   float64 `json:"budgetConsumed"`
+	// Category only applies the thresholds: burn rates, trends and forecasts are computed over the primary window.
+	Category Category `json:"category"`
 }
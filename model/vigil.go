@@ -5,6 +5,9 @@ type CloudProvider string
 
 // Supported cloud providers.
 const (
-	CloudProviderGCP CloudProvider = "gcp"
-	CloudProviderDD  CloudProvider = "datadog"
+	CloudProviderGCP        CloudProvider = "gcp"
+	CloudProviderDD         CloudProvider = "datadog"
+	CloudProviderPrometheus CloudProvider = "prometheus"
+	CloudProviderNobl9      CloudProvider = "nobl9"
+	CloudProviderOpenSLO    CloudProvider = "openslo"
 )
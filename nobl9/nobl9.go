@@ -0,0 +1,301 @@
+// Package nobl9 provides a Nobl9 SLO client implementing the Vigil interface.
+package nobl9
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// DefaultURL is the Nobl9 SaaS endpoint.
+const DefaultURL = "https://app.nobl9.com"
+
+// pageLimit is the number of SLOs requested per page from the SLO status API.
+const pageLimit = 100
+
+// tokenExpiryMargin renews the access token slightly before it actually expires.
+const tokenExpiryMargin = time.Minute
+
+// Client is a Nobl9 API client.
+type Client struct {
+	httpClient           *http.Client
+	baseURL              *url.URL
+	organization         string
+	clientID             string
+	clientSecret         string
+	tokenMu              sync.Mutex
+	token                string
+	tokenExpiry          time.Time
+	ErrorBudgetThreshold float64
+	Window               time.Duration
+}
+
+// Objective identifies a single objective of a Nobl9 SLO.
+// Each objective carries its own target, so vigil treats it as an individual SLO.
+type Objective struct {
+	Project   string
+	SLO       string
+	Service   string
+	Name      string
+	TimeFrame string
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type slosResponse struct {
+	Data  []sloStatus `json:"data"`
+	Links struct {
+		Cursor string `json:"cursor"`
+		Next   string `json:"next"`
+	} `json:"links"`
+}
+
+type sloStatus struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Project     string `json:"project"`
+	Service     string `json:"service"`
+	TimeWindows []struct {
+		Unit      string `json:"unit"`
+		Count     int    `json:"count"`
+		IsRolling bool   `json:"isRolling"`
+	} `json:"timeWindows"`
+	Objectives []struct {
+		Name        string  `json:"name"`
+		DisplayName string  `json:"displayName"`
+		Target      float64 `json:"target"`
+	} `json:"objectives"`
+}
+
+type historyResponse struct {
+	ErrorBudgetRemaining []struct {
+		Timestamp time.Time `json:"timestamp"`
+		Value     *float64  `json:"value"`
+	} `json:"errorBudgetRemainingPercentage"`
+}
+
+// NewClient creates a new Nobl9 client. Requires NOBL9_CLIENT_ID and NOBL9_CLIENT_SECRET environment variables.
+func NewClient(_ context.Context, organization, nobl9URL string, errorBudgetThreshold float64, window time.Duration) (*Client, error) {
+	clientID, ok := os.LookupEnv("NOBL9_CLIENT_ID")
+	if !ok {
+		return nil, errors.New("NOBL9_CLIENT_ID environment variable is required")
+	}
+	clientSecret, ok := os.LookupEnv("NOBL9_CLIENT_SECRET")
+	if !ok {
+		return nil, errors.New("NOBL9_CLIENT_SECRET environment variable is required")
+	}
+
+	if nobl9URL == "" {
+		nobl9URL = DefaultURL
+	}
+	baseURL, err := url.Parse(nobl9URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse nobl9 url: %w", err)
+	}
+
+	return &Client{
+		httpClient:           &http.Client{Timeout: time.Minute},
+		baseURL:              baseURL,
+		organization:         organization,
+		clientID:             clientID,
+		clientSecret:         clientSecret,
+		ErrorBudgetThreshold: errorBudgetThreshold,
+		Window:               window,
+	}, nil
+}
+
+// GetProvider returns the Nobl9 provider identifier.
+func (c *Client) GetProvider() model.CloudProvider {
+	return model.CloudProviderNobl9
+}
+
+// Close releases idle HTTP connections.
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// GetSLOs retrieves all SLO objectives across every project from the SLO status API with pagination.
+func (c *Client) GetSLOs(ctx context.Context) ([]*model.SLO, error) {
+	var (
+		slos   []*model.SLO
+		cursor string
+	)
+
+	for {
+		params := url.Values{"limit": {strconv.Itoa(pageLimit)}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		var resp slosResponse
+		if err := c.get(ctx, "/api/v2/slos", params, &resp); err != nil {
+			return nil, fmt.Errorf("failed to list SLOs: %w", err)
+		}
+
+		for _, s := range resp.Data {
+			timeFrame := ""
+			if len(s.TimeWindows) > 0 {
+				timeFrame = fmt.Sprintf("%d%s", s.TimeWindows[0].Count, s.TimeWindows[0].Unit)
+			}
+
+			for _, o := range s.Objectives {
+				displayName := s.DisplayName
+				if displayName == "" {
+					displayName = s.Name
+				}
+				objectiveName := o.DisplayName
+				if objectiveName == "" {
+					objectiveName = o.Name
+				}
+
+				slos = append(slos, &model.SLO{
+					Name:        fmt.Sprintf("%s/%s/%s", s.Project, s.Name, o.Name),
+					DisplayName: fmt.Sprintf("%s (%s)", displayName, objectiveName),
+					Project:     s.Project,
+					Goal:        o.Target,
+					Service:     s.Service,
+					SLI: Objective{
+						Project:   s.Project,
+						SLO:       s.Name,
+						Service:   s.Service,
+						Name:      o.Name,
+						TimeFrame: timeFrame,
+					},
+				})
+			}
+		}
+
+		if resp.Links.Cursor == "" || len(resp.Data) == 0 {
+			break
+		}
+		cursor = resp.Links.Cursor
+	}
+
+	return slos, nil
+}
+
+// GetErrorBudgetTimeSeries fetches the remaining error budget history for a given SLO objective.
+func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, error) {
+	objective, ok := slo.SLI.(Objective)
+	if !ok {
+		return "", "", nil, fmt.Errorf("SLI is not of expected type: %T", slo.SLI)
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(cmp.Or(slo.Window, c.Window) * -1)
+
+	var resp historyResponse
+	err := c.get(ctx, "/api/timeseries/slo", url.Values{
+		"project":   {objective.Project},
+		"slo":       {objective.SLO},
+		"objective": {objective.Name},
+		"from":      {from.Format(time.RFC3339)},
+		"to":        {to.Format(time.RFC3339)},
+	}, &resp)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to get SLO history: %w", err)
+	}
+
+	var points []model.Point
+	for _, p := range resp.ErrorBudgetRemaining {
+		if p.Value == nil {
+			continue
+		}
+		points = append(points, model.Point{Time: p.Timestamp.UTC(), Value: *p.Value})
+	}
+
+	good := "objective: " + objective.Name
+	total := fmt.Sprintf("service: %s/%s, time window: %s", objective.Project, objective.Service, objective.TimeFrame)
+	if len(points) == 0 {
+		return good, total, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
+	}
+
+	return good, total, points, nil
+}
+
+// accessToken returns a cached access token, exchanging the client credentials when it is missing or expired.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpiry) {
+		return c.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL.JoinPath("/api/accessToken").String(), http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+	req.Header.Set("Organization", c.organization)
+
+	var token tokenResponse
+	if err := c.do(req, &token); err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	c.token = token.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+
+	return c.token, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Organization", c.organization)
+	req.Header.Set("Project", "*")
+
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package nobl9
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+func init() {
+	provider.Register(model.CloudProviderNobl9, &factory{})
+}
+
+type factory struct {
+	org string
+	url string
+}
+
+func (f *factory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.org, "nobl9-org", "", "nobl9 organization")
+	fs.StringVar(&f.url, "nobl9-url", DefaultURL, "nobl9 api url")
+}
+
+func (f *factory) Validate() error {
+	if f.org == "" {
+		return errors.New("--nobl9-org is required for Nobl9")
+	}
+	if _, ok := os.LookupEnv("NOBL9_CLIENT_ID"); !ok {
+		return errors.New("NOBL9_CLIENT_ID environment variable is required for Nobl9")
+	}
+	if _, ok := os.LookupEnv("NOBL9_CLIENT_SECRET"); !ok {
+		return errors.New("NOBL9_CLIENT_SECRET environment variable is required for Nobl9")
+	}
+	return nil
+}
+
+func (f *factory) New(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+	return NewClient(ctx, f.org, f.url, opts.ErrorBudgetThreshold, opts.Window)
+}
+
+func (f *factory) Target() string {
+	return fmt.Sprintf("Nobl9 (%s)", f.org)
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// Message is the summary of a run posted to a chat channel.
+type Message struct {
+	// Title is the headline of the message, e.g. "SLO Report (my-project)".
+	Title string
+	// Summary is the one line result of the run, e.g. "3 of 42 SLOs flagged".
+	Summary string
+	// Facts are the labeled values of the summary.
+	Facts [][2]string
+	// ListTitle heads List, e.g. "Worst Offenders (top 10)".
+	ListTitle string
+	List      []Link
+	// Footer closes the message, e.g. who generated it.
+	Footer string
+}
+
+// Link is a line of text linking to URL, plain text when URL is empty.
+type Link struct {
+	Text string
+	URL  string
+}
+
+// Notifier posts the summary of a run to a chat channel. A new channel only has to format the message as a card of
+// its own.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// postCard posts a card to an incoming webhook.
+func postCard(ctx context.Context, client *http.Client, url string, card interface{}) error {
+	return doJSON(ctx, client, http.MethodPost, url, nil, card, nil)
+}
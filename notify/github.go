@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// GitHubAPIURL is the REST API of github.com. GitHub Enterprise Server serves it at https://<host>/api/v3.
+const GitHubAPIURL = "https://api.github.com"
+
+// githubLabel is the label of the issues opened by vigil, which narrows down the open issues listed to find those of
+// the SLOs.
+const githubLabel = source
+
+// githubMarker is the hidden comment in the body of an issue that ties it to its SLO.
+var githubMarker = regexp.MustCompile(`<!-- (vigil-[0-9a-f]+) -->`)
+
+// GitHub opens issues in a repository with a token that can write its issues.
+type GitHub struct {
+	Token  string
+	Repo   string
+	URL    string
+	Client *http.Client
+
+	// open are the URLs and numbers of the open issues of vigil by the dedup key of their SLO, listed on first use.
+	open map[string]githubIssue
+}
+
+// NewGitHub returns a GitHub tracker for repo, owner/name, on the API at url.
+func NewGitHub(token, repo, url string) *GitHub {
+	return &GitHub{Token: token, Repo: repo, URL: strings.TrimSuffix(url, "/"), Client: &http.Client{Timeout: time.Minute}}
+}
+
+type githubIssue struct {
+	Number      int       `json:"number"`
+	HTMLURL     string    `json:"html_url"`
+	Body        string    `json:"body"`
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+// Track opens an issue labeled vigil for the SLO, or comments on its open issue.
+func (g *GitHub) Track(ctx context.Context, issue Issue) (string, bool, error) {
+	if g.open == nil {
+		open, err := g.listOpen(ctx)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to list the open issues of %s: %w", g.Repo, err)
+		}
+		g.open = open
+	}
+
+	key := dedupKey(issue.SLO)
+	if existing, ok := g.open[key]; ok {
+		url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", g.URL, g.Repo, existing.Number)
+		if err := doJSON(ctx, g.Client, http.MethodPost, url, g.header(), map[string]string{"body": issue.comment(markdown)}, nil); err != nil {
+			return "", false, fmt.Errorf("failed to comment on %s: %w", existing.HTMLURL, err)
+		}
+		return existing.HTMLURL, false, nil
+	}
+
+	req := map[string]interface{}{
+		"title":  issue.Title,
+		"body":   issue.body(markdown) + "\n<!-- " + key + " -->\n",
+		"labels": []string{githubLabel},
+	}
+	var created githubIssue
+	if err := doJSON(ctx, g.Client, http.MethodPost, fmt.Sprintf("%s/repos/%s/issues", g.URL, g.Repo), g.header(), req, &created); err != nil {
+		return "", false, fmt.Errorf("failed to open an issue in %s for %s: %w", g.Repo, issue.SLO.DisplayName, err)
+	}
+	g.open[key] = created
+	return created.HTMLURL, true, nil
+}
+
+// listOpen lists the open issues labeled vigil, page by page.
+func (g *GitHub) listOpen(ctx context.Context) (map[string]githubIssue, error) {
+	open := map[string]githubIssue{}
+	for page := 1; ; page++ {
+		var issues []githubIssue
+		url := fmt.Sprintf("%s/repos/%s/issues?state=open&labels=%s&per_page=100&page=%d", g.URL, g.Repo, githubLabel, page)
+		if err := doJSON(ctx, g.Client, http.MethodGet, url, g.header(), nil, &issues); err != nil {
+			return nil, err
+		}
+		for _, i := range issues {
+			// The issues API lists pull requests too.
+			if i.PullRequest != nil {
+				continue
+			}
+			if m := githubMarker.FindStringSubmatch(i.Body); m != nil {
+				open[m[1]] = i
+			}
+		}
+		if len(issues) < 100 {
+			return open, nil
+		}
+	}
+}
+
+func (g *GitHub) header() http.Header {
+	return http.Header{
+		"Authorization":        {"Bearer " + g.Token},
+		"Accept":               {"application/vnd.github+json"},
+		"X-Github-Api-Version": {"2022-11-28"},
+	}
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GoogleChat posts cards to a Google Chat space through an incoming webhook.
+type GoogleChat struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewGoogleChat returns a Google Chat notifier posting to the webhook at url.
+func NewGoogleChat(url string) *GoogleChat {
+	return &GoogleChat{URL: url, Client: &http.Client{Timeout: time.Minute}}
+}
+
+// Notify posts the message as a card: the title and summary in the header, a section of facts and one of links.
+func (g *GoogleChat) Notify(ctx context.Context, msg Message) error {
+	var sections []map[string]interface{}
+	if len(msg.Facts) > 0 {
+		widgets := make([]map[string]interface{}, 0, len(msg.Facts))
+		for _, f := range msg.Facts {
+			widgets = append(widgets, map[string]interface{}{"decoratedText": map[string]string{"topLabel": f[0], "text": html.EscapeString(f[1])}})
+		}
+		sections = append(sections, map[string]interface{}{"widgets": widgets})
+	}
+	if len(msg.List) > 0 {
+		lines := make([]string, 0, len(msg.List))
+		for i, l := range msg.List {
+			text := html.EscapeString(l.Text)
+			if l.URL != "" {
+				text = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(l.URL), text)
+			}
+			lines = append(lines, fmt.Sprintf("%d. %s", i+1, text))
+		}
+		sections = append(sections, map[string]interface{}{
+			"header":  html.EscapeString(msg.ListTitle),
+			"widgets": []map[string]interface{}{{"textParagraph": map[string]string{"text": strings.Join(lines, "<br>")}}},
+		})
+	}
+	if msg.Footer != "" {
+		sections = append(sections, map[string]interface{}{
+			"widgets": []map[string]interface{}{{"textParagraph": map[string]string{"text": "<i>" + html.EscapeString(msg.Footer) + "</i>"}}},
+		})
+	}
+
+	card := map[string]interface{}{
+		"cardsV2": []map[string]interface{}{{
+			"cardId": source,
+			"card": map[string]interface{}{
+				"header":   map[string]string{"title": msg.Title, "subtitle": msg.Summary},
+				"sections": sections,
+			},
+		}},
+	}
+	if err := postCard(ctx, g.Client, g.URL, card); err != nil {
+		return fmt.Errorf("failed to post to Google Chat: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Issue is the tracking issue of a flagged SLO.
+type Issue struct {
+	SLO *model.SLOData
+	// Title is the title of a new issue, e.g. "slo-too-lax: checkout availability".
+	Title string
+	// Summary explains why the SLO is flagged and what to do about it.
+	Summary string
+	// Location is where the SLO is defined, file:line, empty when it was not found.
+	Location string
+	// RunAt is when the run that flagged the SLO started.
+	RunAt time.Time
+}
+
+// Tracker opens a tracking issue per flagged SLO. Issues are found again by the SLO, so repeated runs comment on the
+// issue that is still open instead of opening another one.
+type Tracker interface {
+	// Track opens an issue for the SLO, or comments on its open issue, and returns the URL of the issue and whether
+	// it was opened.
+	Track(ctx context.Context, issue Issue) (url string, created bool, err error)
+}
+
+// markup formats the text of issues and comments for a tracker.
+type markup struct {
+	bold func(s string) string
+	code func(s string) string
+	link func(text, url string) string
+	// header and row are the lines of a table.
+	header func(cells ...string) string
+	row    func(cells ...string) string
+}
+
+// markdown is the markup of GitHub.
+var markdown = markup{
+	bold: func(s string) string { return "**" + s + "**" },
+	code: func(s string) string { return "`" + s + "`" },
+	link: func(text, url string) string { return "[" + text + "](" + url + ")" },
+	header: func(cells ...string) string {
+		return "| " + strings.Join(escapeCells(cells), " | ") + " |\n" + strings.Repeat("|---", len(cells)) + "|"
+	},
+	row: func(cells ...string) string { return "| " + strings.Join(escapeCells(cells), " | ") + " |" },
+}
+
+// jiraWiki is the wiki markup of Jira.
+var jiraWiki = markup{
+	bold:   func(s string) string { return "*" + s + "*" },
+	code:   func(s string) string { return "{{" + s + "}}" },
+	link:   func(text, url string) string { return "[" + text + "|" + url + "]" },
+	header: func(cells ...string) string { return "||" + strings.Join(escapeCells(cells), "||") + "||" },
+	row:    func(cells ...string) string { return "|" + strings.Join(escapeCells(cells), "|") + "|" },
+}
+
+func escapeCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = strings.ReplaceAll(c, "|", `\|`)
+	}
+	return escaped
+}
+
+// body is the description of a new issue: the summary, the recommendation, the stats and where to find the SLO.
+func (i Issue) body(m markup) string {
+	var b strings.Builder
+	b.WriteString(i.Summary + "\n\n")
+	i.writeDetails(&b, m)
+	return b.String()
+}
+
+// comment is the comment added to the open issue of an SLO flagged again.
+func (i Issue) comment(m markup) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n\n", m.bold(fmt.Sprintf("Still flagged by the run of %s.", i.RunAt.Format(time.DateOnly))), i.Summary)
+	i.writeDetails(&b, m)
+	return b.String()
+}
+
+func (i Issue) writeDetails(b *strings.Builder, m markup) {
+	v := i.SLO
+	if v.TargetSLO > 0 {
+		fmt.Fprintf(b, "%s change the goal from %.2f%% to %.2f%%.\n\n", m.bold("Recommendation:"), v.SLO*100, v.TargetSLO*100)
+	}
+
+	rows := [][2]string{
+		{"Category", string(v.Category)},
+		{"Goal", fmt.Sprintf("%.2f%%", v.SLO*100)},
+		{"Window", v.Window},
+		{"Min budget", fmt.Sprintf("%.2f%%", v.MinBudget*100)},
+		{"Avg budget", fmt.Sprintf("%.2f%%", v.AvgBudget*100)},
+		{"Negative fraction", fmt.Sprintf("%.2f%%", v.NegativeFraction*100)},
+		{"Peak burn rate", fmt.Sprintf("%.1fx", v.PeakBurnRate)},
+		{"Health score", fmt.Sprintf("%.0f", v.HealthScore)},
+		{"Confidence", fmt.Sprintf("%.0f%%", v.Confidence*100)},
+	}
+	if !v.ExhaustionDate.IsZero() {
+		rows = append(rows, [2]string{"Exhaustion date", v.ExhaustionDate.Format(time.DateOnly)})
+	}
+	for _, r := range [][2]string{{"Provider", string(v.Provider)}, {"Project", v.Project}, {"Service", v.Service}, {"Team", v.Team}} {
+		if r[1] != "" {
+			rows = append(rows, r)
+		}
+	}
+	b.WriteString(m.header("Stat", "Value") + "\n")
+	for _, r := range rows {
+		b.WriteString(m.row(r[0], r[1]) + "\n")
+	}
+	b.WriteString("\n")
+
+	if i.Location != "" {
+		fmt.Fprintf(b, "Defined in %s.\n", m.code(i.Location))
+	}
+	if v.ConsoleURL != "" {
+		fmt.Fprintf(b, "%s\n", m.link("Open the SLO in the console", v.ConsoleURL))
+	}
+	fmt.Fprintf(b, "\nSLO key: %s\n", m.code(v.Key))
+}
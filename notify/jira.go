@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Jira opens issues in a Jira project through the REST API v2, whose descriptions and comments are wiki markup.
+// Cloud authenticates with the email and API token of an account, Data Center with a personal access token.
+type Jira struct {
+	URL       string
+	Project   string
+	IssueType string
+	Email     string
+	Token     string
+	Client    *http.Client
+
+	// legacySearch is set once /search/jql is not found, on Data Center.
+	legacySearch bool
+}
+
+// NewJira returns a Jira tracker opening issues of issueType in project, on the site at url. An empty email
+// authenticates with token as a personal access token.
+func NewJira(url, project, issueType, email, token string) *Jira {
+	return &Jira{
+		URL:       strings.TrimSuffix(url, "/"),
+		Project:   project,
+		IssueType: issueType,
+		Email:     email,
+		Token:     token,
+		Client:    &http.Client{Timeout: time.Minute},
+	}
+}
+
+type jiraSearchResult struct {
+	Issues []struct {
+		Key string `json:"key"`
+	} `json:"issues"`
+}
+
+// Track opens an issue for the SLO, labeled vigil and with its dedup key, or comments on its unresolved issue.
+func (j *Jira) Track(ctx context.Context, issue Issue) (string, bool, error) {
+	key := dedupKey(issue.SLO)
+	existing, err := j.find(ctx, key)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to search %s for the issue of %s: %w", j.Project, issue.SLO.DisplayName, err)
+	}
+	if existing != "" {
+		url := fmt.Sprintf("%s/rest/api/2/issue/%s/comment", j.URL, existing)
+		if err := doJSON(ctx, j.Client, http.MethodPost, url, j.header(), map[string]string{"body": issue.comment(jiraWiki)}, nil); err != nil {
+			return "", false, fmt.Errorf("failed to comment on %s: %w", existing, err)
+		}
+		return j.browseURL(existing), false, nil
+	}
+
+	req := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.Project},
+			"issuetype":   map[string]string{"name": j.IssueType},
+			"summary":     issue.Title,
+			"description": issue.body(jiraWiki),
+			"labels":      []string{source, key},
+		},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := doJSON(ctx, j.Client, http.MethodPost, j.URL+"/rest/api/2/issue", j.header(), req, &created); err != nil {
+		return "", false, fmt.Errorf("failed to open an issue in %s for %s: %w", j.Project, issue.SLO.DisplayName, err)
+	}
+	return j.browseURL(created.Key), true, nil
+}
+
+// find returns the key of the unresolved issue labeled with key, empty when there is none. Cloud searches with
+// /search/jql, Data Center, which does not have it, with /search.
+func (j *Jira) find(ctx context.Context, key string) (string, error) {
+	query := url.Values{
+		"jql":        {fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done ORDER BY created DESC`, j.Project, key)},
+		"fields":     {"key"},
+		"maxResults": {"1"},
+	}
+	var result jiraSearchResult
+	var err error
+	if !j.legacySearch {
+		err = doJSON(ctx, j.Client, http.MethodGet, j.URL+"/rest/api/2/search/jql?"+query.Encode(), j.header(), nil, &result)
+		var status *statusError
+		j.legacySearch = errors.As(err, &status) && status.status == http.StatusNotFound
+	}
+	if j.legacySearch {
+		err = doJSON(ctx, j.Client, http.MethodGet, j.URL+"/rest/api/2/search?"+query.Encode(), j.header(), nil, &result)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(result.Issues) == 0 {
+		return "", nil
+	}
+	return result.Issues[0].Key, nil
+}
+
+func (j *Jira) browseURL(key string) string {
+	return j.URL + "/browse/" + key
+}
+
+func (j *Jira) header() http.Header {
+	auth := "Bearer " + j.Token
+	if j.Email != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(j.Email+":"+j.Token))
+	}
+	return http.Header{"Authorization": {auth}, "Accept": {"application/json"}}
+}
@@ -0,0 +1,118 @@
+// Package notify sends the findings of a run to incident management services and issue trackers.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// source names vigil in the incidents and alerts it creates.
+const source = "vigil"
+
+// Page is a critical finding about an SLO, paged to the on-call of the team owning it.
+type Page struct {
+	SLO *model.SLOData
+	// Reasons say why the SLO is critical, e.g. "error budget negative for 62% of the window".
+	Reasons []string
+}
+
+// Pager creates an incident or alert for a critical SLO. Pages are deduplicated by the SLO, so the pages of
+// repeated runs land on the incident or alert that is still open instead of opening another one.
+type Pager interface {
+	Page(ctx context.Context, p Page) error
+}
+
+// summary is the one line description of a page, e.g. "SLO checkout availability is critical: error budget ...".
+func (p Page) summary() string {
+	return fmt.Sprintf("SLO %s is critical: %s", p.SLO.DisplayName, strings.Join(p.Reasons, ", "))
+}
+
+// details are the stats of the SLO attached to a page.
+func (p Page) details() map[string]string {
+	v := p.SLO
+	details := map[string]string{
+		"slo":               v.DisplayName,
+		"key":               v.Key,
+		"provider":          string(v.Provider),
+		"category":          string(v.Category),
+		"goal":              fmt.Sprintf("%.2f%%", v.SLO*100),
+		"window":            v.Window,
+		"min_budget":        fmt.Sprintf("%.2f%%", v.MinBudget*100),
+		"avg_budget":        fmt.Sprintf("%.2f%%", v.AvgBudget*100),
+		"negative_fraction": fmt.Sprintf("%.2f%%", v.NegativeFraction*100),
+		"peak_burn_rate":    fmt.Sprintf("%.1fx", v.PeakBurnRate),
+	}
+	for k, s := range map[string]string{"project": v.Project, "team": v.Team, "console_url": v.ConsoleURL} {
+		if s != "" {
+			details[k] = s
+		}
+	}
+	if !v.ExhaustionDate.IsZero() {
+		details["exhaustion_date"] = v.ExhaustionDate.Format(time.DateOnly)
+	}
+	return details
+}
+
+// dedupKey identifies an SLO across runs, in the pages and issues created for it. The key of the SLO is hashed, since
+// provider keys can be longer than the services accept, and the result is a valid Jira label.
+func dedupKey(v *model.SLOData) string {
+	sum := sha256.Sum256([]byte(string(v.Provider) + "/" + v.Key))
+	return source + "-" + hex.EncodeToString(sum[:16])
+}
+
+// doJSON sends body, when not nil, as JSON to url and decodes the response into out, when not nil. It fails on any
+// status but 2xx.
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{status: resp.StatusCode, msg: fmt.Sprintf("%s: %s", resp.Status, bytes.TrimSpace(msg))}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// statusError is the error of a response with a status other than 2xx.
+type statusError struct {
+	status int
+	msg    string
+}
+
+func (e *statusError) Error() string {
+	return e.msg
+}
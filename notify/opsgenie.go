@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpsgenieURL is the Opsgenie API of the US region. Accounts in the EU region use https://api.eu.opsgenie.com.
+const OpsgenieURL = "https://api.opsgenie.com"
+
+// Opsgenie creates alerts with the key of an API integration.
+type Opsgenie struct {
+	APIKey string
+	URL    string
+	Client *http.Client
+}
+
+// NewOpsgenie returns an Opsgenie pager for the API integration of apiKey, on the API at url.
+func NewOpsgenie(apiKey, url string) *Opsgenie {
+	return &Opsgenie{APIKey: apiKey, URL: strings.TrimSuffix(url, "/"), Client: &http.Client{Timeout: time.Minute}}
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Tags        []string          `json:"tags"`
+	Details     map[string]string `json:"details"`
+	Entity      string            `json:"entity"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+}
+
+// Page creates a P1 alert for the SLO, tagged with vigil and team:<team> for the owning team. An alert with the same
+// alias that is still open is deduplicated by Opsgenie.
+func (o *Opsgenie) Page(ctx context.Context, page Page) error {
+	tags := []string{source}
+	if page.SLO.Team != "" {
+		tags = append(tags, "team:"+page.SLO.Team)
+	}
+	alert := opsgenieAlert{
+		// Opsgenie rejects messages longer than 130 characters.
+		Message:     truncate(page.summary(), 130),
+		Alias:       dedupKey(page.SLO),
+		Description: page.summary(),
+		Tags:        tags,
+		Details:     page.details(),
+		Entity:      page.SLO.DisplayName,
+		Source:      source,
+		Priority:    "P1",
+	}
+	header := http.Header{"Authorization": {"GenieKey " + o.APIKey}}
+	if err := doJSON(ctx, o.Client, http.MethodPost, o.URL+"/v2/alerts", header, alert, nil); err != nil {
+		return fmt.Errorf("failed to create an Opsgenie alert for %s: %w", page.SLO.DisplayName, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PagerDutyEventsURL is the endpoint of the PagerDuty Events API v2.
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty triggers incidents through the Events API v2 with the routing key of an integration.
+type PagerDuty struct {
+	RoutingKey string
+	URL        string
+	Client     *http.Client
+}
+
+// NewPagerDuty returns a PagerDuty pager for the integration of routingKey.
+func NewPagerDuty(routingKey string) *PagerDuty {
+	return &PagerDuty{RoutingKey: routingKey, URL: PagerDutyEventsURL, Client: &http.Client{Timeout: time.Minute}}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     pagerDutyPayload `json:"payload"`
+	Links       []pagerDutyLink  `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	Class         string            `json:"class"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// Page triggers a critical incident for the SLO. The owning team is the group of the event, and the service of the
+// SLO its component.
+func (p *PagerDuty) Page(ctx context.Context, page Page) error {
+	event := pagerDutyEvent{
+		RoutingKey:  p.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    dedupKey(page.SLO),
+		Payload: pagerDutyPayload{
+			// PagerDuty truncates longer summaries.
+			Summary:       truncate(page.summary(), 1024),
+			Source:        source,
+			Severity:      "critical",
+			Component:     page.SLO.Service,
+			Group:         page.SLO.Team,
+			Class:         "slo",
+			CustomDetails: page.details(),
+		},
+	}
+	if page.SLO.ConsoleURL != "" {
+		event.Links = []pagerDutyLink{{Href: page.SLO.ConsoleURL, Text: page.SLO.DisplayName}}
+	}
+	if err := doJSON(ctx, p.Client, http.MethodPost, p.URL, nil, event, nil); err != nil {
+		return fmt.Errorf("failed to trigger a PagerDuty incident for %s: %w", page.SLO.DisplayName, err)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Teams posts Adaptive Cards to a Microsoft Teams channel through the webhook of a Workflows flow, "Post to a channel
+// when a webhook request is received".
+type Teams struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewTeams returns a Teams notifier posting to the webhook at url.
+func NewTeams(url string) *Teams {
+	return &Teams{URL: url, Client: &http.Client{Timeout: time.Minute}}
+}
+
+// Notify posts the message as an Adaptive Card: the title and summary, a fact set and a list of links.
+func (t *Teams) Notify(ctx context.Context, msg Message) error {
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": msg.Title, "size": "Large", "weight": "Bolder", "wrap": true},
+		{"type": "TextBlock", "text": msg.Summary, "wrap": true},
+	}
+	if len(msg.Facts) > 0 {
+		facts := make([]map[string]string, 0, len(msg.Facts))
+		for _, f := range msg.Facts {
+			facts = append(facts, map[string]string{"title": f[0], "value": f[1]})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+	if len(msg.List) > 0 {
+		lines := make([]string, 0, len(msg.List))
+		for i, l := range msg.List {
+			text := teamsEscaper.Replace(l.Text)
+			if l.URL != "" {
+				text = fmt.Sprintf("[%s](%s)", text, l.URL)
+			}
+			lines = append(lines, fmt.Sprintf("%d. %s", i+1, text))
+		}
+		body = append(body,
+			map[string]interface{}{"type": "TextBlock", "text": msg.ListTitle, "weight": "Bolder", "wrap": true, "spacing": "Medium"},
+			map[string]interface{}{"type": "TextBlock", "text": strings.Join(lines, "\r"), "wrap": true})
+	}
+	if msg.Footer != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": msg.Footer, "size": "Small", "isSubtle": true, "wrap": true})
+	}
+
+	card := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+	if err := postCard(ctx, t.Client, t.URL, card); err != nil {
+		return fmt.Errorf("failed to post to Microsoft Teams: %w", err)
+	}
+	return nil
+}
+
+// teamsEscaper escapes the markdown of Adaptive Card text blocks in names.
+var teamsEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`)
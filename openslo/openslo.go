@@ -0,0 +1,264 @@
+// Package openslo provides a client that evaluates OpenSLO YAML specs against a metrics backend, implementing the Vigil interface.
+package openslo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// resolution is the number of steps each window is split into when querying the backend.
+const resolution = 1000
+
+// Backend evaluates metric source queries over a time range.
+type Backend interface {
+	GetProvider() model.CloudProvider
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (map[int64]float64, error)
+	Close() error
+}
+
+// Client evaluates OpenSLO specs read from a directory.
+type Client struct {
+	backend              Backend
+	slos                 map[string]document
+	slis                 map[string]sliSpec
+	ErrorBudgetThreshold float64
+	Window               time.Duration
+}
+
+// Objective is a single objective of an OpenSLO SLO together with its resolved indicator.
+type Objective struct {
+	SLO       string
+	Service   string
+	Indicator sliSpec
+}
+
+// NewClient loads every OpenSLO spec under dir. Metric sources are evaluated with backend.
+func NewClient(_ context.Context, dir string, backend Backend, errorBudgetThreshold float64, window time.Duration) (*Client, error) {
+	slos, slis, err := loadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenSLO specs: %w", err)
+	}
+
+	return &Client{
+		backend:              backend,
+		slos:                 slos,
+		slis:                 slis,
+		ErrorBudgetThreshold: errorBudgetThreshold,
+		Window:               window,
+	}, nil
+}
+
+// GetProvider returns the OpenSLO provider identifier.
+func (c *Client) GetProvider() model.CloudProvider {
+	return model.CloudProviderOpenSLO
+}
+
+// Close closes the metrics backend.
+func (c *Client) Close() error {
+	return c.backend.Close()
+}
+
+// TimeSeriesCalls returns the number of backend queries GetErrorBudgetTimeSeries makes: the numerator and the total.
+func (c *Client) TimeSeriesCalls(_ *model.SLO) int {
+	return 2
+}
+
+// GetSLOs returns one SLO per objective found in the spec directory.
+func (c *Client) GetSLOs(_ context.Context) ([]*model.SLO, error) {
+	names := make([]string, 0, len(c.slos))
+	for name := range c.slos {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var slos []*model.SLO
+	for _, name := range names {
+		doc := c.slos[name]
+
+		var spec sloSpec
+		if err := doc.Spec.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("failed to decode SLO %s: %w", name, err)
+		}
+
+		indicator, err := c.indicator(spec)
+		if err != nil {
+			return nil, fmt.Errorf("SLO %s: %w", name, err)
+		}
+
+		displayName := doc.Metadata.DisplayName
+		if displayName == "" {
+			displayName = name
+		}
+
+		for i, o := range spec.Objectives {
+			goal, err := o.goal()
+			if err != nil {
+				return nil, fmt.Errorf("SLO %s: %w", name, err)
+			}
+
+			objectiveName := displayName
+			if len(spec.Objectives) > 1 {
+				objectiveName = fmt.Sprintf("%s (%s)", displayName, objectiveLabel(o, i))
+			}
+
+			slos = append(slos, &model.SLO{
+				Name:        fmt.Sprintf("%s/%d", name, i),
+				DisplayName: objectiveName,
+				Goal:        goal,
+				Service:     spec.Service,
+				SLI: Objective{
+					SLO:       name,
+					Service:   spec.Service,
+					Indicator: indicator,
+				},
+			})
+		}
+	}
+
+	return slos, nil
+}
+
+// GetErrorBudgetTimeSeries evaluates the ratio metric of an SLO over the window and returns the
+// remaining error budget fraction, accumulated from the start of the window, at each step.
+func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, error) {
+	good, total, points, _, err := c.GetWeightedErrorBudgetTimeSeries(ctx, slo)
+	return good, total, points, err
+}
+
+// GetWeightedErrorBudgetTimeSeries is GetErrorBudgetTimeSeries that also returns the total events of each step.
+func (c *Client) GetWeightedErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, []float64, error) {
+	objective, ok := slo.SLI.(Objective)
+	if !ok {
+		return "", "", nil, nil, fmt.Errorf("SLI is not of expected type: %T", slo.SLI)
+	}
+	if slo.Goal >= 1 {
+		return "", "", nil, nil, fmt.Errorf("SLO %s has a 100%% target and no error budget", slo.DisplayName)
+	}
+
+	ratio := objective.Indicator.RatioMetric
+	if ratio == nil {
+		return "", "", nil, nil, fmt.Errorf("SLO %s: only ratioMetric indicators are supported", slo.DisplayName)
+	}
+
+	isBad := ratio.Good == nil
+	numerator := ratio.Good
+	if isBad {
+		numerator = ratio.Bad
+	}
+	if numerator == nil || ratio.Total == nil {
+		return "", "", nil, nil, fmt.Errorf("SLO %s: ratioMetric requires good or bad, and total", slo.DisplayName)
+	}
+
+	numeratorQuery, err := c.query(numerator)
+	if err != nil {
+		return "", "", nil, nil, fmt.Errorf("SLO %s: %w", slo.DisplayName, err)
+	}
+	totalQuery, err := c.query(ratio.Total)
+	if err != nil {
+		return "", "", nil, nil, fmt.Errorf("SLO %s: %w", slo.DisplayName, err)
+	}
+
+	window := cmp.Or(slo.Window, c.Window)
+	end := time.Now().UTC()
+	start := end.Add(window * -1)
+	step := window / resolution
+
+	numeratorSamples, err := c.backend.QueryRange(ctx, aggregate(numeratorQuery, ratio.Counter, step), start, end, step)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+	totalSamples, err := c.backend.QueryRange(ctx, aggregate(totalQuery, ratio.Counter, step), start, end, step)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+
+	timestamps := make([]int64, 0, len(totalSamples))
+	for ts := range totalSamples {
+		timestamps = append(timestamps, ts)
+	}
+	slices.Sort(timestamps)
+
+	var (
+		points        []model.Point
+		weights       []float64
+		numeratorSum  float64
+		totalEventSum float64
+	)
+	for _, ts := range timestamps {
+		numeratorSum += numeratorSamples[ts]
+		totalEventSum += totalSamples[ts]
+		if totalEventSum == 0 {
+			continue
+		}
+
+		goodRatio := numeratorSum / totalEventSum
+		if isBad {
+			goodRatio = 1 - goodRatio
+		}
+		points = append(points, model.Point{Time: time.Unix(ts, 0).UTC(), Value: 1 - (1-goodRatio)/(1-slo.Goal)})
+		weights = append(weights, totalSamples[ts])
+	}
+
+	good := numeratorQuery
+	if isBad {
+		good = "bad: " + numeratorQuery
+	}
+	if len(points) == 0 {
+		return good, totalQuery, nil, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
+	}
+
+	return good, totalQuery, points, weights, nil
+}
+
+// indicator resolves the inline indicator or indicatorRef of an SLO.
+func (c *Client) indicator(spec sloSpec) (sliSpec, error) {
+	if spec.Indicator != nil {
+		return spec.Indicator.Spec, nil
+	}
+	if spec.IndicatorRef == "" {
+		return sliSpec{}, errors.New("indicator or indicatorRef is required")
+	}
+
+	sli, ok := c.slis[spec.IndicatorRef]
+	if !ok {
+		return sliSpec{}, fmt.Errorf("SLI %s not found", spec.IndicatorRef)
+	}
+
+	return sli, nil
+}
+
+// query returns the backend query of a metric source, rejecting sources the backend cannot evaluate.
+func (c *Client) query(m *metric) (string, error) {
+	if !strings.EqualFold(m.MetricSource.Type, string(c.backend.GetProvider())) {
+		return "", fmt.Errorf("metric source type %q is not supported by the %s backend", m.MetricSource.Type, c.backend.GetProvider())
+	}
+
+	q := m.MetricSource.Spec["query"]
+	if q == "" {
+		return "", errors.New("metric source has no query")
+	}
+
+	return q, nil
+}
+
+// aggregate wraps a query so each step yields the number of events observed during that step.
+func aggregate(query string, counter bool, step time.Duration) string {
+	if counter {
+		return fmt.Sprintf("sum(increase((%s)[%ds:]))", query, int64(step.Seconds()))
+	}
+	return fmt.Sprintf("sum(%s)", query)
+}
+
+func objectiveLabel(o objective, i int) string {
+	if o.DisplayName != "" {
+		return o.DisplayName
+	}
+	return fmt.Sprintf("objective %d", i+1)
+}
@@ -0,0 +1,54 @@
+package openslo
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+func init() {
+	provider.Register(model.CloudProviderOpenSLO, &factory{})
+}
+
+type factory struct {
+	path    string
+	backend string
+}
+
+func (f *factory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.path, "path", "", "directory of OpenSLO YAML specs")
+	fs.StringVar(&f.backend, "openslo-backend", string(model.CloudProviderPrometheus), "provider evaluating OpenSLO metric sources. prometheus")
+}
+
+func (f *factory) Validate() error {
+	if f.path == "" {
+		return errors.New("--path is required for OpenSLO")
+	}
+	if model.CloudProvider(f.backend) == model.CloudProviderOpenSLO {
+		return errors.New("--openslo-backend cannot be openslo")
+	}
+	return provider.Validate(model.CloudProvider(f.backend))
+}
+
+// New creates the backend through the provider registry; any provider that can evaluate range queries qualifies.
+func (f *factory) New(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+	p, err := provider.New(ctx, model.CloudProvider(f.backend), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, ok := p.(Backend)
+	if !ok {
+		return nil, errors.Join(fmt.Errorf("provider %s cannot be used as an OpenSLO backend", f.backend), p.Close())
+	}
+
+	return NewClient(ctx, f.path, backend, opts.ErrorBudgetThreshold, opts.Window)
+}
+
+func (f *factory) Target() string {
+	return fmt.Sprintf("OpenSLO (%s)", f.path)
+}
@@ -0,0 +1,162 @@
+package openslo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported OpenSLO kinds.
+const (
+	kindSLO = "SLO"
+	kindSLI = "SLI"
+)
+
+type document struct {
+	APIVersion string    `yaml:"apiVersion"`
+	Kind       string    `yaml:"kind"`
+	Metadata   metadata  `yaml:"metadata"`
+	Spec       yaml.Node `yaml:"spec"`
+}
+
+type metadata struct {
+	Name        string `yaml:"name"`
+	DisplayName string `yaml:"displayName"`
+}
+
+type sloSpec struct {
+	Description  string       `yaml:"description"`
+	Service      string       `yaml:"service"`
+	IndicatorRef string       `yaml:"indicatorRef"`
+	Indicator    *inlineSLI   `yaml:"indicator"`
+	Objectives   []objective  `yaml:"objectives"`
+	TimeWindow   []timeWindow `yaml:"timeWindow"`
+}
+
+type inlineSLI struct {
+	Metadata metadata `yaml:"metadata"`
+	Spec     sliSpec  `yaml:"spec"`
+}
+
+type objective struct {
+	DisplayName   string   `yaml:"displayName"`
+	Target        *float64 `yaml:"target"`
+	TargetPercent *float64 `yaml:"targetPercent"`
+}
+
+type timeWindow struct {
+	Duration  string `yaml:"duration"`
+	IsRolling bool   `yaml:"isRolling"`
+}
+
+type sliSpec struct {
+	RatioMetric     *ratioMetric `yaml:"ratioMetric"`
+	ThresholdMetric *metric      `yaml:"thresholdMetric"`
+}
+
+type ratioMetric struct {
+	Counter bool    `yaml:"counter"`
+	Good    *metric `yaml:"good"`
+	Bad     *metric `yaml:"bad"`
+	Total   *metric `yaml:"total"`
+}
+
+type metric struct {
+	MetricSource metricSource `yaml:"metricSource"`
+}
+
+type metricSource struct {
+	Type string            `yaml:"type"`
+	Spec map[string]string `yaml:"spec"`
+}
+
+// goal returns the objective target as a 0-1 ratio.
+func (o objective) goal() (float64, error) {
+	switch {
+	case o.Target != nil:
+		return *o.Target, nil
+	case o.TargetPercent != nil:
+		return *o.TargetPercent / 100, nil
+	default:
+		return 0, errors.New("objective has neither target nor targetPercent")
+	}
+}
+
+// loadDir parses every YAML file under dir and returns the SLO and SLI documents keyed by name.
+func loadDir(dir string) (map[string]document, map[string]sliSpec, error) {
+	slos := make(map[string]document)
+	slis := make(map[string]sliSpec)
+
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		docs, err := loadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+
+		for _, doc := range docs {
+			switch doc.Kind {
+			case kindSLO:
+				slos[doc.Metadata.Name] = doc
+			case kindSLI:
+				var spec sliSpec
+				if err := doc.Spec.Decode(&spec); err != nil {
+					return fmt.Errorf("failed to decode SLI %s in %s: %w", doc.Metadata.Name, path, err)
+				}
+				slis[doc.Metadata.Name] = spec
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return slos, slis, nil
+}
+
+func loadFile(path string) ([]document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			log.Printf("Failed to close %s: %v", path, closeErr)
+		}
+	}()
+
+	var docs []document
+	dec := yaml.NewDecoder(f)
+	for {
+		var doc document
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(doc.APIVersion, "openslo/") {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
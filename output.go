@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// defaultOutput reproduces the historical report file names, e.g. slo_report.xlsx.
+const defaultOutput = "slo_report.{{.Format}}"
+
+// outputFields are the values available to the --output template.
+type outputFields struct {
+	// Project is the distinct projects of the scanned SLOs joined with "-", empty when the provider has none.
+	Project string
+	// Provider is the scanned providers and plugins joined with "-".
+	Provider string
+	// Date is the local date of the run as YYYY-MM-DD.
+	Date string
+	// Time is the local time of the run as HHMMSS.
+	Time string
+	// Format is the report format, which doubles as the file extension.
+	Format string
+}
+
+// outputPath renders the --output template for the SLOs about to be reported.
+// Unless --force is given it refuses to overwrite an existing file, so the check happens before any SLO is processed.
+func outputPath(slos []*model.SLO, now time.Time) (string, error) {
+	path, err := renderOutput(slos, now)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if !*force {
+			return "", fmt.Errorf("%s already exists. pass --force to overwrite it", path)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	return path, nil
+}
+
+// renderOutput renders the --output template without touching the file system.
+func renderOutput(slos []*model.SLO, now time.Time) (string, error) {
+	tmpl, err := template.New("output").Parse(*output)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse --output template: %w", err)
+	}
+
+	var projects []string
+	for _, slo := range slos {
+		if slo.Project != "" {
+			projects = append(projects, sanitizeFilename(slo.Project))
+		}
+	}
+	slices.Sort(projects)
+
+	var providers []string
+	for _, p := range cloudProviders() {
+		providers = append(providers, string(p))
+	}
+	for _, p := range pluginPaths() {
+		providers = append(providers, sanitizeFilename(filepath.Base(p)))
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, outputFields{
+		Project:  strings.Join(slices.Compact(projects), "-"),
+		Provider: strings.Join(providers, "-"),
+		Date:     now.Format(time.DateOnly),
+		Time:     now.Format("150405"),
+		Format:   *format,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render --output template: %w", err)
+	}
+	if buf.Len() == 0 {
+		return "", errors.New("--output rendered an empty path")
+	}
+
+	return buf.String(), nil
+}
+
+// sanitizeFilename keeps template values from introducing directories or characters that are invalid on Windows.
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, s)
+}
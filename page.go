@@ -0,0 +1,94 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/notify"
+)
+
+// Services --page can page.
+const (
+	pagePagerDuty = "pagerduty"
+	pageOpsgenie  = "opsgenie"
+)
+
+// newPager returns the pager of --page, with the key of its integration from the environment.
+func newPager(service string) (notify.Pager, error) {
+	switch service {
+	case pagePagerDuty:
+		key := os.Getenv("PAGERDUTY_ROUTING_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("--page %s needs PAGERDUTY_ROUTING_KEY, the integration key of an Events API v2 integration", service)
+		}
+		return notify.NewPagerDuty(key), nil
+	case pageOpsgenie:
+		key := os.Getenv("OPSGENIE_API_KEY")
+		if key == "" {
+			return nil, fmt.Errorf("--page %s needs OPSGENIE_API_KEY, the key of an API integration", service)
+		}
+		return notify.NewOpsgenie(key, cmp.Or(os.Getenv("OPSGENIE_API_URL"), notify.OpsgenieURL)), nil
+	default:
+		return nil, fmt.Errorf("--page must be '%s' or '%s'", pagePagerDuty, pageOpsgenie)
+	}
+}
+
+// pageReasons returns why v is critical enough to page: its budget was negative for more than
+// --page-negative-fraction of the window, or is projected to be exhausted within --page-exhaustion-days. Nil when
+// it is not critical.
+func pageReasons(v *model.SLOData, now time.Time) []string {
+	if v.Category == model.CategoryNoData {
+		return nil
+	}
+	var reasons []string
+	if *pageNegativeFraction > 0 && v.NegativeFraction > *pageNegativeFraction {
+		reasons = append(reasons, fmt.Sprintf("error budget negative for %.0f%% of the %s window", v.NegativeFraction*100, v.Window))
+	}
+	if *pageExhaustionDays > 0 && !v.ExhaustionDate.IsZero() && v.ExhaustionDate.Sub(now) <= time.Duration(*pageExhaustionDays)*24*time.Hour {
+		if v.ExhaustionDate.After(now) {
+			reasons = append(reasons, fmt.Sprintf("error budget projected to be exhausted on %s", v.ExhaustionDate.Format(time.DateOnly)))
+		} else {
+			reasons = append(reasons, "error budget exhausted")
+		}
+	}
+	return reasons
+}
+
+// pageCritical pages the owning team of every critical SLO through --page. Every SLO is paged even when some pages
+// fail, and the run fails afterwards.
+func pageCritical(ctx context.Context, data map[string]*model.SLOData) {
+	pager, err := newPager(*page)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+
+	slos := make([]*model.SLOData, 0, len(data))
+	for _, v := range data {
+		slos = append(slos, v)
+	}
+	sortSLOs(slos)
+
+	now := time.Now()
+	paged, failed := 0, 0
+	for _, v := range slos {
+		reasons := pageReasons(v, now)
+		if len(reasons) == 0 {
+			continue
+		}
+		if err := pager.Page(ctx, notify.Page{SLO: v, Reasons: reasons}); err != nil {
+			log.Printf("%v", err)
+			failed++
+			continue
+		}
+		paged++
+	}
+	if failed > 0 {
+		log.Panicf("Failed to page %d of %d critical SLOs through %s", failed, paged+failed, *page)
+	}
+	infof("Paged %d critical SLOs through %s", paged, *page)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+)
+
+// Page geometry in PDF points, A4 landscape.
+const (
+	pdfPageWidth  = 842
+	pdfPageHeight = 595
+	pdfMargin     = 36
+	pdfFontSize   = 8
+	pdfRowHeight  = 14
+)
+
+type pdfColumn struct {
+	header string
+	width  float64
+	value  func(*model.SLOData) string
+}
+
+// pdfDocument is a minimal PDF 1.4 writer that only supports the standard Helvetica fonts.
+// The standard fonts cover WinAnsi, so characters outside of Latin-1 are replaced.
+type pdfDocument struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+func generatePDFReport(data map[string]*model.SLOData, msgs *i18n.Messages, output string) error {
+	var flagged []*model.SLOData
+	for _, v := range data {
+		if v.Flag {
+			flagged = append(flagged, v)
+		}
+	}
+	sortSLOs(flagged)
+
+	percent := func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) }
+	columns := []pdfColumn{
+		{msgs.HeaderName, 330, func(v *model.SLOData) string { return v.DisplayName }},
+		{msgs.HeaderProject, 130, func(v *model.SLOData) string { return v.Project }},
+		{msgs.HeaderSLO, 60, func(v *model.SLOData) string { return percent(v.SLO) }},
+		{msgs.HeaderSLIMin, 70, func(v *model.SLOData) string { return percent(v.MinBudget) }},
+		{msgs.HeaderSLIAvg, 70, func(v *model.SLOData) string { return percent(v.AvgBudget) }},
+		{msgs.HeaderNegative, 70, func(v *model.SLOData) string { return percent(v.NegativeFraction) }},
+	}
+
+	doc := &pdfDocument{}
+	doc.addPage()
+
+	doc.text(16, true, msgs.ReportTitle)
+	doc.y -= 10
+	description := reportDescription(msgs)
+	for _, line := range strings.Split(description, "\n") {
+		doc.text(10, false, line)
+	}
+	doc.text(pdfFontSize, false, msgs.GeneratedBy)
+	doc.y -= pdfRowHeight / 2
+
+	for _, r := range summarize(data, reportTime()).rows(msgs) {
+		doc.cell(pdfMargin, 160, true, r[0])
+		doc.cell(pdfMargin+160, 600, false, r[1])
+		doc.y -= pdfRowHeight
+	}
+	doc.y -= pdfRowHeight
+
+	doc.tableHeader(columns)
+	for _, v := range flagged {
+		if doc.y < pdfMargin+pdfRowHeight {
+			doc.addPage()
+			doc.tableHeader(columns)
+		}
+		x := float64(pdfMargin)
+		for _, c := range columns {
+			doc.cell(x, c.width, false, c.value(v))
+			x += c.width
+		}
+		doc.y -= pdfRowHeight
+	}
+
+	if err := os.WriteFile(output, doc.bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write PDF report: %w", err)
+	}
+	return nil
+}
+
+// generateDiffPDF writes the changes between two runs of vigil diff as a single table.
+func generateDiffPDF(from, to diffRun, changes []sloChange, msgs *i18n.Messages, output string) {
+	// Widths of the diffHeader columns.
+	widths := []float64{70, 250, 100, 90, 90, 60, 60, 50}
+	var columns []pdfColumn
+	for i, h := range diffHeader(msgs) {
+		columns = append(columns, pdfColumn{header: h, width: widths[i]})
+	}
+
+	doc := &pdfDocument{}
+	doc.addPage()
+
+	doc.text(16, true, msgs.DiffTitle)
+	doc.y -= 10
+	doc.text(10, false, diffDescription(from, to, msgs))
+	doc.text(10, false, diffSummary(changes, msgs))
+	doc.text(pdfFontSize, false, msgs.GeneratedBy)
+	doc.y -= pdfRowHeight
+
+	doc.tableHeader(columns)
+	for _, c := range changes {
+		if doc.y < pdfMargin+pdfRowHeight {
+			doc.addPage()
+			doc.tableHeader(columns)
+		}
+		x := float64(pdfMargin)
+		for i, cell := range diffCells(c, msgs) {
+			doc.cell(x, columns[i].width, c.Change == changeFlagged, cell)
+			x += columns[i].width
+		}
+		doc.y -= pdfRowHeight
+	}
+
+	if err := os.WriteFile(output, doc.bytes(), 0o644); err != nil {
+		log.Panicf("Failed to write PDF report: %v", err)
+	}
+}
+
+func (d *pdfDocument) addPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// text writes a single line at the left margin and moves the cursor down.
+func (d *pdfDocument) text(size float64, bold bool, s string) {
+	d.y -= size * 1.4
+	d.write(pdfMargin, d.y, size, bold, s)
+}
+
+// cell writes s into a table cell on the current row, truncating it to the column width.
+func (d *pdfDocument) cell(x, width float64, bold bool, s string) {
+	// Helvetica glyphs average a little over half of the font size in width.
+	maxChars := int(width / (pdfFontSize * 0.55))
+	if runes := []rune(s); len(runes) > maxChars {
+		s = string(runes[:maxChars-3]) + "..."
+	}
+	d.write(x, d.y-pdfRowHeight+4, pdfFontSize, bold, s)
+}
+
+func (d *pdfDocument) tableHeader(columns []pdfColumn) {
+	x := float64(pdfMargin)
+	for _, c := range columns {
+		d.cell(x, c.width, true, c.header)
+		x += c.width
+	}
+	d.y -= pdfRowHeight
+	fmt.Fprintf(d.current, "%.1f %.1f m %.1f %.1f l S\n", float64(pdfMargin), d.y+2, x, d.y+2)
+}
+
+func (d *pdfDocument) write(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current, "BT /%s %g Tf %.1f %.1f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+// bytes assembles the objects, cross-reference table and trailer of the document.
+func (d *pdfDocument) bytes() []byte {
+	// Objects 1-4 are the catalog, page tree and fonts; each page adds a page and a content stream object.
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	var kids []string
+	for _, page := range d.pages {
+		pageID := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pageID+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfEscape encodes s as the body of a PDF literal string in WinAnsi (Latin-1) encoding.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80:
+			b.WriteRune(r)
+		case r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+// Package analysis defines the checks that categorize an SLO from its analyzed error budget series, and the ones vigil
+// runs by default. A check is added by implementing Check and passing it in vigil.Options.Checks.
+package analysis
+
+import (
+	"github.com/rluisr/vigil/model"
+)
+
+// Check is a heuristic that categorizes an SLO.
+type Check interface {
+	// Name identifies the check in the findings and in --checks, e.g. too-lax.
+	Name() string
+	// Evaluate returns the finding of the check on v, the error budget series of slo along with its stats, burn
+	// rates, trend and forecast. It returns the zero Finding when the check finds nothing.
+	Evaluate(v *model.SLOData, slo *model.SLO) Finding
+}
+
+// Finding is what a check found about an SLO. Its Check is set by Run.
+type Finding = model.Finding
+
+// Run evaluates every check on v in order and returns the category of the first finding, HEALTHY when there is none,
+// along with every finding.
+func Run(checks []Check, v *model.SLOData, slo *model.SLO) (model.Category, []Finding) {
+	var findings []Finding
+	for _, c := range checks {
+		f := c.Evaluate(v, slo)
+		if f.Category == "" {
+			continue
+		}
+		f.Check = c.Name()
+		findings = append(findings, f)
+	}
+	if len(findings) == 0 {
+		return model.CategoryHealthy, nil
+	}
+	return findings[0].Category, findings
+}
@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Names of the built-in checks.
+const (
+	NoData         = "no-data"
+	NegativeBudget = "negative-budget"
+	BurnRate       = "burn-rate"
+	Trend          = "trend"
+	Forecast       = "forecast"
+	TooLax         = "too-lax"
+)
+
+// Names are the built-in checks in the order vigil runs them: an SLO without enough data gets no other category, and
+// spending the budget too fast takes precedence over a lax objective, since an SLO can briefly burn fast and still
+// never drop below its threshold.
+var Names = []string{NoData, NegativeBudget, BurnRate, Trend, Forecast, TooLax}
+
+// check is a built-in check.
+type check struct {
+	name     string
+	evaluate func(v *model.SLOData) Finding
+}
+
+func (c check) Name() string { return c.name }
+
+func (c check) Evaluate(v *model.SLOData, _ *model.SLO) Finding { return c.evaluate(v) }
+
+// NoDataCheck finds the SLOs with fewer than minPoints error budget points, too few for a recommendation.
+func NoDataCheck(minPoints int) Check {
+	return check{NoData, func(v *model.SLOData) Finding {
+		if len(v.Points) >= max(minPoints, 1) {
+			return Finding{}
+		}
+		return Finding{Category: model.CategoryNoData, Reason: fmt.Sprintf("%d error budget points, fewer than %d", len(v.Points), max(minPoints, 1))}
+	}}
+}
+
+// NegativeBudgetCheck finds the SLOs whose error budget was negative for at least their negative budget fraction of
+// the window.
+func NegativeBudgetCheck() Check {
+	return check{NegativeBudget, func(v *model.SLOData) Finding {
+		if !Burning(v) {
+			return Finding{}
+		}
+		return Finding{Category: model.CategoryBurning, Reason: fmt.Sprintf("the error budget was negative for %.1f%% of the window", v.NegativeFraction*100)}
+	}}
+}
+
+// BurnRateCheck finds the SLOs that burned their budget at a peak multiwindow burn rate of at least threshold.
+func BurnRateCheck(threshold float64) Check {
+	return check{BurnRate, func(v *model.SLOData) Finding {
+		if !FastBurn(v, threshold) {
+			return Finding{}
+		}
+		return Finding{Category: model.CategoryBurning, Reason: fmt.Sprintf("the peak burn rate was %.1fx, at least %gx", v.PeakBurnRate, threshold)}
+	}}
+}
+
+// TrendCheck finds the SLOs whose budget, still positive, is trending toward exhaustion within one more window.
+func TrendCheck() Check {
+	return check{Trend, func(v *model.SLOData) Finding {
+		if !TrendingToExhaustion(v) {
+			return Finding{}
+		}
+		return Finding{Category: model.CategoryBurning, Reason: fmt.Sprintf("the error budget is projected at %.2f%% one window from now", v.ProjectedBudget*100)}
+	}}
+}
+
+// ForecastCheck finds the SLOs whose budget is forecast to hit zero within the next window.
+func ForecastCheck() Check {
+	return check{Forecast, func(v *model.SLOData) Finding {
+		if !v.ExhaustsWithinWindow {
+			return Finding{}
+		}
+		return Finding{Category: model.CategoryBurning, Reason: "the error budget is forecast to be exhausted on " + v.ExhaustionDate.Format(time.DateOnly)}
+	}}
+}
+
+// TooLaxCheck finds the SLOs whose budget never dropped below their error budget threshold.
+func TooLaxCheck() Check {
+	return check{TooLax, func(v *model.SLOData) Finding {
+		if v.MinBudget < v.ErrorBudgetThreshold {
+			return Finding{}
+		}
+		return Finding{Category: model.CategoryLax, Reason: fmt.Sprintf("the error budget never dropped below %.2f%%, the threshold is %g%%", v.MinBudget*100, v.ErrorBudgetThreshold*100)}
+	}}
+}
+
+// Burning reports whether the error budget of v was negative for at least its negative budget fraction of the window.
+func Burning(v *model.SLOData) bool {
+	return v.NegativeFraction >= v.NegativeBudgetFraction
+}
+
+// FastBurn reports whether v burned its budget at a peak multiwindow burn rate of at least threshold, a multiple of
+// the rate that exactly exhausts the budget over the window. A threshold of 0 disables it.
+func FastBurn(v *model.SLOData, threshold float64) bool {
+	return threshold > 0 && v.PeakBurnRate >= threshold
+}
+
+// TrendingToExhaustion reports whether the budget of v, still positive, is degrading and projected to be exhausted
+// within one more window.
+func TrendingToExhaustion(v *model.SLOData) bool {
+	return v.Trend == model.TrendDegrading && v.ProjectedBudget <= 0 && len(v.Points) > 0 && v.Points[len(v.Points)-1] > 0
+}
@@ -0,0 +1,47 @@
+package vigil
+
+import (
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/utils"
+)
+
+// anomalyThreshold is the modified z-score above which the budget consumed in one step is anomalous.
+const anomalyThreshold = 3.5
+
+// incidentShare is the share of the consumed budget above which a single anomaly, rather than chronic behavior,
+// is considered to drive an SLO.
+const incidentShare = 0.5
+
+// setAnomalies finds the anomalous steps of the budget consumption of v for --detect-anomalies. Consecutive anomalous
+// steps are counted as a single incident. Only steps spending budget count: the recovery after an incident is as
+// anomalous but gives the budget back. When a single incident drives the spending, relaxing the objective is the
+// wrong fix.
+func setAnomalies(v *model.SLOData) {
+	if len(v.Points) < 2 {
+		return
+	}
+
+	consumption := make([]float64, len(v.Points)-1)
+	for i := range consumption {
+		consumption[i] = v.Points[i] - v.Points[i+1]
+	}
+
+	var total, anomalous float64
+	previous := false
+	for i, outlier := range utils.Outliers(consumption, anomalyThreshold) {
+		c := max(consumption[i], 0)
+		total += c
+		outlier = outlier && c > 0
+		if outlier {
+			anomalous += c
+			if !previous {
+				v.Anomalies++
+			}
+		}
+		previous = outlier
+	}
+	if total > 0 {
+		v.AnomalyShare = anomalous / total
+	}
+	v.IncidentDriven = v.Anomalies == 1 && v.AnomalyShare >= incidentShare
+}
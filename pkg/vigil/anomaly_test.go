@@ -0,0 +1,45 @@
+package vigil
+
+import (
+	"testing"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider/fake"
+)
+
+func TestSetAnomalies(t *testing.T) {
+	tests := []struct {
+		name           string
+		points         []float64
+		anomalies      int
+		incidentDriven bool
+	}{
+		{
+			name:           "single dip",
+			points:         fake.Dip(720, 600, 6, -0.5),
+			anomalies:      1,
+			incidentDriven: true,
+		},
+		{
+			name:   "chronic burn",
+			points: fake.Linear(1, -0.5, 720),
+		},
+		{
+			name:   "no consumption",
+			points: fake.Constant(1, 720),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &model.SLOData{Points: tt.points}
+			setAnomalies(v)
+			if v.Anomalies != tt.anomalies {
+				t.Errorf("Anomalies = %d, want %d", v.Anomalies, tt.anomalies)
+			}
+			if v.IncidentDriven != tt.incidentDriven {
+				t.Errorf("IncidentDriven = %t, want %t", v.IncidentDriven, tt.incidentDriven)
+			}
+		})
+	}
+}
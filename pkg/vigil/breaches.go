@@ -0,0 +1,30 @@
+package vigil
+
+import (
+	"slices"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/utils"
+)
+
+// setBreaches lists the periods the budget of a flagged v stayed below its threshold, timed with v.Timestamps. A
+// single point counts for the spacing of the points, as for LongestBreachHours.
+func setBreaches(v *model.SLOData, sloWindow time.Duration) {
+	if !v.Flag || len(v.Points) < 2 || len(v.Timestamps) != len(v.Points) {
+		return
+	}
+	step := pointStep(v, sloWindow)
+
+	for _, run := range utils.RunsBelow(v.Points, v.ErrorBudgetThreshold) {
+		b := model.Breach{
+			Start:     v.Timestamps[run[0]],
+			Hours:     float64(run[1]-run[0]) * step.Hours(),
+			MinBudget: slices.Min(v.Points[run[0]:run[1]]),
+		}
+		if run[1] < len(v.Points) {
+			b.End = v.Timestamps[run[1]]
+		}
+		v.Breaches = append(v.Breaches, b)
+	}
+}
@@ -0,0 +1,55 @@
+package vigil
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/utils"
+)
+
+// burnRateLookbacks are the lookbacks of the Google SRE multiwindow burn rate alerts. Each one is paired with the
+// next longer one to compute the peak multiwindow burn rate.
+var burnRateLookbacks = []time.Duration{time.Hour, 6 * time.Hour, 24 * time.Hour, 72 * time.Hour}
+
+// exhaustionLookback is the lookback of the burn rate the time to exhaustion is projected from.
+const exhaustionLookback = 24 * time.Hour
+
+// setBurnRates fills the burn rate fields of v from its chronological error budget series, its points spaced as
+// pointStep tells.
+func setBurnRates(v *model.SLOData, sloWindow time.Duration) {
+	if len(v.Points) < 2 {
+		return
+	}
+	step := pointStep(v, sloWindow)
+
+	peaks := []*float64{&v.BurnRate1h, &v.BurnRate6h, &v.BurnRate24h, &v.BurnRate72h}
+	var previous []float64
+	for i, lookback := range burnRateLookbacks {
+		rates := utils.BurnRates(v.Points, step, sloWindow, lookback)
+		*peaks[i] = utils.PeakBurnRate(rates)
+		if previous != nil && rates != nil {
+			v.PeakBurnRate = max(v.PeakBurnRate, utils.MultiWindowPeak(previous, rates))
+		}
+		previous = rates
+
+		if lookback == exhaustionLookback && rates != nil {
+			if d, ok := utils.TimeToExhaustion(v.Points[len(v.Points)-1], rates[len(rates)-1], sloWindow); ok {
+				v.TimeToExhaustion = formatDuration(d)
+			}
+		}
+	}
+}
+
+// formatDuration rounds d to the hour, or to the minute below an hour, and drops the zero units, e.g. 36h or 45m.
+func formatDuration(d time.Duration) string {
+	if d >= time.Hour {
+		d = d.Round(time.Hour)
+	} else {
+		d = d.Round(time.Minute)
+	}
+	if d == 0 {
+		return "0s"
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(d.String(), "0s"), "0m")
+}
@@ -0,0 +1,32 @@
+package vigil
+
+import (
+	"cmp"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil/analysis"
+)
+
+// DefaultChecks returns the built-in checks opts enables, in the order of analysis.Names. The burn rate, trend and
+// forecast checks are only enabled by BurnRateThreshold, FlagDegrading and FlagExhaustion.
+func DefaultChecks(opts Options) []analysis.Check {
+	checks := []analysis.Check{
+		analysis.NoDataCheck(cmp.Or(opts.MinPoints, DefaultMinPoints)),
+		analysis.NegativeBudgetCheck(),
+	}
+	if opts.BurnRateThreshold > 0 {
+		checks = append(checks, analysis.BurnRateCheck(opts.BurnRateThreshold))
+	}
+	if opts.FlagDegrading {
+		checks = append(checks, analysis.TrendCheck())
+	}
+	if opts.FlagExhaustion {
+		checks = append(checks, analysis.ForecastCheck())
+	}
+	return append(checks, analysis.TooLaxCheck())
+}
+
+// categorize sets the category of v and the findings behind it from the checks of opts.
+func categorize(v *model.SLOData, slo *model.SLO, opts *Options) {
+	v.Category, v.Findings = analysis.Run(opts.Checks, v, slo)
+}
@@ -0,0 +1,112 @@
+package vigil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil/analysis"
+	"github.com/rluisr/vigil/provider/fake"
+)
+
+func TestCategories(t *testing.T) {
+	const n = 720
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	all := Options{
+		Window:            n * time.Hour,
+		BurnRateThreshold: 14.4,
+		FlagDegrading:     true,
+		FlagExhaustion:    true,
+	}
+	// Without the trend check, so the forecast decides the category of a budget steadily running out.
+	forecastOnly := all
+	forecastOnly.FlagDegrading = false
+
+	tests := []struct {
+		name     string
+		points   []float64
+		opts     Options
+		category model.Category
+		check    string
+	}{
+		{
+			name:     "no data",
+			opts:     all,
+			category: model.CategoryNoData,
+			check:    analysis.NoData,
+		},
+		{
+			name:     "negative budget",
+			points:   fake.Linear(0.2, -0.9, n),
+			opts:     all,
+			category: model.CategoryBurning,
+			check:    analysis.NegativeBudget,
+		},
+		{
+			name:     "fast burn",
+			points:   fake.Dip(n, n-72, 6, -0.5),
+			opts:     all,
+			category: model.CategoryBurning,
+			check:    analysis.BurnRate,
+		},
+		{
+			name:     "trend",
+			points:   fake.Linear(0.8, 0.05, n),
+			opts:     all,
+			category: model.CategoryBurning,
+			check:    analysis.Trend,
+		},
+		{
+			name:     "forecast",
+			points:   fake.Linear(0.8, 0.05, n),
+			opts:     forecastOnly,
+			category: model.CategoryBurning,
+			check:    analysis.Forecast,
+		},
+		{
+			name:     "too lax",
+			points:   fake.Constant(0.95, n),
+			opts:     all,
+			category: model.CategoryLax,
+			check:    analysis.TooLax,
+		},
+		{
+			name:     "healthy",
+			points:   append(fake.Linear(1, 0.6, n/2), fake.Linear(0.6, 0.8, n-n/2)...),
+			opts:     all,
+			category: model.CategoryHealthy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slo := fake.NewSLO("checkout-availability", 0.999)
+			p := fake.New().Add(slo, fake.Series{Points: fake.Points(now, time.Hour, tt.points...)})
+			opts := tt.opts
+			opts.Now = func() time.Time { return now }
+
+			result, err := Run(context.Background(), p, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := result.SLOs[slo.Name]
+			if v.Category != tt.category {
+				t.Errorf("Category = %s, want %s", v.Category, tt.category)
+			}
+			if tt.check == "" {
+				if len(v.Findings) > 0 {
+					t.Errorf("Findings = %v, want none", v.Findings)
+				}
+				return
+			}
+			if len(v.Findings) == 0 || v.Findings[0].Check != tt.check {
+				t.Errorf("Findings = %v, want %s first", v.Findings, tt.check)
+			}
+			wantFlag := tt.category == model.CategoryLax || tt.category == model.CategoryBurning
+			if v.Flag != wantFlag {
+				t.Errorf("Flag = %t, want %t", v.Flag, wantFlag)
+			}
+		})
+	}
+}
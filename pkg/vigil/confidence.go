@@ -0,0 +1,21 @@
+package vigil
+
+import (
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/utils"
+)
+
+// fullConfidencePoints is the number of points from which the sample count no longer lowers the confidence.
+const fullConfidencePoints = 100
+
+// setConfidence scores how much the category of v, and so the recommendation made for it, can be trusted: 0 ~ 1.
+// Few points or a budget swinging widely over the window both lower it.
+func setConfidence(v *model.SLOData) {
+	if len(v.Points) == 0 {
+		return
+	}
+
+	samples := min(float64(len(v.Points))/fullConfidencePoints, 1)
+	stability := max(1-utils.StdDev(v.Points), 0)
+	v.Confidence = samples * stability
+}
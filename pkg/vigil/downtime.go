@@ -0,0 +1,20 @@
+package vigil
+
+import (
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// setDowntime translates the budget of v into minutes of downtime over sloWindow. For request based SLOs the minutes
+// are equivalent ones: the time the service could have been fully down for the same number of bad requests.
+func setDowntime(v *model.SLOData, sloWindow time.Duration) {
+	if len(v.Points) == 0 || v.SLO <= 0 || v.SLO >= 1 {
+		return
+	}
+
+	v.AllowedDowntimeMinutes = (1 - v.SLO) * sloWindow.Minutes()
+	last := v.Points[len(v.Points)-1]
+	v.BadMinutes = max(1-last, 0) * v.AllowedDowntimeMinutes
+	v.RemainingDowntimeMinutes = last * v.AllowedDowntimeMinutes
+}
@@ -0,0 +1,41 @@
+package vigil
+
+import (
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/utils"
+)
+
+// Smoothing factors of the exhaustion forecast. The level follows recent points closely, the trend changes slowly so
+// a single spike does not dominate the projection.
+const (
+	forecastAlpha = 0.3
+	forecastBeta  = 0.05
+)
+
+// setExhaustionForecast projects the date the budget of v hits zero at its current consumption rate with Holt's
+// linear method. The points are spaced as pointStep tells and the series is assumed to end at now. A date beyond the
+// window is left out, as a nearly flat trend projects one too far to be a time.Time.
+func setExhaustionForecast(v *model.SLOData, sloWindow time.Duration, now time.Time) {
+	if len(v.Points) < 2 {
+		return
+	}
+	step := pointStep(v, sloWindow)
+
+	level, trend := utils.Holt(v.Points, forecastAlpha, forecastBeta)
+	switch {
+	case level <= 0:
+		v.ExhaustionDate = now
+	case trend < 0:
+		// In float64 first: the horizon overflows a time.Duration when the trend is close to 0.
+		horizon := -level / trend * float64(step)
+		if horizon > float64(sloWindow) {
+			return
+		}
+		v.ExhaustionDate = now.Add(time.Duration(horizon))
+	default:
+		return
+	}
+	v.ExhaustsWithinWindow = true
+}
@@ -0,0 +1,63 @@
+package vigil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider/fake"
+)
+
+func TestSetExhaustionForecast(t *testing.T) {
+	const window = 720 * time.Hour
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		points   []float64
+		exhausts bool
+	}{
+		{
+			name:     "running out",
+			points:   fake.Linear(0.8, 0.05, 720),
+			exhausts: true,
+		},
+		{
+			name:     "exhausted",
+			points:   fake.Linear(0.2, -0.9, 720),
+			exhausts: true,
+		},
+		{
+			// The trend is barely negative, projecting the exhaustion beyond what a time.Duration holds.
+			name:   "nearly flat",
+			points: fake.Linear(0.95, 0.9499999, 720),
+		},
+		{
+			name:   "beyond the window",
+			points: fake.Linear(0.95, 0.9, 720),
+		},
+		{
+			name:   "flat",
+			points: fake.Constant(0.95, 720),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &model.SLOData{Points: tt.points}
+			setExhaustionForecast(v, window, now)
+			if v.ExhaustsWithinWindow != tt.exhausts {
+				t.Errorf("ExhaustsWithinWindow = %t, want %t", v.ExhaustsWithinWindow, tt.exhausts)
+			}
+			if !tt.exhausts {
+				if !v.ExhaustionDate.IsZero() {
+					t.Errorf("ExhaustionDate = %s, want zero", v.ExhaustionDate)
+				}
+				return
+			}
+			if v.ExhaustionDate.Before(now) || v.ExhaustionDate.After(now.Add(window)) {
+				t.Errorf("ExhaustionDate = %s, want within %s of %s", v.ExhaustionDate, window, now)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package vigil
+
+import (
+	"math"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Recommended goals are rounded down to goalPrecision, 0.01%, and SLOs without any bad event are not tightened past
+// maxRecommendedGoal.
+const (
+	goalPrecision      = 1e-4
+	maxRecommendedGoal = 0.9999
+)
+
+// setRecommendation recommends a new goal for a flagged SLO: the goal at which the worst point of the window would have
+// left exactly the ErrorBudgetThreshold of the budget. It tightens LAX SLOs and loosens BURNING ones. The goal is
+// rounded down, so a tightened goal is never stricter than the data supports.
+func setRecommendation(v *model.SLOData) {
+	// The budget of an SLO with monitor issues is no basis for a goal, and a single incident is no reason to loosen one.
+	if !v.Flag || len(v.MonitorIssues) > 0 || v.IncidentDriven || v.SLO <= 0 || v.SLO >= 1 {
+		return
+	}
+
+	// bad is the share of bad events over the window at its worst point.
+	bad := (1 - v.SLO) * (1 - v.MinBudget)
+	goal := math.Floor((1-bad/(1-v.ErrorBudgetThreshold))/goalPrecision+1e-9) * goalPrecision
+	goal = math.Round(min(goal, max(maxRecommendedGoal, v.SLO))/goalPrecision) * goalPrecision
+	if goal <= 0 || goal == v.SLO {
+		return
+	}
+	v.TargetSLO = goal
+}
@@ -0,0 +1,59 @@
+package vigil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rluisr/vigil/provider/fake"
+)
+
+func TestRecommendation(t *testing.T) {
+	const n = 720
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		points    []float64
+		recommend bool
+	}{
+		{
+			// A single incident spent the budget: the goal is kept.
+			name:   "single incident",
+			points: fake.Dip(n, n-72, 6, -0.5),
+		},
+		{
+			name:      "chronic burn",
+			points:    fake.Linear(0.2, -0.9, n),
+			recommend: true,
+		},
+		{
+			name:      "too lax",
+			points:    fake.Constant(0.95, n),
+			recommend: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slo := fake.NewSLO("checkout-availability", 0.999)
+			p := fake.New().Add(slo, fake.Series{Points: fake.Points(now, time.Hour, tt.points...)})
+			result, err := Run(context.Background(), p, Options{
+				Window:            n * time.Hour,
+				BurnRateThreshold: 14.4,
+				DetectAnomalies:   true,
+				Now:               func() time.Time { return now },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			v := result.SLOs[slo.Name]
+			if !v.Flag {
+				t.Fatalf("the SLO is not flagged: %s", v.Category)
+			}
+			if got := v.TargetSLO != 0; got != tt.recommend {
+				t.Errorf("TargetSLO = %g, want a recommendation: %t", v.TargetSLO, tt.recommend)
+			}
+		})
+	}
+}
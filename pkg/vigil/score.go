@@ -0,0 +1,36 @@
+package vigil
+
+import (
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Weights of the components of the health score, summing to 1.
+const (
+	minBudgetWeight = 0.35
+	negativeWeight  = 0.3
+	burnRateWeight  = 0.2
+	trendWeight     = 0.15
+)
+
+// burnRateScale is the peak burn rate that maxes out its component, the fast burn page threshold of the Google SRE
+// workbook.
+const burnRateScale = 14.4
+
+// setHealthScore combines the minimum budget, negative fraction, peak burn rate and trend of v into a single score,
+// 0 ~ 100 where 100 is healthy, so SLOs can be ranked. Each component is a penalty capped at 1: the budget consumed
+// at the worst point, the negative fraction, the peak burn rate over burnRateScale and the decline of the fitted
+// budget over sloWindow. It must run after setBurnRates and setTrend.
+func setHealthScore(v *model.SLOData, sloWindow time.Duration) {
+	if len(v.Points) == 0 {
+		return
+	}
+
+	clamp := func(x float64) float64 { return min(max(x, 0), 1) }
+	penalty := minBudgetWeight*clamp(1-v.MinBudget) +
+		negativeWeight*clamp(v.NegativeFraction) +
+		burnRateWeight*clamp(v.PeakBurnRate/burnRateScale) +
+		trendWeight*clamp(-v.Slope*sloWindow.Hours()/24)
+	v.HealthScore = 100 * (1 - penalty)
+}
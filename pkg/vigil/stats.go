@@ -0,0 +1,57 @@
+package vigil
+
+import (
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/utils"
+)
+
+// budgetStats summarizes an error budget series. The lowest trim fraction of the points is left out of the minimum and
+// average budget and the negative fraction, and the average is weighted by the traffic behind each point when weights
+// are known. The percentiles use every point.
+func budgetStats(points, weights []float64, trim float64) model.BudgetStats {
+	statPoints, statWeights := trimPoints(points, weights, trim)
+	minBudget, _ := utils.GetMinAvgErrorBudget(statPoints)
+	avgBudget, trafficWeighted := averageBudget(statPoints, statWeights)
+	percentiles := utils.Quantiles(points, 0.5, 0.1, 0.01, 0.001)
+
+	return model.BudgetStats{
+		AvgBudget:        avgBudget,
+		MinBudget:        minBudget,
+		NegativeFraction: utils.NegativeFraction(statPoints),
+		TrafficWeighted:  trafficWeighted,
+		TrimmedPoints:    len(points) - len(statPoints),
+		P50Budget:        percentiles[0],
+		P90Budget:        percentiles[1],
+		P99Budget:        percentiles[2],
+		P999Budget:       percentiles[3],
+	}
+}
+
+// splitPoints splits the points of a provider into their values and times.
+func splitPoints(points []model.Point) (values []float64, times []time.Time) {
+	if len(points) == 0 {
+		return nil, nil
+	}
+
+	values = make([]float64, len(points))
+	times = make([]time.Time, len(points))
+	for i, p := range points {
+		values[i] = p.Value
+		times[i] = p.Time.UTC()
+	}
+	return values, times
+}
+
+// pointStep returns the average spacing of the points of v from their timestamps, or sloWindow split evenly between
+// them when the provider gave none. v has at least two points.
+func pointStep(v *model.SLOData, sloWindow time.Duration) time.Duration {
+	n := len(v.Points)
+	if len(v.Timestamps) == n {
+		if span := v.Timestamps[n-1].Sub(v.Timestamps[0]); span > 0 {
+			return span / time.Duration(n-1)
+		}
+	}
+	return sloWindow / time.Duration(n-1)
+}
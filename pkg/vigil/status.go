@@ -0,0 +1,73 @@
+package vigil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+// setStatus fetches the status the provider computes for slo, such as its compliance, when it computes one.
+func setStatus(ctx context.Context, client provider.Provider, slo *model.SLO, v *model.SLOData) error {
+	p, ok := client.(provider.StatusProvider)
+	if !ok {
+		return nil
+	}
+
+	status, err := p.GetSLOStatus(ctx, slo)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of %s: %w", slo.DisplayName, err)
+	}
+	v.Status = status
+	return nil
+}
+
+// setAlerted looks up whether an alert fires on the burn rate of slo, when the provider knows its alert policies.
+func setAlerted(ctx context.Context, client provider.Provider, slo *model.SLO, v *model.SLOData) error {
+	p, ok := client.(provider.AlertChecker)
+	if !ok {
+		return nil
+	}
+
+	alerted, err := p.Alerted(ctx, slo)
+	if err != nil {
+		return fmt.Errorf("failed to look up the alerts of %s: %w", slo.DisplayName, err)
+	}
+	v.Alerted = &alerted
+	return nil
+}
+
+// setBadTimeInDowntime looks up the share of the bad time of slo spent in scheduled downtimes, when the provider knows
+// the downtimes of its SLI.
+func setBadTimeInDowntime(ctx context.Context, client provider.Provider, slo *model.SLO, v *model.SLOData) error {
+	p, ok := client.(provider.DowntimeReporter)
+	if !ok {
+		return nil
+	}
+
+	share, ok, err := p.BadTimeInDowntime(ctx, slo)
+	if err != nil {
+		return fmt.Errorf("failed to look up the downtimes of %s: %w", slo.DisplayName, err)
+	}
+	if ok {
+		v.BadTimeInDowntime = &share
+	}
+	return nil
+}
+
+// setMonitorIssues looks up the monitors of slo that keep it from seeing bad time, when the provider computes it from
+// monitors.
+func setMonitorIssues(ctx context.Context, client provider.Provider, slo *model.SLO, v *model.SLOData) error {
+	p, ok := client.(provider.MonitorHealthChecker)
+	if !ok {
+		return nil
+	}
+
+	issues, err := p.MonitorIssues(ctx, slo)
+	if err != nil {
+		return fmt.Errorf("failed to check the monitors of %s: %w", slo.DisplayName, err)
+	}
+	v.MonitorIssues = issues
+	return nil
+}
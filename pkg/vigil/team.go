@@ -0,0 +1,12 @@
+package vigil
+
+import (
+	"cmp"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// sloTeam returns the owning team of slo from its teamLabel label, or the one of its service.
+func sloTeam(slo *model.SLO, teamLabel string) string {
+	return cmp.Or(slo.Labels[teamLabel], slo.ServiceLabels[teamLabel])
+}
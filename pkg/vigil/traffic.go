@@ -0,0 +1,29 @@
+package vigil
+
+import (
+	"context"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+	"github.com/rluisr/vigil/utils"
+)
+
+// fetchErrorBudget fetches the error budget of slo, along with the total events behind each point when the provider
+// knows them. weights is nil otherwise.
+func fetchErrorBudget(ctx context.Context, client provider.Provider, slo *model.SLO) (good, total string, points []model.Point, weights []float64, err error) {
+	if p, ok := client.(provider.TrafficProvider); ok {
+		return p.GetWeightedErrorBudgetTimeSeries(ctx, slo)
+	}
+	good, total, points, err = client.GetErrorBudgetTimeSeries(ctx, slo)
+	return good, total, points, nil, err
+}
+
+// averageBudget returns the average of points weighted by traffic when weights line up with them, so low-traffic
+// periods do not distort the average of bursty services. It falls back to the plain average otherwise.
+func averageBudget(points, weights []float64) (avg float64, weighted bool) {
+	if avg, ok := utils.WeightedMean(points, weights); ok {
+		return avg, true
+	}
+	_, avg = utils.GetMinAvgErrorBudget(points)
+	return avg, false
+}
@@ -0,0 +1,39 @@
+package vigil
+
+import (
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/utils"
+)
+
+// stableChange is the change of the fitted budget over a whole window below which a trend is stable.
+const stableChange = 0.05
+
+// setTrend fits a line through the error budget series of v, its points spaced as pointStep tells.
+func setTrend(v *model.SLOData, sloWindow time.Duration) {
+	if len(v.Points) < 2 {
+		v.Trend = model.TrendStable
+		return
+	}
+
+	slope, intercept := utils.LinearRegression(v.Points)
+	step := pointStep(v, sloWindow)
+	steps := float64(len(v.Points) - 1)
+	// The number of steps in one window.
+	window := float64(sloWindow) / float64(step)
+	v.Slope = slope / (step.Hours() / 24)
+
+	change := slope * window
+	switch {
+	case change <= -stableChange:
+		v.Trend = model.TrendDegrading
+	case change >= stableChange:
+		v.Trend = model.TrendImproving
+	default:
+		v.Trend = model.TrendStable
+	}
+
+	// The fitted budget one more window after the last point.
+	v.ProjectedBudget = intercept + slope*(steps+window)
+}
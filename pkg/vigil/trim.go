@@ -0,0 +1,24 @@
+package vigil
+
+import "github.com/rluisr/vigil/utils"
+
+// trimPoints drops the lowest fraction of points, and their weights when they line up, before the minimum and average
+// budget and the negative fraction are computed. The other statistics keep every point.
+func trimPoints(points, weights []float64, fraction float64) (trimmedPoints, trimmedWeights []float64) {
+	if fraction == 0 {
+		return points, weights
+	}
+
+	kept := utils.TrimLowest(points, fraction)
+	trimmedPoints = make([]float64, len(kept))
+	for i, k := range kept {
+		trimmedPoints[i] = points[k]
+	}
+	if len(weights) == len(points) {
+		trimmedWeights = make([]float64, len(kept))
+		for i, k := range kept {
+			trimmedWeights[i] = weights[k]
+		}
+	}
+	return trimmedPoints, trimmedWeights
+}
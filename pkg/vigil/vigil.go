@@ -0,0 +1,380 @@
+// Package vigil audits SLOs: it fetches the error budget time series of every SLO of a provider and flags the ones
+// whose objective is too lax or whose budget is burning, with the statistics, trends and recommended goals the vigil
+// command reports.
+//
+// Every SLO a provider client lists is audited with Run:
+//
+//	client, err := prometheus.NewClient(ctx, "http://localhost:9090", 0.9, 30*24*time.Hour)
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	result, err := vigil.Run(ctx, client, vigil.Options{ErrorBudgetThreshold: 0.9, Window: 30 * 24 * time.Hour})
+//	if err != nil {
+//		return err
+//	}
+//	for _, v := range result.SLOs {
+//		if v.Flag {
+//			fmt.Printf("%s is %s\n", v.DisplayName, v.Category)
+//		}
+//	}
+//
+// Audit does the same for SLOs already listed, possibly from several providers.
+package vigil
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/pkg/vigil/analysis"
+	"github.com/rluisr/vigil/provider"
+	"github.com/rluisr/vigil/utils"
+)
+
+// Defaults of the zero values of Options.
+const (
+	DefaultErrorBudgetThreshold   = 0.9
+	DefaultWindow                 = 720 * time.Hour
+	DefaultNegativeBudgetFraction = 0.5
+	DefaultTeamLabel              = "team"
+	DefaultMinPoints              = 10
+	DefaultConcurrency            = 16
+)
+
+// Options are the thresholds and analyses of an audit. The zero value of a field is its default.
+type Options struct {
+	// ErrorBudgetThreshold flags an SLO as too lax when its remaining error budget never dropped below it during the
+	// window. 0 ~ 1, DefaultErrorBudgetThreshold by default.
+	ErrorBudgetThreshold float64
+	// Window is the period the error budget is analyzed over, DefaultWindow by default. The window an SLO is
+	// configured with in its provider is used instead when it has one, unless Window is given.
+	Window time.Duration
+	// NegativeBudgetFraction flags an SLO as burning when its error budget was negative for at least this fraction of
+	// the window. 0 ~ 1, DefaultNegativeBudgetFraction by default.
+	NegativeBudgetFraction float64
+	// Windows are more windows the error budget is summarized over, in per-window stats, without other analyses.
+	Windows []time.Duration
+	// BurnRateThreshold flags an SLO as burning when its peak multiwindow burn rate reached this multiple of the rate
+	// that exactly exhausts the budget over the window, e.g. 14.4. 0 disables it.
+	BurnRateThreshold float64
+	// FlagDegrading also flags SLOs whose budget is still positive but trending toward exhaustion within one more
+	// window.
+	FlagDegrading bool
+	// FlagExhaustion also flags SLOs whose budget is forecast to hit zero within the next window.
+	FlagExhaustion bool
+	// DetectAnomalies finds anomalous budget consumption, marking the SLOs whose spending is driven by a single
+	// incident.
+	DetectAnomalies bool
+	// TeamLabel is the label naming the team that owns an SLO, DefaultTeamLabel by default.
+	TeamLabel string
+	// Trim is the fraction of the lowest error budget points left out of the minimum and average budget and the
+	// negative fraction. 0 ~ 0.5.
+	Trim float64
+	// MinPoints is the number of points below which an SLO is categorized as NO_DATA, DefaultMinPoints by default.
+	MinPoints int
+	// Concurrency is the number of SLOs processed at once, DefaultConcurrency by default.
+	Concurrency int
+	// Checks categorize every SLO, the first one with a finding deciding its category. nil runs DefaultChecks, while
+	// an empty slice runs none and leaves every SLO HEALTHY or flagged on its monitor issues alone.
+	Checks []analysis.Check
+	// ContinueOnError records the SLOs that fail in Result.Failures instead of aborting the audit.
+	ContinueOnError bool
+	// Settings, when set, returns the settings of each SLO instead of the thresholds and window of Options, e.g. to
+	// apply per-SLO overrides.
+	Settings func(slo *model.SLO) Settings
+	// Progress, when set, is called once every SLO is processed, one call at a time.
+	Progress func(slo *model.SLO)
+	// Debugf, when set, logs the fetch time and number of points of every SLO.
+	Debugf func(format string, v ...interface{})
+	// Now, when set, returns the time the exhaustion forecast is made from instead of time.Now, so a fixed series
+	// gives the same results every time.
+	Now func() time.Time
+}
+
+// Settings are the settings an SLO is evaluated with.
+type Settings struct {
+	ErrorBudgetThreshold   float64
+	Window                 time.Duration
+	NegativeBudgetFraction float64
+}
+
+// Result is the outcome of an audit.
+type Result struct {
+	// SLOs are the audited SLOs by key.
+	SLOs map[string]*model.SLOData
+	// Failures are the SLOs that failed with ContinueOnError.
+	Failures []Failure
+	// Skipped is the number of SLOs left out because the context was cancelled.
+	Skipped int
+	// Warnings are the SLOs the provider could not list, as returned in a provider.PartialError.
+	Warnings []string
+	// Options are the options the SLOs were audited with, defaults included, e.g. DefaultChecks when Checks was nil.
+	Options Options
+}
+
+// Failure is an SLO that could not be processed with ContinueOnError.
+type Failure struct {
+	Key         string              `json:"key"`
+	DisplayName string              `json:"displayName"`
+	Provider    model.CloudProvider `json:"provider"`
+	Error       string              `json:"error"`
+}
+
+// Run audits every SLO of client. The SLOs the provider fails to list are reported in Result.Warnings. When ctx is
+// cancelled, Run returns the SLOs processed so far along with the error of ctx.
+func Run(ctx context.Context, client provider.Provider, opts Options) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	slos, err := client.GetSLOs(ctx)
+	var (
+		warnings []string
+		partial  *provider.PartialError
+	)
+	if errors.As(err, &partial) {
+		for _, skipped := range partial.Skipped {
+			warnings = append(warnings, fmt.Sprintf("%s SLOs skipped: %s", client.GetProvider(), skipped))
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list %s SLOs: %w", client.GetProvider(), err)
+	}
+
+	clients := make(map[*model.SLO]provider.Provider, len(slos))
+	for _, slo := range slos {
+		clients[slo] = client
+	}
+	result, err := Audit(ctx, slos, clients, opts)
+	if result != nil {
+		result.Warnings = warnings
+	}
+	return result, err
+}
+
+// Audit audits slos with the provider each is mapped to in clients. It stops at the first SLO that fails unless
+// ContinueOnError is set. When ctx is cancelled, Audit returns the SLOs processed so far, with the others counted in
+// Result.Skipped, along with the error of ctx.
+func Audit(ctx context.Context, slos []*model.SLO, clients map[*model.SLO]provider.Provider, opts Options) (*Result, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	opts.setDefaults()
+
+	var (
+		result    = &Result{SLOs: make(map[string]*model.SLOData), Options: opts}
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		sem       = make(chan struct{}, opts.Concurrency)
+		firstErr  error
+		processed int
+	)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	for _, slo := range slos {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil || failed() {
+			break
+		}
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			v, err := processSLO(ctx, clients[slo], slo, &opts)
+			// Failures caused by the cancellation are counted as skipped, not as errors.
+			if err != nil && ctx.Err() != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && !opts.ContinueOnError {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to process SLO %s: %w", slo.DisplayName, err)
+				}
+				return
+			}
+			processed++
+			if err != nil {
+				result.Failures = append(result.Failures, Failure{
+					Key:         slo.Name,
+					DisplayName: slo.DisplayName,
+					Provider:    clients[slo].GetProvider(),
+					Error:       err.Error(),
+				})
+			} else {
+				result.SLOs[slo.Name] = v
+			}
+			if opts.Progress != nil {
+				opts.Progress(slo)
+			}
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		result.Skipped = len(slos) - processed
+		return result, err
+	}
+	return result, nil
+}
+
+// validate checks the thresholds of opts, where a zero value stands for its default.
+func (opts *Options) validate() error {
+	switch {
+	case opts.ErrorBudgetThreshold < 0 || opts.ErrorBudgetThreshold >= 1:
+		return errors.New("the error budget threshold must be between 0 and 1")
+	case opts.Window < 0:
+		return errors.New("the window must be a positive duration")
+	case opts.NegativeBudgetFraction < 0 || opts.NegativeBudgetFraction > 1:
+		return errors.New("the negative budget fraction must be greater than 0 and at most 1")
+	case opts.BurnRateThreshold < 0:
+		return errors.New("the burn rate threshold must not be negative")
+	case opts.Trim < 0 || opts.Trim >= 0.5:
+		return errors.New("the trim must be at least 0 and less than 0.5")
+	case opts.MinPoints < 0:
+		return errors.New("the minimum points must not be negative")
+	case opts.Concurrency < 0:
+		return errors.New("the concurrency must not be negative")
+	}
+	for _, w := range opts.Windows {
+		if w <= 0 {
+			return fmt.Errorf("the window %s is not a positive duration", w)
+		}
+	}
+	return nil
+}
+
+func (opts *Options) setDefaults() {
+	if opts.Checks == nil {
+		opts.Checks = DefaultChecks(*opts)
+	}
+	if opts.Settings == nil {
+		defaults := Settings{
+			ErrorBudgetThreshold:   cmp.Or(opts.ErrorBudgetThreshold, DefaultErrorBudgetThreshold),
+			Window:                 cmp.Or(opts.Window, DefaultWindow),
+			NegativeBudgetFraction: cmp.Or(opts.NegativeBudgetFraction, DefaultNegativeBudgetFraction),
+		}
+		window := opts.Window
+		opts.Settings = func(slo *model.SLO) Settings {
+			s := defaults
+			if slo.Period > 0 && window == 0 {
+				s.Window = slo.Period
+			}
+			return s
+		}
+	}
+	opts.Window = cmp.Or(opts.Window, DefaultWindow)
+	opts.TeamLabel = cmp.Or(opts.TeamLabel, DefaultTeamLabel)
+	opts.MinPoints = cmp.Or(opts.MinPoints, DefaultMinPoints)
+	opts.Concurrency = cmp.Or(opts.Concurrency, DefaultConcurrency)
+	if opts.Debugf == nil {
+		opts.Debugf = func(string, ...interface{}) {}
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+}
+
+// processSLO fetches the error budget of slo and analyzes it with the settings opts gives it.
+func processSLO(ctx context.Context, client provider.Provider, slo *model.SLO, opts *Options) (*model.SLOData, error) {
+	settings := opts.Settings(slo)
+	sloWindow := settings.Window
+	// The providers fetch the window of the SLO, so a copy carries it rather than the SLO of the caller, which later
+	// runs with other settings get again.
+	if sloWindow != opts.Window {
+		s := *slo
+		s.Window = sloWindow
+		slo = &s
+	}
+
+	start := time.Now()
+	goodQuery, totalQuery, series, weights, err := fetchErrorBudget(ctx, client, slo)
+	if err != nil {
+		if !strings.Contains(err.Error(), "no data points found") {
+			return nil, err
+		}
+		// A dead SLI pipeline is a finding too: the SLO is reported without points, in the NO_DATA category.
+		opts.Debugf("%v", err)
+	}
+	opts.Debugf("Fetched %s in %s: %d points", slo.DisplayName, time.Since(start).Round(time.Millisecond), len(series))
+	points, timestamps := splitPoints(series)
+
+	v := &model.SLOData{
+		Key:         slo.Name,
+		DisplayName: slo.DisplayName,
+		Project:     slo.Project,
+		Service:     slo.Service,
+		Team:        sloTeam(slo, opts.TeamLabel),
+		Provider:    client.GetProvider(),
+		SLO:         slo.Goal,
+		GoodQuery:   goodQuery,
+		TotalQuery:  totalQuery,
+		ConsoleURL:  slo.ConsoleURL,
+		Labels:      slo.Labels,
+		Points:      points,
+		Timestamps:  timestamps,
+		BudgetStats: budgetStats(points, weights, opts.Trim),
+
+		ServiceType:     slo.ServiceType,
+		ServiceResource: slo.ServiceResource,
+
+		ErrorBudgetThreshold:   settings.ErrorBudgetThreshold,
+		Window:                 sloWindow.String(),
+		NegativeBudgetFraction: settings.NegativeBudgetFraction,
+	}
+	if len(points) > 0 {
+		v.BudgetConsumed = 1 - v.MinBudget
+		v.BudgetConsumedTotal = utils.ConsumedBudget(points)
+	}
+	setDowntime(v, sloWindow)
+	setBurnRates(v, sloWindow)
+	setTrend(v, sloWindow)
+	setExhaustionForecast(v, sloWindow, opts.Now())
+	if opts.DetectAnomalies {
+		setAnomalies(v)
+	}
+	setConfidence(v)
+	setHealthScore(v, sloWindow)
+	if err := setWindows(ctx, client, slo, v, weights, settings, opts); err != nil {
+		return nil, err
+	}
+	if err := setStatus(ctx, client, slo, v); err != nil {
+		return nil, err
+	}
+	if err := setAlerted(ctx, client, slo, v); err != nil {
+		return nil, err
+	}
+	if err := setBadTimeInDowntime(ctx, client, slo, v); err != nil {
+		return nil, err
+	}
+	if err := setMonitorIssues(ctx, client, slo, v); err != nil {
+		return nil, err
+	}
+	if len(points) > 1 {
+		step := pointStep(v, sloWindow)
+		v.LongestBreachHours = float64(utils.LongestRunBelow(points, settings.ErrorBudgetThreshold)) * step.Hours()
+	}
+	categorize(v, slo, opts)
+	// An SLO whose monitors are muted or without data cannot see bad time, whatever its budget says.
+	v.Flag = v.Category == model.CategoryLax || v.Category == model.CategoryBurning || len(v.MonitorIssues) > 0
+	setRecommendation(v)
+	setBreaches(v, sloWindow)
+	return v, nil
+}
@@ -0,0 +1,44 @@
+package vigil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+	"github.com/rluisr/vigil/provider/fake"
+)
+
+func TestAuditKeepsSLOWindow(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	slo := fake.NewSLO("checkout-availability", 0.999)
+	slo.Period = 7 * 24 * time.Hour
+	p := fake.New().Add(slo, fake.Series{Points: fake.Points(now, time.Hour, fake.Constant(0.5, 720)...)})
+	clients := map[*model.SLO]provider.Provider{slo: p}
+
+	// Without a window, the SLO is evaluated over its period.
+	result, err := Audit(context.Background(), []*model.SLO{slo}, clients, Options{Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.SLOs[slo.Name].Window; got != "168h0m0s" {
+		t.Errorf("Window = %s, want 168h0m0s", got)
+	}
+	if slo.Window != 0 {
+		t.Fatalf("Audit set the window of the SLO to %s", slo.Window)
+	}
+
+	// A later run with a window gets the points of that window, not of the period of the previous run.
+	result, err = Audit(context.Background(), []*model.SLO{slo}, clients, Options{Window: 720 * time.Hour, Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := result.SLOs[slo.Name]
+	if v.Window != "720h0m0s" {
+		t.Errorf("Window = %s, want 720h0m0s", v.Window)
+	}
+	if len(v.Points) != 720 {
+		t.Errorf("got %d points, want 720", len(v.Points))
+	}
+}
@@ -0,0 +1,56 @@
+package vigil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+// setWindows summarizes the error budget of slo over its window and every one of opts.Windows when any is given. The
+// primary one reuses the series of v and its traffic weights, so it is the window of the settings of the SLO.
+func setWindows(ctx context.Context, client provider.Provider, slo *model.SLO, v *model.SLOData, weights []float64, settings Settings, opts *Options) error {
+	if len(opts.Windows) == 0 {
+		return nil
+	}
+
+	v.Windows = []model.WindowStats{windowStats(slo, v.Points, weights, settings.Window, settings, opts)}
+	for _, w := range opts.Windows {
+		s := *slo
+		s.Window = w
+		_, _, points, weights, err := fetchErrorBudget(ctx, client, &s)
+		if err != nil && !strings.Contains(err.Error(), "no data points found") {
+			return fmt.Errorf("failed to get the error budget over %s: %w", w, err)
+		}
+		values, _ := splitPoints(points)
+		v.Windows = append(v.Windows, windowStats(slo, values, weights, w, settings, opts))
+	}
+	return nil
+}
+
+// windowStats summarizes an error budget series of slo over window w with the thresholds of settings, as budgetStats
+// does for the primary window.
+func windowStats(slo *model.SLO, points, weights []float64, w time.Duration, settings Settings, opts *Options) model.WindowStats {
+	v := &model.SLOData{
+		Points:                 points,
+		BudgetStats:            budgetStats(points, weights, opts.Trim),
+		ErrorBudgetThreshold:   settings.ErrorBudgetThreshold,
+		NegativeBudgetFraction: settings.NegativeBudgetFraction,
+	}
+	// Only the thresholds apply: burn rates, trends and forecasts are computed over the primary window.
+	categorize(v, slo, opts)
+	stats := model.WindowStats{
+		Window:           w.String(),
+		MinBudget:        v.MinBudget,
+		AvgBudget:        v.AvgBudget,
+		NegativeFraction: v.NegativeFraction,
+		Category:         v.Category,
+	}
+	if len(points) > 0 {
+		stats.BudgetConsumed = 1 - v.MinBudget
+	}
+	return stats
+}
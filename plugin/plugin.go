@@ -0,0 +1,330 @@
+// Package plugin runs providers as external processes that talk to vigil over JSON-RPC on stdin/stdout,
+// so proprietary SLO systems can be audited without forking vigil.
+//
+// A plugin is a standalone binary whose main function calls Serve:
+//
+//	func main() {
+//		plugin.Serve(func(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+//			return mybackend.NewClient(ctx, opts.ErrorBudgetThreshold, opts.Window)
+//		})
+//	}
+//
+// Plugins must not write to stdout; it carries the RPC stream. The log package writes to stderr, which vigil forwards.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+// The magic cookie keeps plugins from being run by hand, where they would wait on stdin forever.
+const (
+	magicCookieKey   = "VIGIL_PLUGIN_MAGIC_COOKIE"
+	magicCookieValue = "vigil-plugin-v1"
+	serviceName      = "Plugin"
+)
+
+// Factory creates the provider served by a plugin.
+type Factory func(ctx context.Context, opts provider.Options) (provider.Provider, error)
+
+// InitArgs carries the provider options from vigil to the plugin.
+type InitArgs struct {
+	ErrorBudgetThreshold float64
+	Window               time.Duration
+}
+
+// SLO is the wire representation of model.SLO. The provider specific SLI never leaves the plugin process.
+type SLO struct {
+	Name        string
+	DisplayName string
+	Project     string
+	Goal        float64
+	Service     string
+	Labels      map[string]string
+	// ServiceLabels was added after the first protocol version, so older plugins leave it empty.
+	ServiceLabels map[string]string
+	ConsoleURL    string
+}
+
+// TimeSeriesArgs identifies the SLO whose error budget is requested.
+// Window overrides the window of InitArgs for this SLO when non-zero.
+type TimeSeriesArgs struct {
+	Name   string
+	Window time.Duration
+}
+
+// TimeSeriesReply is the result of GetErrorBudgetTimeSeries.
+// Weights are the total events behind each point, empty unless the provider implements provider.TrafficProvider.
+type TimeSeriesReply struct {
+	Good    string
+	Total   string
+	Points  []model.Point
+	Weights []float64
+}
+
+// Server exposes a provider over net/rpc. Its exported methods are the RPC surface.
+type Server struct {
+	factory  Factory
+	mu       sync.Mutex
+	provider provider.Provider
+	slos     map[string]*model.SLO
+}
+
+// Serve runs the plugin RPC server on stdin/stdout until vigil closes the connection.
+func Serve(factory Factory) {
+	if os.Getenv(magicCookieKey) != magicCookieValue {
+		fmt.Fprintln(os.Stderr, "This binary is a vigil provider plugin. Load it with: vigil --provider-plugin <path>")
+		os.Exit(1)
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, &Server{factory: factory, slos: make(map[string]*model.SLO)}); err != nil {
+		log.Fatalf("Failed to register plugin server: %v", err)
+	}
+	server.ServeCodec(jsonrpc.NewServerCodec(stdio{Reader: os.Stdin, WriteCloser: os.Stdout}))
+}
+
+// Init creates the provider and replies with its name.
+func (s *Server) Init(args InitArgs, reply *model.CloudProvider) error {
+	p, err := s.factory(context.Background(), provider.Options{
+		ErrorBudgetThreshold: args.ErrorBudgetThreshold,
+		Window:               args.Window,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.provider = p
+	s.mu.Unlock()
+
+	*reply = p.GetProvider()
+	return nil
+}
+
+// GetSLOs lists the provider SLOs and remembers them for later time series requests.
+func (s *Server) GetSLOs(_ struct{}, reply *[]SLO) error {
+	p, err := s.get()
+	if err != nil {
+		return err
+	}
+
+	slos, err := p.GetSLOs(context.Background())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, slo := range slos {
+		s.slos[slo.Name] = slo
+		*reply = append(*reply, SLO{
+			Name:          slo.Name,
+			DisplayName:   slo.DisplayName,
+			Project:       slo.Project,
+			Goal:          slo.Goal,
+			Service:       slo.Service,
+			Labels:        slo.Labels,
+			ServiceLabels: slo.ServiceLabels,
+			ConsoleURL:    slo.ConsoleURL,
+		})
+	}
+
+	return nil
+}
+
+// GetErrorBudgetTimeSeries fetches the error budget of an SLO previously returned by GetSLOs.
+func (s *Server) GetErrorBudgetTimeSeries(args TimeSeriesArgs, reply *TimeSeriesReply) error {
+	p, err := s.get()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	slo, ok := s.slos[args.Name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown SLO: %s", args.Name)
+	}
+
+	if args.Window != 0 {
+		override := *slo
+		override.Window = args.Window
+		slo = &override
+	}
+
+	var (
+		good, total string
+		points      []model.Point
+		weights     []float64
+	)
+	if tp, ok := p.(provider.TrafficProvider); ok {
+		good, total, points, weights, err = tp.GetWeightedErrorBudgetTimeSeries(context.Background(), slo)
+	} else {
+		good, total, points, err = p.GetErrorBudgetTimeSeries(context.Background(), slo)
+	}
+	if err != nil {
+		return err
+	}
+
+	*reply = TimeSeriesReply{Good: good, Total: total, Points: points, Weights: weights}
+	return nil
+}
+
+// Close closes the provider.
+func (s *Server) Close(_ struct{}, _ *struct{}) error {
+	s.mu.Lock()
+	p := s.provider
+	s.mu.Unlock()
+
+	if p == nil {
+		return nil
+	}
+	return p.Close()
+}
+
+func (s *Server) get() (provider.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider == nil {
+		return nil, errors.New("plugin is not initialized")
+	}
+	return s.provider, nil
+}
+
+// Client is a provider backed by a plugin process.
+type Client struct {
+	cmd  *exec.Cmd
+	rpc  *rpc.Client
+	name model.CloudProvider
+}
+
+// Open starts the plugin binary at path and initializes its provider with opts.
+func Open(ctx context.Context, path string, opts provider.Options) (*Client, error) {
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(), magicCookieKey+"="+magicCookieValue)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plugin stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+
+	c := &Client{
+		cmd: cmd,
+		rpc: jsonrpc.NewClient(stdio{Reader: stdout, WriteCloser: stdin}),
+	}
+
+	err = c.call(ctx, "Init", InitArgs{
+		ErrorBudgetThreshold: opts.ErrorBudgetThreshold,
+		Window:               opts.Window,
+	}, &c.name)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to initialize plugin %s: %w", path, err), c.shutdown())
+	}
+
+	return c, nil
+}
+
+// GetProvider returns the name reported by the plugin.
+func (c *Client) GetProvider() model.CloudProvider {
+	return c.name
+}
+
+// GetSLOs retrieves all SLOs from the plugin.
+func (c *Client) GetSLOs(ctx context.Context) ([]*model.SLO, error) {
+	var reply []SLO
+	if err := c.call(ctx, "GetSLOs", struct{}{}, &reply); err != nil {
+		return nil, err
+	}
+
+	slos := make([]*model.SLO, 0, len(reply))
+	for _, s := range reply {
+		slos = append(slos, &model.SLO{
+			Name:          s.Name,
+			DisplayName:   s.DisplayName,
+			Project:       s.Project,
+			Goal:          s.Goal,
+			Service:       s.Service,
+			Labels:        s.Labels,
+			ServiceLabels: s.ServiceLabels,
+			ConsoleURL:    s.ConsoleURL,
+		})
+	}
+
+	return slos, nil
+}
+
+// GetErrorBudgetTimeSeries fetches error budget time series data for a given SLO from the plugin.
+func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, error) {
+	good, total, points, _, err := c.GetWeightedErrorBudgetTimeSeries(ctx, slo)
+	return good, total, points, err
+}
+
+// GetWeightedErrorBudgetTimeSeries is GetErrorBudgetTimeSeries that also returns the traffic weights of the points,
+// nil when the plugin provider does not know them.
+func (c *Client) GetWeightedErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, []float64, error) {
+	var reply TimeSeriesReply
+	if err := c.call(ctx, "GetErrorBudgetTimeSeries", TimeSeriesArgs{Name: slo.Name, Window: slo.Window}, &reply); err != nil {
+		return "", "", nil, nil, err
+	}
+	return reply.Good, reply.Total, reply.Points, reply.Weights, nil
+}
+
+// Close closes the plugin provider and waits for the process to exit.
+func (c *Client) Close() error {
+	err := c.call(context.Background(), "Close", struct{}{}, &struct{}{})
+	return errors.Join(err, c.shutdown())
+}
+
+func (c *Client) shutdown() error {
+	if err := c.rpc.Close(); err != nil && !errors.Is(err, rpc.ErrShutdown) {
+		return fmt.Errorf("failed to close plugin connection: %w", err)
+	}
+	if err := c.cmd.Wait(); err != nil {
+		return fmt.Errorf("plugin exited with error: %w", err)
+	}
+	return nil
+}
+
+// call invokes an RPC method, giving up when ctx is done. Remote errors are returned as-is so
+// "no data points found" keeps putting the SLO in the NO_DATA category.
+func (c *Client) call(ctx context.Context, method string, args, reply interface{}) error {
+	call := c.rpc.Go(serviceName+"."+method, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-call.Done:
+		var serverErr rpc.ServerError
+		if errors.As(call.Error, &serverErr) {
+			return errors.New(string(serverErr))
+		}
+		return call.Error
+	}
+}
+
+// stdio joins a reader and a writer into the io.ReadWriteCloser net/rpc expects.
+type stdio struct {
+	io.Reader
+	io.WriteCloser
+}
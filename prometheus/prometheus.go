@@ -0,0 +1,329 @@
+// Package prometheus provides a Prometheus SLO client for Sloth-generated recording rules implementing the Vigil interface.
+package prometheus
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Sloth recording rule names.
+// See https://sloth.dev/introduction/ for the full list of generated series.
+const (
+	objectiveMetric       = "slo:objective:ratio"
+	errorRatioMetric      = "slo:sli_error:ratio_rate5m"
+	budgetRemainingMetric = "slo:period_error_budget_remaining:ratio"
+)
+
+// maxPoints keeps query_range requests below the default Prometheus limit of 11,000 points per series.
+const maxPoints = 10000
+
+// minStep is the smallest resolution used for query_range, matching Sloth's shortest rule interval.
+const minStep = 5 * time.Minute
+
+// Client is a Prometheus HTTP API client that discovers SLOs from Sloth recording rules.
+type Client struct {
+	httpClient           *http.Client
+	baseURL              *url.URL
+	ErrorBudgetThreshold float64
+	Window               time.Duration
+}
+
+// SlothSLO identifies an SLO generated by Sloth.
+type SlothSLO struct {
+	ID             string
+	Service        string
+	Name           string
+	ErrorRatioExpr string
+}
+
+type apiResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+}
+
+type queryData struct {
+	ResultType string   `json:"resultType"`
+	Result     []series `json:"result"`
+}
+
+type series struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+	Values [][]interface{}   `json:"values"`
+}
+
+type rulesData struct {
+	Groups []struct {
+		Rules []struct {
+			Name   string            `json:"name"`
+			Query  string            `json:"query"`
+			Labels map[string]string `json:"labels"`
+		} `json:"rules"`
+	} `json:"groups"`
+}
+
+// NewClient creates a new Prometheus client for the given server URL.
+func NewClient(_ context.Context, prometheusURL string, errorBudgetThreshold float64, window time.Duration) (*Client, error) {
+	baseURL, err := url.Parse(prometheusURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prometheus url: %w", err)
+	}
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("prometheus url must be absolute: %s", prometheusURL)
+	}
+
+	return &Client{
+		httpClient:           &http.Client{Timeout: time.Minute},
+		baseURL:              baseURL,
+		ErrorBudgetThreshold: errorBudgetThreshold,
+		Window:               window,
+	}, nil
+}
+
+// GetProvider returns the Prometheus provider identifier.
+func (c *Client) GetProvider() model.CloudProvider {
+	return model.CloudProviderPrometheus
+}
+
+// Close releases idle HTTP connections.
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// GetSLOs discovers SLOs from the Sloth objective recording rule.
+func (c *Client) GetSLOs(ctx context.Context) ([]*model.SLO, error) {
+	var data queryData
+	err := c.get(ctx, "/api/v1/query", url.Values{"query": {objectiveMetric}}, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", objectiveMetric, err)
+	}
+
+	exprs, err := c.errorRatioExprs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var slos []*model.SLO
+	for _, s := range data.Result {
+		id := s.Metric["sloth_id"]
+		if id == "" {
+			continue
+		}
+
+		goal, err := sampleValue(s.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse objective of %s: %w", id, err)
+		}
+
+		slos = append(slos, &model.SLO{
+			Name:        id,
+			DisplayName: id,
+			Goal:        goal,
+			Service:     s.Metric["sloth_service"],
+			Labels:      s.Metric,
+			ConsoleURL:  c.graphURL(fmt.Sprintf("%s{sloth_id=%q}", budgetRemainingMetric, id)),
+			SLI: SlothSLO{
+				ID:             id,
+				Service:        s.Metric["sloth_service"],
+				Name:           s.Metric["sloth_slo"],
+				ErrorRatioExpr: exprs[id],
+			},
+		})
+	}
+
+	return slos, nil
+}
+
+// GetErrorBudgetTimeSeries fetches the remaining error budget ratio over the window for a given SLO.
+// The good query is the SLI error ratio expression recorded by Sloth.
+func (c *Client) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, error) {
+	sloth, ok := slo.SLI.(SlothSLO)
+	if !ok {
+		return "", "", nil, fmt.Errorf("SLI is not of expected type: %T", slo.SLI)
+	}
+
+	good := sloth.ErrorRatioExpr
+	if good == "" {
+		good = fmt.Sprintf("%s{sloth_id=%q}", errorRatioMetric, sloth.ID)
+	}
+	total := fmt.Sprintf("%s{sloth_id=%q}", budgetRemainingMetric, sloth.ID)
+
+	window := cmp.Or(slo.Window, c.Window)
+	end := time.Now().UTC()
+	start := end.Add(window * -1)
+
+	samples, err := c.QueryRange(ctx, total, start, end, Step(window))
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var points []model.Point
+	for _, ts := range SortedTimestamps(samples) {
+		points = append(points, model.Point{Time: time.Unix(ts, 0).UTC(), Value: samples[ts]})
+	}
+
+	if len(points) == 0 {
+		return good, total, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
+	}
+
+	return good, total, points, nil
+}
+
+// Step returns the query_range resolution used for a window, staying under the Prometheus points-per-series limit.
+func Step(window time.Duration) time.Duration {
+	step := window / maxPoints
+	if step < minStep {
+		step = minStep
+	}
+	return step
+}
+
+// QueryRange evaluates a PromQL expression over a time range and returns the samples keyed by unix timestamp.
+// Values of multiple series at the same timestamp are summed; NaN samples are dropped.
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (map[int64]float64, error) {
+	var data queryData
+	err := c.get(ctx, "/api/v1/query_range", url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query range of %s: %w", query, err)
+	}
+
+	samples := make(map[int64]float64)
+	for _, s := range data.Result {
+		for _, v := range s.Values {
+			ts, value, err := samplePair(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse sample of %s: %w", query, err)
+			}
+			if math.IsNaN(value) {
+				continue
+			}
+			samples[ts] += value
+		}
+	}
+
+	return samples, nil
+}
+
+// SortedTimestamps returns the timestamps of samples in ascending order.
+func SortedTimestamps(samples map[int64]float64) []int64 {
+	timestamps := make([]int64, 0, len(samples))
+	for ts := range samples {
+		timestamps = append(timestamps, ts)
+	}
+	slices.Sort(timestamps)
+	return timestamps
+}
+
+// graphURL links to the Prometheus expression browser graphing query over the window.
+func (c *Client) graphURL(query string) string {
+	u := c.baseURL.JoinPath("/graph")
+	u.RawQuery = url.Values{
+		"g0.expr":        {query},
+		"g0.tab":         {"0"},
+		"g0.range_input": {fmt.Sprintf("%dh", int(c.Window.Hours()))},
+	}.Encode()
+	return u.String()
+}
+
+// errorRatioExprs returns the PromQL expressions Sloth recorded as SLI error ratios, keyed by sloth_id.
+func (c *Client) errorRatioExprs(ctx context.Context) (map[string]string, error) {
+	var data rulesData
+	err := c.get(ctx, "/api/v1/rules", url.Values{"type": {"record"}}, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recording rules: %w", err)
+	}
+
+	exprs := make(map[string]string)
+	for _, g := range data.Groups {
+		for _, r := range g.Rules {
+			if r.Name == errorRatioMetric && r.Labels["sloth_id"] != "" {
+				exprs[r.Labels["sloth_id"]] = strings.TrimSpace(r.Query)
+			}
+		}
+	}
+
+	return exprs, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			log.Printf("Failed to close response body: %v", closeErr)
+		}
+	}()
+
+	var body apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode response (status %d): %w", resp.StatusCode, err)
+	}
+	if body.Status != "success" {
+		return fmt.Errorf("prometheus api error (status %d): %s: %s", resp.StatusCode, body.ErrorType, body.Error)
+	}
+
+	if err := json.Unmarshal(body.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response data: %w", err)
+	}
+
+	return nil
+}
+
+// sampleValue parses the value of a Prometheus [timestamp, "value"] sample pair.
+func sampleValue(sample []interface{}) (float64, error) {
+	_, value, err := samplePair(sample)
+	return value, err
+}
+
+// samplePair parses a Prometheus [timestamp, "value"] sample pair.
+func samplePair(sample []interface{}) (int64, float64, error) {
+	if len(sample) != 2 {
+		return 0, 0, errors.New("malformed sample")
+	}
+	ts, ok := sample[0].(float64)
+	if !ok {
+		return 0, 0, fmt.Errorf("sample timestamp is not a number: %T", sample[0])
+	}
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, 0, fmt.Errorf("sample value is not a string: %T", sample[1])
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int64(ts), value, nil
+}
@@ -0,0 +1,37 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+func init() {
+	provider.Register(model.CloudProviderPrometheus, &factory{})
+}
+
+type factory struct {
+	url string
+}
+
+func (f *factory) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&f.url, "prometheus-url", "", "prometheus server url with sloth recording rules. e.g. http://localhost:9090")
+}
+
+func (f *factory) Validate() error {
+	if f.url == "" {
+		return errors.New("--prometheus-url is required for Prometheus")
+	}
+	return nil
+}
+
+func (f *factory) New(ctx context.Context, opts provider.Options) (provider.Provider, error) {
+	return NewClient(ctx, f.url, opts.ErrorBudgetThreshold, opts.Window)
+}
+
+func (f *factory) Target() string {
+	return f.url
+}
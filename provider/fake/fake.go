@@ -0,0 +1,197 @@
+// Package fake provides an in-memory provider serving the SLOs and error budget series it is given, so the
+// orchestration of vigil.Run, the analysis and the reports can be exercised without calling any provider API.
+//
+//	p := fake.New().
+//		Add(fake.NewSLO("checkout-availability", 0.999), fake.Series{Points: fake.Points(time.Now(), time.Hour, fake.Constant(0.9, 720)...)}).
+//		Add(fake.NewSLO("search-latency", 0.99), fake.Series{Points: fake.Points(time.Now(), time.Hour, fake.Linear(0.5, -0.2, 720)...)})
+//	result, err := vigil.Run(ctx, p, vigil.Options{Window: 720 * time.Hour})
+package fake
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Name is the provider name of the SLOs of a Provider without a CloudProvider.
+const Name model.CloudProvider = "fake"
+
+// Series is the error budget of an SLO served by a Provider.
+type Series struct {
+	// Good and Total are the queries reported for the SLO, derived from its name when empty.
+	Good, Total string
+	// Points is the remaining error budget fraction, oldest point first. An SLO without points fails with "no data
+	// points found", as real providers do.
+	Points []model.Point
+	// Weights are the total events behind each point, nil when the traffic is unknown. It has one weight per point.
+	Weights []float64
+	// Err is returned instead of the series when set, e.g. to fail the SLO as an API error would.
+	Err error
+}
+
+// Provider is an in-memory provider. It is safe for concurrent use, as vigil.Run fetches several SLOs at once.
+type Provider struct {
+	// CloudProvider is returned by GetProvider, Name when empty. Setting it to a real provider exercises the code
+	// specific to that provider, e.g. the console links of GCP.
+	CloudProvider model.CloudProvider
+	// Window keeps the points within this duration of the last point of each series when non-zero, as the window a
+	// real provider is created with. The Window of an SLO overrides it.
+	Window time.Duration
+	// ListErr is returned by GetSLOs along with the SLOs, e.g. a *provider.PartialError.
+	ListErr error
+
+	mu     sync.Mutex
+	slos   []*model.SLO
+	series map[string]Series
+	calls  map[string]int
+	closed bool
+}
+
+// New returns a Provider without SLOs.
+func New() *Provider {
+	return &Provider{
+		series: make(map[string]Series),
+		calls:  make(map[string]int),
+	}
+}
+
+// Add serves slo with the error budget of s, replacing an SLO of the same name. It returns p for chaining.
+func (p *Provider) Add(slo *model.SLO, s Series) *Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.series[slo.Name]; ok {
+		for i, existing := range p.slos {
+			if existing.Name == slo.Name {
+				p.slos[i] = slo
+			}
+		}
+	} else {
+		p.slos = append(p.slos, slo)
+	}
+	p.series[slo.Name] = s
+	return p
+}
+
+// GetProvider returns CloudProvider, or Name when it is empty.
+func (p *Provider) GetProvider() model.CloudProvider {
+	return cmp.Or(p.CloudProvider, Name)
+}
+
+// GetSLOs returns copies of the SLOs in the order they were added, so the callers changing them do not change the
+// SLOs of the next scan.
+func (p *Provider) GetSLOs(ctx context.Context) ([]*model.SLO, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	slos := make([]*model.SLO, len(p.slos))
+	for i, slo := range p.slos {
+		s := *slo
+		slos[i] = &s
+	}
+	return slos, p.ListErr
+}
+
+// GetErrorBudgetTimeSeries returns the series of slo within its window.
+func (p *Provider) GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, error) {
+	good, total, points, _, err := p.GetWeightedErrorBudgetTimeSeries(ctx, slo)
+	return good, total, points, err
+}
+
+// GetWeightedErrorBudgetTimeSeries returns the series of slo within its window with the weights of its points.
+func (p *Provider) GetWeightedErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (string, string, []model.Point, []float64, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", nil, nil, err
+	}
+
+	p.mu.Lock()
+	s, ok := p.series[slo.Name]
+	p.calls[slo.Name]++
+	p.mu.Unlock()
+	if !ok {
+		return "", "", nil, nil, fmt.Errorf("unknown SLO: %s", slo.Name)
+	}
+
+	good := cmp.Or(s.Good, fmt.Sprintf("good{slo=%q}", slo.Name))
+	total := cmp.Or(s.Total, fmt.Sprintf("total{slo=%q}", slo.Name))
+	if s.Err != nil {
+		return good, total, nil, nil, s.Err
+	}
+
+	points, weights := within(s.Points, s.Weights, cmp.Or(slo.Window, p.Window))
+	if len(points) == 0 {
+		return good, total, nil, nil, fmt.Errorf("no data points found for SLO: %s", slo.DisplayName)
+	}
+	return good, total, points, weights, nil
+}
+
+// UpdateGoal sets the goal of the SLO, failing when it is no longer slo.Goal, as vigil apply expects.
+func (p *Provider) UpdateGoal(_ context.Context, slo *model.SLO, goal float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, s := range p.slos {
+		if s.Name != slo.Name {
+			continue
+		}
+		if s.Goal != slo.Goal {
+			return fmt.Errorf("the goal of %s changed to %s%% since it was scanned", slo.Name, strconv.FormatFloat(s.Goal*100, 'f', -1, 64))
+		}
+		updated := *s
+		updated.Goal = goal
+		p.slos[i] = &updated
+		return nil
+	}
+	return fmt.Errorf("unknown SLO: %s", slo.Name)
+}
+
+// Calls returns the number of times the series of the SLO named name was fetched.
+func (p *Provider) Calls(name string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.calls[name]
+}
+
+// Closed reports whether Close was called.
+func (p *Provider) Closed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.closed
+}
+
+// Close marks the provider closed.
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	return nil
+}
+
+// within returns the points no older than window before the last one, with their weights. A zero window keeps every
+// point.
+func within(points []model.Point, weights []float64, window time.Duration) ([]model.Point, []float64) {
+	if window <= 0 || len(points) == 0 {
+		return points, weights
+	}
+	start := points[len(points)-1].Time.Add(-window)
+	i := 0
+	for i < len(points) && points[i].Time.Before(start) {
+		i++
+	}
+	if len(weights) == len(points) {
+		weights = weights[i:]
+	}
+	return points[i:], weights
+}
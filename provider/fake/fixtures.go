@@ -0,0 +1,60 @@
+package fake
+
+import (
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// NewSLO returns an SLO named name with goal, displayed under its name. The other fields can be set on the result,
+// e.g. Labels to give it an owner.
+func NewSLO(name string, goal float64) *model.SLO {
+	return &model.SLO{
+		Name:        name,
+		DisplayName: name,
+		Project:     "fake-project",
+		Goal:        goal,
+	}
+}
+
+// Points returns a series of values step apart, oldest first, whose last point is at end. Analyses relative to the
+// current time, such as the exhaustion forecast, expect end to be about now.
+func Points(end time.Time, step time.Duration, values ...float64) []model.Point {
+	points := make([]model.Point, len(values))
+	for i, v := range values {
+		points[i] = model.Point{Time: end.Add(-time.Duration(len(values)-1-i) * step), Value: v}
+	}
+	return points
+}
+
+// Constant returns n times value, e.g. the budget of an SLO that never spends it.
+func Constant(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// Linear returns n values going from from to to in equal steps, e.g. the budget of an SLO steadily spending it.
+func Linear(from, to float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		if n == 1 {
+			values[i] = from
+			continue
+		}
+		values[i] = from + (to-from)*float64(i)/float64(n-1)
+	}
+	return values
+}
+
+// Dip returns n values at 1 except for the width values from start, which are at value, e.g. the budget of an SLO
+// through a single incident.
+func Dip(n, start, width int, value float64) []float64 {
+	values := Constant(1, n)
+	for i := max(start, 0); i < start+width && i < n; i++ {
+		values[i] = value
+	}
+	return values
+}
@@ -0,0 +1,207 @@
+// Package provider defines the SLO provider contract and a registry that main uses to select providers by name.
+//
+// Providers register themselves from an init function, so compiling one in is a matter of importing its package:
+//
+//	import _ "example.com/vigil-provider-internal"
+package provider
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/replay"
+)
+
+// Provider is implemented by every SLO backend.
+// GetErrorBudgetTimeSeries returns the remaining error budget fraction over the window with the time of each point,
+// oldest point first. An SLO without points fails with an error containing "no data points found", returned along
+// with its queries so the SLO is still reported, in the NO_DATA category.
+type Provider interface {
+	GetProvider() model.CloudProvider
+	GetSLOs(ctx context.Context) ([]*model.SLO, error)
+	GetErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (good string, total string, points []model.Point, err error)
+	Close() error
+}
+
+// PartialError is returned by GetSLOs together with the SLOs it could list when some could not be, e.g. the SLOs of a
+// service that kept failing. Skipped describes what was left out; callers report it as warnings and carry on.
+type PartialError struct {
+	Skipped []string
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d skipped: %s", len(e.Skipped), strings.Join(e.Skipped, "; "))
+}
+
+// CallCounter is optionally implemented by providers that need more than one API call to fetch the error budget
+// time series of an SLO. --dry-run assumes a single call for the other providers.
+type CallCounter interface {
+	TimeSeriesCalls(slo *model.SLO) int
+}
+
+// ServiceLister is optionally implemented by providers that know the services SLOs can be defined for, so vigil
+// coverage can report the ones without any. Service names match model.SLO.Service.
+type ServiceLister interface {
+	GetServices(ctx context.Context) ([]*model.Service, error)
+}
+
+// TrafficProvider is optionally implemented by providers that see the total events of an SLI along with its error
+// budget. GetWeightedErrorBudgetTimeSeries is GetErrorBudgetTimeSeries that also returns the total events behind each
+// point, so the average budget is weighted by traffic and quiet periods do not count as much as busy ones. weights is
+// nil when the provider does not know the total events of the SLO.
+type TrafficProvider interface {
+	GetWeightedErrorBudgetTimeSeries(ctx context.Context, slo *model.SLO) (good string, total string, points []model.Point, weights []float64, err error)
+}
+
+// StatusProvider is optionally implemented by providers that compute the status of an SLO beyond its error budget
+// series, such as its compliance. GetSLOStatus returns nil when the provider is not configured to fetch it.
+type StatusProvider interface {
+	GetSLOStatus(ctx context.Context, slo *model.SLO) (*model.SLOStatus, error)
+}
+
+// AlertChecker is optionally implemented by providers that know the alert policies of an SLO. Alerted reports whether
+// an alert fires on the burn rate of slo.
+type AlertChecker interface {
+	Alerted(ctx context.Context, slo *model.SLO) (bool, error)
+}
+
+// DowntimeReporter is optionally implemented by providers that know when the SLIs of SLOs were in a scheduled
+// downtime. BadTimeInDowntime returns the share of the bad time of the error budget series last fetched for slo that
+// fell inside one; ok is false when the provider does not know it for slo.
+type DowntimeReporter interface {
+	BadTimeInDowntime(ctx context.Context, slo *model.SLO) (share float64, ok bool, err error)
+}
+
+// MonitorHealthChecker is optionally implemented by providers whose SLOs are computed from monitors. MonitorIssues
+// describes the monitors of slo that keep it from seeing bad time, such as muted ones, so its budget looks perfect.
+type MonitorHealthChecker interface {
+	MonitorIssues(ctx context.Context, slo *model.SLO) ([]string, error)
+}
+
+// GoalExporter is optionally implemented by providers that can write SLOs with a new goal in a form their users apply,
+// such as Terraform. ExportGoals writes the changes into dir and returns the paths of the files it wrote.
+type GoalExporter interface {
+	ExportGoals(ctx context.Context, dir string, changes []model.GoalChange) ([]string, error)
+}
+
+// GoalUpdater is optionally implemented by providers that can change the goal of an SLO in place, for vigil apply.
+// UpdateGoal fails without changing anything when the goal of the SLO is no longer slo.Goal.
+type GoalUpdater interface {
+	UpdateGoal(ctx context.Context, slo *model.SLO, goal float64) error
+}
+
+// Options holds the settings shared by every provider.
+type Options struct {
+	ErrorBudgetThreshold float64
+	Window               time.Duration
+	// Cassette records the API calls of the provider, or answers them from a recording, for --record and --replay.
+	// nil for the live API. Providers that do not support recording ignore it.
+	Cassette *replay.Cassette
+}
+
+// Factory creates a Provider and owns its provider specific flags.
+type Factory interface {
+	// RegisterFlags adds the provider specific flags to fs.
+	RegisterFlags(fs *flag.FlagSet)
+	// Validate checks the provider specific flags and environment before any client is created.
+	Validate() error
+	// New creates the provider.
+	New(ctx context.Context, opts Options) (Provider, error)
+	// Target describes what the provider scans, e.g. a project ID, for the report title.
+	Target() string
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[model.CloudProvider]Factory)
+)
+
+// Register makes a provider available under name. It panics if name is registered twice.
+func Register(name model.CloudProvider, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("provider: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("provider: Register called twice for provider " + name)
+	}
+	factories[name] = factory
+}
+
+// Names returns the registered provider names in sorted order.
+func Names() []model.CloudProvider {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	return namesLocked()
+}
+
+// RegisterFlags adds the flags of every registered provider to fs.
+func RegisterFlags(fs *flag.FlagSet) {
+	for _, name := range Names() {
+		mustGet(name).RegisterFlags(fs)
+	}
+}
+
+// Validate checks the flags of the named provider.
+func Validate(name model.CloudProvider) error {
+	factory, err := get(name)
+	if err != nil {
+		return err
+	}
+	return factory.Validate()
+}
+
+// New creates the named provider.
+func New(ctx context.Context, name model.CloudProvider, opts Options) (Provider, error) {
+	factory, err := get(name)
+	if err != nil {
+		return nil, err
+	}
+	return factory.New(ctx, opts)
+}
+
+// Target describes what the named provider scans.
+func Target(name model.CloudProvider) string {
+	factory, err := get(name)
+	if err != nil {
+		return string(name)
+	}
+	return factory.Target()
+}
+
+func get(name model.CloudProvider) (Factory, error) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("not supported cloud provider: %s. use one of %v", name, namesLocked())
+	}
+	return factory, nil
+}
+
+func mustGet(name model.CloudProvider) Factory {
+	factory, err := get(name)
+	if err != nil {
+		panic(err)
+	}
+	return factory
+}
+
+func namesLocked() []model.CloudProvider {
+	names := make([]model.CloudProvider, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// defaultPushgatewayGroup is the grouping key the metrics are pushed under when --pushgateway has no
+// /metrics/job/... path.
+const defaultPushgatewayGroup = "/metrics/job/vigil"
+
+// sloMetric is a per-SLO gauge pushed to the Pushgateway.
+type sloMetric struct {
+	name  string
+	help  string
+	value func(*model.SLOData) float64
+}
+
+var sloMetrics = []sloMetric{
+	{"vigil_slo_flagged", "Whether the SLO is flagged, 1 or 0.", func(v *model.SLOData) float64 {
+		if v.Flag {
+			return 1
+		}
+		return 0
+	}},
+	{"vigil_slo_goal_ratio", "Goal of the SLO, 0 ~ 1.", func(v *model.SLOData) float64 { return v.SLO }},
+	{"vigil_slo_min_budget_ratio", "Lowest remaining error budget over the window, 1 is untouched and negative is overspent.", func(v *model.SLOData) float64 { return v.MinBudget }},
+	{"vigil_slo_avg_budget_ratio", "Average remaining error budget over the window.", func(v *model.SLOData) float64 { return v.AvgBudget }},
+	{"vigil_slo_negative_budget_ratio", "Fraction of the window the error budget was negative.", func(v *model.SLOData) float64 { return v.NegativeFraction }},
+	{"vigil_slo_peak_burn_rate", "Peak multiwindow burn rate over the window.", func(v *model.SLOData) float64 { return v.PeakBurnRate }},
+	{"vigil_slo_health_score", "Health score of the SLO, 0 ~ 100.", func(v *model.SLOData) float64 { return v.HealthScore }},
+	{"vigil_slo_budget_consumed_ratio", "Share of the error budget consumed over the window.", func(v *model.SLOData) float64 { return v.BudgetConsumed }},
+}
+
+// pushMetrics replaces the metrics of the --pushgateway group with the stats of every SLO of the run, so deleted
+// SLOs disappear from it and dashboards can track the flagged SLOs over time.
+func pushMetrics(ctx context.Context, gateway string, startedAt time.Time, data map[string]*model.SLOData) {
+	u, err := pushgatewayURL(gateway)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+
+	var buf bytes.Buffer
+	writeMetrics(&buf, startedAt, data)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, &buf)
+	if err != nil {
+		log.Panicf("Failed to push metrics: %v", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := (&http.Client{Timeout: time.Minute}).Do(req)
+	if err != nil {
+		log.Panicf("Failed to push metrics to %s: %v", gateway, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		log.Panicf("Failed to push metrics to %s: %s: %s", gateway, resp.Status, bytes.TrimSpace(msg))
+	}
+	infof("Pushed the metrics of %d SLOs to %s", len(data), gateway)
+}
+
+// pushgatewayURL returns the URL of the group to push to. A --pushgateway without a /metrics/job/ path pushes to the
+// vigil job.
+func pushgatewayURL(gateway string) (string, error) {
+	u, err := url.Parse(gateway)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid --pushgateway %s: use http(s)://host:9091, optionally followed by /metrics/job/<job>", gateway)
+	}
+	if !strings.Contains(u.Path, "/metrics/job/") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + defaultPushgatewayGroup
+	}
+	return u.String(), nil
+}
+
+// writeMetrics writes the per-SLO gauges and the run totals in the Prometheus text exposition format.
+func writeMetrics(w io.Writer, startedAt time.Time, data map[string]*model.SLOData) {
+	slos := make([]*model.SLOData, 0, len(data))
+	flagged := 0
+	for _, v := range data {
+		slos = append(slos, v)
+		if v.Flag {
+			flagged++
+		}
+	}
+	sortSLOs(slos)
+
+	for _, m := range sloMetrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, v := range slos {
+			fmt.Fprintf(w, "%s{%s} %g\n", m.name, metricLabels(v), m.value(v))
+		}
+	}
+	// The category is the label of its own gauge, so the series of the stats do not break when it changes.
+	fmt.Fprintf(w, "# HELP vigil_slo_category Category of the SLO, LAX, BURNING, HEALTHY or NO_DATA, as a label.\n# TYPE vigil_slo_category gauge\n")
+	for _, v := range slos {
+		fmt.Fprintf(w, "vigil_slo_category{%s,category=\"%s\"} 1\n", metricLabels(v), v.Category)
+	}
+	fmt.Fprintf(w, "# HELP vigil_slos Number of SLOs scanned by the last run.\n# TYPE vigil_slos gauge\nvigil_slos %d\n", len(data))
+	fmt.Fprintf(w, "# HELP vigil_slos_flagged Number of SLOs flagged by the last run.\n# TYPE vigil_slos_flagged gauge\nvigil_slos_flagged %d\n", flagged)
+	fmt.Fprintf(w, "# HELP vigil_last_run_timestamp_seconds Time the last run started.\n# TYPE vigil_last_run_timestamp_seconds gauge\nvigil_last_run_timestamp_seconds %d\n", startedAt.Unix())
+}
+
+// metricLabels are the labels identifying an SLO in its metrics.
+func metricLabels(v *model.SLOData) string {
+	labels := [][2]string{
+		{"slo", v.DisplayName},
+		{"key", v.Key},
+		{"provider", string(v.Provider)},
+		{"project", v.Project},
+		{"team", v.Team},
+		{"window", v.Window},
+	}
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, l[0]+`="`+labelValueEscaper.Replace(l[1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+// labelValueEscaper escapes the backslashes, double quotes and newlines of label values, the only escapes of the text
+// exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/provider"
+)
+
+// exportGoals writes the recommended goals of the SLOs into dir, in the format of each provider that implements
+// provider.GoalExporter.
+func exportGoals(ctx context.Context, dir string, slos []*model.SLO, sloClients map[*model.SLO]Vigil, sloData map[string]*model.SLOData) {
+	var clients []Vigil
+	changes := make(map[Vigil][]model.GoalChange)
+	for _, slo := range slos {
+		v, ok := sloData[slo.Name]
+		if !ok || v.TargetSLO == 0 {
+			continue
+		}
+		client := sloClients[slo]
+		if _, ok := changes[client]; !ok {
+			clients = append(clients, client)
+		}
+		changes[client] = append(changes[client], model.GoalChange{SLO: slo, Goal: v.TargetSLO, Category: v.Category})
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Panicf("Failed to create %s: %v", dir, err)
+	}
+	for _, client := range clients {
+		exporter, ok := client.(provider.GoalExporter)
+		if !ok {
+			infof("%s cannot export goals: %d recommended goals are only in the report", client.GetProvider(), len(changes[client]))
+			continue
+		}
+		paths, err := exporter.ExportGoals(ctx, dir, changes[client])
+		if err != nil {
+			log.Panicf("Failed to export the %s goals: %v", client.GetProvider(), err)
+		}
+		slices.Sort(paths)
+		infof("Recommended %s goals written to %s", client.GetProvider(), strings.Join(paths, ", "))
+	}
+}
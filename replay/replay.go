@@ -0,0 +1,328 @@
+// Package replay records the API calls of providers into a cassette file and answers them from it later, so the
+// parsing of provider responses can be checked against real responses without credentials or network access.
+//
+// A scan asks for the window up to now, so requests are not matched on their time range as is: the times of a request
+// are taken relative to when it was made, and a call gets the recorded response of the same request whose relative
+// times are the closest. A cassette recorded today replays the same responses tomorrow, and the chunks of a long
+// history, requested at once, each get their own.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeFields are the query parameters and JSON fields of requests that can hold the bounds of their time range.
+var timeFields = []string{"from", "to", "from_ts", "to_ts", "start", "end", "startTime", "endTime"}
+
+// Interaction is a recorded API call. Method is the HTTP method with URL, or the full method name of a gRPC call.
+// Request headers are never recorded, so API keys do not end up in cassettes.
+type Interaction struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url,omitempty"`
+	RequestBody string            `json:"requestBody,omitempty"`
+	Status      int               `json:"status,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	// Code and Error are the status of a failed gRPC call.
+	Code  int    `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Time is when the call was made, what the times of the request are relative to.
+	Time time.Time `json:"time"`
+
+	request request
+}
+
+// request is what a call is matched on: its method, URL and body without their times, and the times relative to the
+// call.
+type request struct {
+	key     string
+	offsets []float64
+}
+
+// Cassette holds the interactions of a scan, either being recorded or being replayed. It is safe for concurrent use.
+type Cassette struct {
+	path      string
+	recording bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+type file struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Record returns an empty cassette whose interactions Save writes to path.
+func Record(path string) *Cassette {
+	return &Cassette{path: path, recording: true}
+}
+
+// Load reads a cassette written by Save to replay it.
+func Load(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var f file
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	c := &Cassette{path: path, interactions: f.Interactions, used: make([]bool, len(f.Interactions))}
+	for i := range c.interactions {
+		in := &c.interactions[i]
+		in.request = newRequest(in.Method, in.URL, in.RequestBody, in.Time)
+	}
+	return c, nil
+}
+
+// Recording reports whether the cassette records calls rather than replaying them.
+func (c *Cassette) Recording() bool {
+	return c.recording
+}
+
+// Add records an interaction, made now when its Time is not set.
+func (c *Cassette) Add(i Interaction) {
+	if i.Time.IsZero() {
+		i.Time = time.Now().UTC()
+	}
+	i.request = newRequest(i.Method, i.URL, i.RequestBody, i.Time)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, i)
+	c.used = append(c.used, false)
+}
+
+// Find returns the recorded interaction of the same request made now with the closest relative times, preferring the
+// ones not replayed yet, so retries and repeated calls get an answer too.
+func (c *Cassette) Find(method, rawURL, body string) (Interaction, error) {
+	r := newRequest(method, rawURL, body, time.Now())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	best, bestUsed := -1, true
+	bestDistance := math.Inf(1)
+	for i, in := range c.interactions {
+		if in.request.key != r.key {
+			continue
+		}
+		d := distance(in.request.offsets, r.offsets)
+		// An interaction not replayed yet beats any replayed one.
+		if (bestUsed && !c.used[i]) || (bestUsed == c.used[i] && d < bestDistance) {
+			best, bestUsed, bestDistance = i, c.used[i], d
+		}
+	}
+	if best < 0 {
+		return Interaction{}, fmt.Errorf("no recorded response in %s for %s %s", c.path, method, rawURL)
+	}
+	c.used[best] = true
+	return c.interactions[best], nil
+}
+
+// Save writes the recorded interactions to the path of the cassette. It does nothing when replaying.
+func (c *Cassette) Save() error {
+	if !c.recording {
+		return nil
+	}
+
+	// URLs and bodies are kept readable: & and < are not escaped.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	c.mu.Lock()
+	err := enc.Encode(file{Interactions: c.interactions})
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.WriteFile(c.path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Transport returns an http.RoundTripper recording the calls made through base, or answering them from the cassette
+// without calling base when replaying.
+func (c *Cassette) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{cassette: c, base: base}
+}
+
+type transport struct {
+	cassette *Cassette
+	base     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if !t.cassette.recording {
+		in, err := t.cassette.Find(req.Method, req.URL.String(), string(body))
+		if err != nil {
+			return nil, err
+		}
+		header := make(http.Header)
+		for k, v := range in.Header {
+			header.Set(k, v)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(in.Body)),
+			ContentLength: int64(len(in.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	at := time.Now().UTC()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: string(body),
+		Status:      resp.StatusCode,
+		Body:        string(respBody),
+		Time:        at,
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		in.Header = map[string]string{"Content-Type": ct}
+	}
+	t.cassette.Add(in)
+	return resp, nil
+}
+
+// newRequest splits a call made at at into the request without its times, and the seconds between at and each of
+// them, in the order of the query parameters and of the sorted fields of a JSON body.
+func newRequest(method, rawURL, body string, at time.Time) request {
+	var offsets []float64
+	if u, err := url.Parse(rawURL); err == nil {
+		q := u.Query()
+		names := make([]string, 0, len(q))
+		for name := range q {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if !slices.Contains(timeFields, name) || len(q[name]) != 1 {
+				continue
+			}
+			if t, ok := parseTime(q.Get(name)); ok {
+				offsets = append(offsets, at.Sub(t).Seconds())
+				q.Del(name)
+			}
+		}
+		u.RawQuery = q.Encode()
+		rawURL = u.String()
+	}
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err == nil {
+		v = withoutTimes(v, at, &offsets)
+		if b, err := json.Marshal(v); err == nil {
+			body = string(b)
+		}
+	}
+	return request{key: method + " " + rawURL + "\n" + body, offsets: offsets}
+}
+
+// withoutTimes removes the time fields of every object of v, appending their offsets from at in the order of their
+// sorted keys. encoding/json sorts the keys of maps too, so the result marshals the same whatever order the fields
+// were sent in.
+func withoutTimes(v interface{}, at time.Time, offsets *[]float64) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			if slices.Contains(timeFields, k) {
+				if t, ok := parseTime(v[k]); ok {
+					*offsets = append(*offsets, at.Sub(t).Seconds())
+					delete(v, k)
+					continue
+				}
+			}
+			v[k] = withoutTimes(v[k], at, offsets)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = withoutTimes(v[i], at, offsets)
+		}
+	}
+	return v
+}
+
+// parseTime parses a time given as Unix seconds or milliseconds, in a number or a string, or in RFC 3339.
+func parseTime(v interface{}) (time.Time, bool) {
+	var n float64
+	switch v := v.(type) {
+	case float64:
+		n = v
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			return t, err == nil
+		}
+		n = f
+	default:
+		return time.Time{}, false
+	}
+	// 1e11 seconds is in the year 5138, 1e11 milliseconds in 1973.
+	if n > 1e11 {
+		return time.UnixMilli(int64(n)), true
+	}
+	return time.Unix(int64(n), 0), true
+}
+
+// distance is how far apart the relative times of two requests are, in seconds.
+func distance(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return math.Inf(1)
+	}
+	var d float64
+	for i := range a {
+		d += math.Abs(a[i] - b[i])
+	}
+	return d
+}
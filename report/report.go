@@ -0,0 +1,359 @@
+// Package report describes the columns of the tabular reports, so they can be customized without code changes.
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+)
+
+// Spec is an ordered list of report columns and sheet options.
+type Spec struct {
+	Columns []*Column `yaml:"columns"`
+	// FreezeHeader keeps the header row visible while scrolling.
+	FreezeHeader bool `yaml:"freezeHeader"`
+	// AutoFilter adds filter buttons to the header row.
+	AutoFilter bool `yaml:"autoFilter"`
+}
+
+// Column describes a single report column.
+// Its value is either a built-in Field or a text/template Template evaluated against model.SLOData;
+// a column with neither is left empty for reviewers to fill in.
+type Column struct {
+	Header    string  `yaml:"header"`
+	Field     string  `yaml:"field"`
+	Template  string  `yaml:"template"`
+	NumFmt    string  `yaml:"numFmt"`
+	Width     float64 `yaml:"width"`
+	Highlight bool    `yaml:"highlight"`
+
+	tmpl *template.Template
+}
+
+// Number formats of the percentage fields. Goals get a third decimal for objectives such as 99.95%, and changes a
+// sign. Burn rates are shown as multiples, e.g. 14.4x, dates without the time, hours with one decimal,
+// minutes, confidences and health scores without.
+const (
+	PercentFormat     = "0.00%"
+	GoalPercentFormat = "0.00#%"
+	ChangeFormat      = "+0.00%;-0.00%;0.00%"
+	BurnRateFormat    = "0.0\"x\""
+	DateFormat        = "yyyy-mm-dd"
+	DateTimeFormat    = "yyyy-mm-dd hh:mm"
+	HoursFormat       = "0.0"
+	MinutesFormat     = "#,##0"
+	ConfidenceFormat  = "0%"
+	ScoreFormat       = "0"
+)
+
+// Built-in fields. Budgets and goals are ratios, 0 ~ 1, meant to be shown with a percent NumFmt.
+// Burn rates are multiples of the rate that exhausts the budget over the window, meant for BurnRateFormat.
+// The consoleUrl field is rendered as a hyperlink by the Excel report.
+var fields = map[string]func(*model.SLOData) interface{}{
+	"key":                      func(v *model.SLOData) interface{} { return v.Key },
+	"name":                     func(v *model.SLOData) interface{} { return v.DisplayName },
+	"project":                  func(v *model.SLOData) interface{} { return v.Project },
+	"team":                     func(v *model.SLOData) interface{} { return v.Team },
+	"service":                  func(v *model.SLOData) interface{} { return v.Service },
+	"serviceType":              func(v *model.SLOData) interface{} { return v.ServiceType },
+	"serviceResource":          func(v *model.SLOData) interface{} { return formatLabels(v.ServiceResource) },
+	"provider":                 func(v *model.SLOData) interface{} { return string(v.Provider) },
+	"flag":                     func(v *model.SLOData) interface{} { return v.Flag },
+	"category":                 func(v *model.SLOData) interface{} { return string(v.Category) },
+	"slo":                      func(v *model.SLOData) interface{} { return v.SLO },
+	"newSlo":                   func(v *model.SLOData) interface{} { return v.TargetSLO },
+	"minBudget":                func(v *model.SLOData) interface{} { return v.MinBudget },
+	"avgBudget":                func(v *model.SLOData) interface{} { return v.AvgBudget },
+	"negativeFraction":         func(v *model.SLOData) interface{} { return v.NegativeFraction },
+	"goodQuery":                func(v *model.SLOData) interface{} { return v.GoodQuery },
+	"totalQuery":               func(v *model.SLOData) interface{} { return v.TotalQuery },
+	"consoleUrl":               func(v *model.SLOData) interface{} { return v.ConsoleURL },
+	"threshold":                func(v *model.SLOData) interface{} { return v.ErrorBudgetThreshold },
+	"window":                   func(v *model.SLOData) interface{} { return v.Window },
+	"negativeBudgetFraction":   func(v *model.SLOData) interface{} { return v.NegativeBudgetFraction },
+	"burnRate1h":               func(v *model.SLOData) interface{} { return v.BurnRate1h },
+	"burnRate6h":               func(v *model.SLOData) interface{} { return v.BurnRate6h },
+	"burnRate24h":              func(v *model.SLOData) interface{} { return v.BurnRate24h },
+	"burnRate72h":              func(v *model.SLOData) interface{} { return v.BurnRate72h },
+	"peakBurnRate":             func(v *model.SLOData) interface{} { return v.PeakBurnRate },
+	"timeToExhaustion":         func(v *model.SLOData) interface{} { return v.TimeToExhaustion },
+	"p50Budget":                func(v *model.SLOData) interface{} { return v.P50Budget },
+	"p90Budget":                func(v *model.SLOData) interface{} { return v.P90Budget },
+	"p99Budget":                func(v *model.SLOData) interface{} { return v.P99Budget },
+	"p999Budget":               func(v *model.SLOData) interface{} { return v.P999Budget },
+	"slope":                    func(v *model.SLOData) interface{} { return v.Slope },
+	"trend":                    func(v *model.SLOData) interface{} { return v.Trend },
+	"projectedBudget":          func(v *model.SLOData) interface{} { return v.ProjectedBudget },
+	"budgetConsumed":           func(v *model.SLOData) interface{} { return v.BudgetConsumed },
+	"budgetConsumedTotal":      func(v *model.SLOData) interface{} { return v.BudgetConsumedTotal },
+	"allowedDowntimeMinutes":   func(v *model.SLOData) interface{} { return v.AllowedDowntimeMinutes },
+	"badMinutes":               func(v *model.SLOData) interface{} { return v.BadMinutes },
+	"remainingDowntimeMinutes": func(v *model.SLOData) interface{} { return v.RemainingDowntimeMinutes },
+	"healthScore":              func(v *model.SLOData) interface{} { return v.HealthScore },
+	"confidence":               func(v *model.SLOData) interface{} { return v.Confidence },
+	"longestBreachHours":       func(v *model.SLOData) interface{} { return v.LongestBreachHours },
+	"anomalies":                func(v *model.SLOData) interface{} { return v.Anomalies },
+	"anomalyShare":             func(v *model.SLOData) interface{} { return v.AnomalyShare },
+	"incidentDriven":           func(v *model.SLOData) interface{} { return v.IncidentDriven },
+	"trafficWeighted":          func(v *model.SLOData) interface{} { return v.TrafficWeighted },
+	"trimmedPoints":            func(v *model.SLOData) interface{} { return v.TrimmedPoints },
+	"exhaustionDate": func(v *model.SLOData) interface{} {
+		if v.ExhaustionDate.IsZero() {
+			return nil
+		}
+		return v.ExhaustionDate
+	},
+	"compliance":      statusField(func(s *model.SLOStatus) float64 { return s.Compliance }),
+	"budgetRemaining": statusField(func(s *model.SLOStatus) float64 { return s.BudgetRemaining }),
+	"statusBurnRate":  statusField(func(s *model.SLOStatus) float64 { return s.BurnRate }),
+	"alerted": func(v *model.SLOData) interface{} {
+		if v.Alerted == nil {
+			return nil
+		}
+		return *v.Alerted
+	},
+	"badTimeInDowntime": func(v *model.SLOData) interface{} {
+		if v.BadTimeInDowntime == nil {
+			return nil
+		}
+		return *v.BadTimeInDowntime
+	},
+	"minBudgetChange": func(v *model.SLOData) interface{} {
+		if v.MinBudgetChange == nil {
+			return nil
+		}
+		return *v.MinBudgetChange
+	},
+	"monitorIssues": func(v *model.SLOData) interface{} {
+		if len(v.MonitorIssues) == 0 {
+			return nil
+		}
+		return strings.Join(v.MonitorIssues, ", ")
+	},
+}
+
+// statusField reads a field of the status the provider computed, empty when it computed none.
+func statusField(get func(s *model.SLOStatus) float64) func(v *model.SLOData) interface{} {
+	return func(v *model.SLOData) interface{} {
+		if v.Status == nil {
+			return nil
+		}
+		return get(v.Status)
+	}
+}
+
+// formatLabels renders labels as key=value pairs sorted by key, e.g. "cluster_name=prod, location=us-central1".
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Per-window fields, given as field@window such as minBudget@168h with one of the windows of --window. They are empty
+// for SLOs that were not evaluated over that window.
+var windowFields = map[string]func(model.WindowStats) interface{}{
+	"minBudget":        func(w model.WindowStats) interface{} { return w.MinBudget },
+	"avgBudget":        func(w model.WindowStats) interface{} { return w.AvgBudget },
+	"negativeFraction": func(w model.WindowStats) interface{} { return w.NegativeFraction },
+	"budgetConsumed":   func(w model.WindowStats) interface{} { return w.BudgetConsumed },
+	"category":         func(w model.WindowStats) interface{} { return string(w.Category) },
+}
+
+// Fields returns the names of the built-in fields in alphabetical order.
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Default returns the historical layout of the flagged SLO sheet.
+func Default(msgs *i18n.Messages) *Spec {
+	return &Spec{FreezeHeader: true, AutoFilter: true, Columns: []*Column{
+		{Header: msgs.HeaderName, Field: "name", Width: 50},
+		{Header: msgs.HeaderSLO, Field: "slo", NumFmt: GoalPercentFormat, Width: 10},
+		{Header: msgs.HeaderNewSLO, Field: "newSlo", NumFmt: GoalPercentFormat, Width: 10, Highlight: true},
+		{Header: msgs.HeaderConfidence, Field: "confidence", NumFmt: ConfidenceFormat, Width: 10},
+		{Header: msgs.HeaderHealthScore, Field: "healthScore", NumFmt: ScoreFormat, Width: 10},
+		{Header: msgs.HeaderSLIMin, Field: "minBudget", NumFmt: PercentFormat, Width: 10},
+		{Header: msgs.HeaderSLIAvg, Field: "avgBudget", NumFmt: PercentFormat, Width: 10},
+		{Header: msgs.HeaderNegative, Field: "negativeFraction", NumFmt: PercentFormat, Width: 10},
+		{Header: msgs.HeaderBudgetConsumed, Field: "budgetConsumed", NumFmt: PercentFormat, Width: 12},
+		{Header: msgs.HeaderRemainingDowntime, Field: "remainingDowntimeMinutes", NumFmt: MinutesFormat, Width: 12},
+		{Header: msgs.HeaderLongestBreach, Field: "longestBreachHours", NumFmt: HoursFormat, Width: 12},
+		{Header: msgs.HeaderPeakBurnRate, Field: "peakBurnRate", NumFmt: BurnRateFormat, Width: 10},
+		{Header: msgs.HeaderTimeToExhaustion, Field: "timeToExhaustion", Width: 12},
+		{Header: msgs.HeaderExhaustionDate, Field: "exhaustionDate", NumFmt: DateFormat, Width: 14},
+		{Header: msgs.HeaderGoodQuery, Field: "goodQuery", Width: 50},
+		{Header: msgs.HeaderTotalQuery, Field: "totalQuery", Width: 50},
+		{Header: msgs.HeaderNewGoodQuery, Width: 50},
+		{Header: msgs.HeaderNewTotalQuery, Width: 50},
+		{Header: msgs.HeaderProject, Field: "project", Width: 20},
+		{Header: msgs.HeaderConsoleLink, Field: "consoleUrl", Width: 20},
+	}}
+}
+
+// AddService adds the service, its type and resource labels before the project column, or at the end, so rows can be
+// attributed to the workload behind them.
+func (s *Spec) AddService(msgs *i18n.Messages) {
+	columns := []*Column{
+		{Header: msgs.HeaderService, Field: "service", Width: 30},
+		{Header: msgs.HeaderServiceType, Field: "serviceType", Width: 16},
+		{Header: msgs.HeaderServiceResource, Field: "serviceResource", Width: 50},
+	}
+
+	i := slices.IndexFunc(s.Columns, func(c *Column) bool { return c.Is("project") })
+	if i < 0 {
+		i = len(s.Columns)
+	}
+	s.Columns = slices.Insert(s.Columns, i, columns...)
+}
+
+// AddAlerted adds whether an alert fires on the burn rate of the SLO after the health score column, or at the end.
+func (s *Spec) AddAlerted(msgs *i18n.Messages) {
+	i := slices.IndexFunc(s.Columns, func(c *Column) bool { return c.Is("healthScore") })
+	if i < 0 {
+		i = len(s.Columns) - 1
+	}
+	s.Columns = slices.Insert(s.Columns, i+1, &Column{Header: msgs.HeaderAlerted, Field: "alerted", Width: 10})
+}
+
+// AddChange adds the change of the minimum budget since the last stored run after the minimum budget column, or at
+// the end.
+func (s *Spec) AddChange(msgs *i18n.Messages) {
+	i := slices.IndexFunc(s.Columns, func(c *Column) bool { return c.Is("minBudget") })
+	if i < 0 {
+		i = len(s.Columns) - 1
+	}
+	s.Columns = slices.Insert(s.Columns, i+1, &Column{Header: msgs.HeaderMinBudgetChange, Field: "minBudgetChange", NumFmt: ChangeFormat, Width: 12})
+}
+
+// AddMonitorIssues adds the monitors that keep the SLO from seeing bad time after the category column, or at the end.
+func (s *Spec) AddMonitorIssues(msgs *i18n.Messages) {
+	i := slices.IndexFunc(s.Columns, func(c *Column) bool { return c.Is("category") })
+	if i < 0 {
+		i = len(s.Columns) - 1
+	}
+	s.Columns = slices.Insert(s.Columns, i+1, &Column{Header: msgs.HeaderMonitorIssues, Field: "monitorIssues", Width: 30})
+}
+
+// AddWindows adds the minimum budget and category over each window after the negative fraction column, or at the end.
+func (s *Spec) AddWindows(msgs *i18n.Messages, windows []time.Duration) {
+	columns := make([]*Column, 0, 2*len(windows))
+	for _, w := range windows {
+		columns = append(columns,
+			&Column{Header: fmt.Sprintf("%s (%s)", msgs.HeaderSLIMin, WindowLabel(w)), Field: "minBudget@" + w.String(), NumFmt: PercentFormat, Width: 12},
+			&Column{Header: fmt.Sprintf("%s (%s)", msgs.HeaderCategory, WindowLabel(w)), Field: "category@" + w.String(), Width: 14},
+		)
+	}
+
+	i := slices.IndexFunc(s.Columns, func(c *Column) bool { return c.Is("negativeFraction") })
+	if i < 0 {
+		i = len(s.Columns) - 1
+	}
+	s.Columns = slices.Insert(s.Columns, i+1, columns...)
+}
+
+// WindowLabel returns w in days when it is a whole number of them, e.g. 7d, and as a duration otherwise.
+func WindowLabel(w time.Duration) string {
+	if w%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", w/(24*time.Hour))
+	}
+	return w.String()
+}
+
+// Load reads a Spec from a YAML file and validates its columns.
+func Load(path string) (*Spec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report spec: %w", err)
+	}
+
+	// Options left out of the file keep their defaults.
+	spec := Spec{FreezeHeader: true, AutoFilter: true}
+	if err := yaml.Unmarshal(b, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse report spec %s: %w", path, err)
+	}
+	if len(spec.Columns) == 0 {
+		return nil, fmt.Errorf("report spec %s has no columns", path)
+	}
+
+	for i, c := range spec.Columns {
+		if err := c.init(); err != nil {
+			return nil, fmt.Errorf("invalid column %d of %s: %w", i+1, path, err)
+		}
+	}
+
+	return &spec, nil
+}
+
+// Value returns the value of the column for an SLO.
+func (c *Column) Value(v *model.SLOData) (interface{}, error) {
+	if c.tmpl != nil {
+		var buf bytes.Buffer
+		if err := c.tmpl.Execute(&buf, v); err != nil {
+			return nil, fmt.Errorf("failed to render column %q: %w", c.Header, err)
+		}
+		return buf.String(), nil
+	}
+	if f, ok := fields[c.Field]; ok {
+		return f(v), nil
+	}
+	if name, window, ok := strings.Cut(c.Field, "@"); ok {
+		for _, w := range v.Windows {
+			if w.Window == window {
+				return windowFields[name](w), nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Is reports whether the column shows the given built-in field.
+func (c *Column) Is(field string) bool {
+	return c.tmpl == nil && c.Field == field
+}
+
+func (c *Column) init() error {
+	switch {
+	case c.Field != "" && c.Template != "":
+		return errors.New("field and template are mutually exclusive")
+	case c.Template != "":
+		tmpl, err := template.New(c.Header).Option("missingkey=error").Parse(c.Template)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		c.tmpl = tmpl
+	case strings.Contains(c.Field, "@"):
+		name, window, _ := strings.Cut(c.Field, "@")
+		if _, ok := windowFields[name]; !ok {
+			return fmt.Errorf("unknown per-window field %q. must be one of %v", name, slices.Sorted(maps.Keys(windowFields)))
+		}
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			return fmt.Errorf("invalid window of field %q: %w", c.Field, err)
+		}
+		// Normalized to match model.WindowStats.Window, so 168h and 168h0m0s match alike.
+		c.Field = name + "@" + d.String()
+	case c.Field != "":
+		if _, ok := fields[c.Field]; !ok {
+			return fmt.Errorf("unknown field %q. must be one of %v", c.Field, Fields())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/report"
+)
+
+// reportTime is the clock the reports take their generation time from. TestGolden fixes it, so the reports of the
+// same results are the same.
+var reportTime = time.Now
+
+// Reporter writes the results of a run, the SLOs by key, in one report format.
+type Reporter interface {
+	Write(data map[string]*model.SLOData) error
+}
+
+// newReporter returns the Reporter of a --format. The formats printed to stdout, table and github, ignore path.
+func newReporter(reportFormat string, spec *report.Spec, path string) Reporter {
+	msgs := i18n.Get(i18n.Lang(*lang))
+	switch reportFormat {
+	case formatJSON:
+		return jsonReporter{path: path}
+	case formatCSV:
+		return csvReporter{spec: spec, path: path}
+	case formatHTML:
+		return htmlReporter{msgs: msgs, path: path}
+	case formatPDF:
+		// The standard PDF fonts have no CJK glyphs, so the PDF is always rendered in English.
+		return pdfReporter{msgs: i18n.Get(i18n.LangEN), path: path}
+	case formatSARIF:
+		return sarifReporter{path: path}
+	case formatTable:
+		return tableReporter{msgs: msgs, out: os.Stdout}
+	case formatGitHub:
+		return githubReporter{out: os.Stdout}
+	default:
+		return xlsxReporter{msgs: msgs, spec: spec, path: path}
+	}
+}
+
+// xlsxReporter writes the Excel report, its SLO sheets laid out by spec.
+type xlsxReporter struct {
+	msgs *i18n.Messages
+	spec *report.Spec
+	path string
+}
+
+func (r xlsxReporter) Write(data map[string]*model.SLOData) error {
+	return generateExcelReport(data, r.msgs, r.spec, r.path)
+}
+
+// csvReporter writes every SLO as a row of the columns of spec.
+type csvReporter struct {
+	spec *report.Spec
+	path string
+}
+
+func (r csvReporter) Write(data map[string]*model.SLOData) error {
+	return generateCSVReport(data, r.spec, r.path)
+}
+
+// jsonReporter writes the JSON report.
+type jsonReporter struct {
+	path string
+}
+
+func (r jsonReporter) Write(data map[string]*model.SLOData) error {
+	return generateJSONReport(data, r.path)
+}
+
+// htmlReporter writes the standalone HTML report.
+type htmlReporter struct {
+	msgs *i18n.Messages
+	path string
+}
+
+func (r htmlReporter) Write(data map[string]*model.SLOData) error {
+	return generateHTMLReport(data, r.msgs, r.path)
+}
+
+// pdfReporter writes the PDF report of the summary and the flagged SLOs.
+type pdfReporter struct {
+	msgs *i18n.Messages
+	path string
+}
+
+func (r pdfReporter) Write(data map[string]*model.SLOData) error {
+	return generatePDFReport(data, r.msgs, r.path)
+}
+
+// sarifReporter writes the flagged SLOs as a SARIF log.
+type sarifReporter struct {
+	path string
+}
+
+func (r sarifReporter) Write(data map[string]*model.SLOData) error {
+	return generateSARIFReport(data, r.path)
+}
+
+// tableReporter prints the flagged SLOs as a table.
+type tableReporter struct {
+	msgs *i18n.Messages
+	out  *os.File
+}
+
+func (r tableReporter) Write(data map[string]*model.SLOData) error {
+	generateTableReport(data, r.msgs, r.out)
+	return nil
+}
+
+// githubReporter prints the flagged SLOs as GitHub Actions annotations.
+type githubReporter struct {
+	out *os.File
+}
+
+func (r githubReporter) Write(data map[string]*model.SLOData) error {
+	return generateGitHubReport(data, r.out)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Rules reported to CI. An SLO is too lax when its budget never dropped below the threshold
+// burning when the budget was negative for at least --negative-budget-fraction of the window and burning fast when its peak burn rate
+// reached --burn-rate-threshold. Degrading and exhausting SLOs are reported with --flag-degrading and --flag-exhaustion.
+const (
+	ruleTooLax     = "slo-too-lax"
+	ruleBurning    = "slo-burning"
+	ruleFastBurn   = "slo-fast-burn"
+	ruleDegrading  = "slo-degrading"
+	ruleExhaustion = "slo-exhaustion-forecast"
+)
+
+// sourceExtensions are the files searched for SLO definitions when --source-dir is given.
+var sourceExtensions = []string{".tf", ".yaml", ".yml", ".json"}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// finding is a flagged SLO with the rule it violates and, when found, where it is defined.
+type finding struct {
+	slo     *model.SLOData
+	rule    string
+	message string
+	file    string
+	line    int
+}
+
+// generateSARIFReport writes the flagged SLOs as a SARIF 2.1.0 log for code scanning.
+func generateSARIFReport(data map[string]*model.SLOData, output string) error {
+	report := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "vigil",
+				InformationURI: "https://github.com/rluisr/vigil",
+				Rules: []sarifRule{
+					{ID: ruleTooLax, ShortDescription: sarifMessage{Text: "The error budget never dropped below the threshold; the SLO is likely too lax."}},
+					{ID: ruleBurning, ShortDescription: sarifMessage{Text: "The error budget was negative for too much of the window; the SLO is likely too strict."}},
+					{ID: ruleFastBurn, ShortDescription: sarifMessage{Text: "The error budget burned faster than --burn-rate-threshold."}},
+					{ID: ruleDegrading, ShortDescription: sarifMessage{Text: "The error budget is still positive but trending toward exhaustion."}},
+					{ID: ruleExhaustion, ShortDescription: sarifMessage{Text: "The error budget is forecast to hit zero within the next window."}},
+				},
+			}},
+			Results: []sarifResult{},
+		}},
+	}
+
+	for _, f := range findings(data) {
+		location := sarifLocation{
+			LogicalLocations: []sarifLogicalLocation{{Name: f.slo.DisplayName, FullyQualifiedName: f.slo.Key}},
+		}
+		if f.file != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.file},
+				Region:           sarifRegion{StartLine: f.line},
+			}
+		}
+		report.Runs[0].Results = append(report.Runs[0].Results, sarifResult{
+			RuleID:    f.rule,
+			Level:     "warning",
+			Message:   sarifMessage{Text: f.message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write SARIF report: %w", err)
+	}
+	return nil
+}
+
+// generateGitHubReport prints the flagged SLOs to w as GitHub Actions workflow commands, which show up as annotations.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-a-warning-message.
+func generateGitHubReport(data map[string]*model.SLOData, w io.Writer) error {
+	for _, f := range findings(data) {
+		props := []string{"title=" + escapeGitHubProperty(fmt.Sprintf("%s: %s", f.rule, f.slo.DisplayName))}
+		if f.file != "" {
+			props = append(props, "file="+escapeGitHubProperty(f.file), fmt.Sprintf("line=%d", f.line))
+		}
+		if _, err := fmt.Fprintf(w, "::warning %s::%s\n", strings.Join(props, ","), escapeGitHubData(f.message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findings classifies the flagged SLOs and locates their definitions under --source-dir.
+func findings(data map[string]*model.SLOData) []finding {
+	var flagged []*model.SLOData
+	for _, v := range data {
+		if v.Flag {
+			flagged = append(flagged, v)
+		}
+	}
+	sortSLOs(flagged)
+
+	var sources []string
+	if *sourceDir != "" {
+		var err error
+		sources, err = sourceFiles(*sourceDir)
+		if err != nil {
+			log.Panicf("Failed to list --source-dir: %v", err)
+		}
+	}
+
+	result := make([]finding, 0, len(flagged))
+	for _, v := range flagged {
+		f := finding{slo: v, rule: ruleTooLax}
+		switch {
+		case burning(v) && v.IncidentDriven:
+			f.rule = ruleBurning
+			f.message = fmt.Sprintf("SLO %q (goal %.3f%%) had a negative error budget for %.1f%% of the %s window, %.0f%% of it spent in a single incident. Review the incident before relaxing the objective.",
+				v.DisplayName, v.SLO*100, v.NegativeFraction*100, v.Window, v.AnomalyShare*100)
+		case burning(v):
+			f.rule = ruleBurning
+			f.message = fmt.Sprintf("SLO %q (goal %.3f%%) had a negative error budget for %.1f%% of the %s window. Consider relaxing the objective.",
+				v.DisplayName, v.SLO*100, v.NegativeFraction*100, v.Window)
+		case fastBurn(v):
+			f.rule = ruleFastBurn
+			f.message = fmt.Sprintf("SLO %q (goal %.3f%%) burned its error budget at up to %.1fx the sustainable rate in the %s window. Check the incidents behind the burn.",
+				v.DisplayName, v.SLO*100, v.PeakBurnRate, v.Window)
+		case trendingToExhaustion(v):
+			f.rule = ruleDegrading
+			f.message = fmt.Sprintf("SLO %q (goal %.3f%%) lost %.2f%% of its error budget per day over the %s window and is projected to exhaust it within another window.",
+				v.DisplayName, v.SLO*100, -v.Slope*100, v.Window)
+		case forecastExhaustion(v):
+			f.rule = ruleExhaustion
+			f.message = fmt.Sprintf("SLO %q (goal %.3f%%) is forecast to exhaust its error budget on %s at its current consumption rate.",
+				v.DisplayName, v.SLO*100, v.ExhaustionDate.Format(time.DateOnly))
+		default:
+			f.message = fmt.Sprintf("SLO %q (goal %.3f%%) used at most %.2f%% of its error budget in the %s window, never dropping below the %g%% threshold. Consider tightening the objective.",
+				v.DisplayName, v.SLO*100, (1-v.MinBudget)*100, v.Window, v.ErrorBudgetThreshold*100)
+		}
+		f.message += fmt.Sprintf(" Confidence: %.0f%%.", v.Confidence*100)
+		f.file, f.line = locate(sources, v)
+		result = append(result, f)
+	}
+
+	return result
+}
+
+// sourceFiles lists the infrastructure as code files under dir.
+func sourceFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") && p != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, ext := range sourceExtensions {
+			if strings.HasSuffix(d.Name(), ext) {
+				files = append(files, p)
+				break
+			}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// locate returns the first line quoting the SLO display name, or the last segment of its key
+// (e.g. the slo_id of a GCP SLO), in one of the source files. Paths use forward slashes as SARIF expects.
+func locate(files []string, slo *model.SLOData) (string, int) {
+	needles := []string{fmt.Sprintf("%q", slo.DisplayName)}
+	if id := path.Base(slo.Key); id != "" && id != slo.DisplayName {
+		needles = append(needles, fmt.Sprintf("%q", id))
+	}
+
+	for _, file := range files {
+		if line := findLine(file, needles); line > 0 {
+			return filepath.ToSlash(file), line
+		}
+	}
+	return "", 0
+}
+
+func findLine(file string, needles []string) int {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Printf("Failed to open %s: %v", file, err)
+		return 0
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Failed to close file: %v", err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		for _, needle := range needles {
+			if strings.Contains(scanner.Text(), needle) {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// escapeGitHubData escapes the message of a workflow command.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
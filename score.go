@@ -0,0 +1,23 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// worstOffenders returns up to n SLOs with the lowest health score, worst first. SLOs without data have no score
+// and are left out.
+func worstOffenders(data map[string]*model.SLOData, n int) []*model.SLOData {
+	var scored []*model.SLOData
+	for _, v := range data {
+		if v.Category != model.CategoryNoData {
+			scored = append(scored, v)
+		}
+	}
+	slices.SortFunc(scored, func(a, b *model.SLOData) int {
+		return cmp.Or(cmp.Compare(a.HealthScore, b.HealthScore), cmp.Compare(a.DisplayName, b.DisplayName))
+	})
+	return scored[:min(n, len(scored))]
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/store"
+)
+
+//go:embed templates/serve.html
+var serveTemplate string
+
+// scanWaitDelay is how long a scan has to write its partial report once vigil serve is stopped.
+const scanWaitDelay = time.Minute
+
+// maxScans is the number of scans vigil serve remembers for GET /scans/{id}, the oldest being forgotten first.
+const maxScans = 1000
+
+// Triggers of a scan.
+const (
+	triggerSchedule = "schedule"
+	triggerAPI      = "api"
+)
+
+// Statuses of a scan.
+const (
+	scanRunning   = "running"
+	scanSucceeded = "succeeded"
+	scanFailed    = "failed"
+)
+
+// server runs the scans of the targets of the config on their schedules and on demand, and serves their reports.
+type server struct {
+	// ctx is the context of the server, which scans run under whatever triggered them.
+	ctx context.Context
+	// exe and args are the binary and command line of vigil serve, passed on to every scan.
+	exe     string
+	args    []string
+	targets []*targetState
+	// tmpl is the status page, slosTmpl and sloTmpl the pages of the dashboard.
+	tmpl, slosTmpl, sloTmpl *template.Template
+	wg                      sync.WaitGroup
+
+	// mu guards the state of the targets and the scans.
+	mu     sync.Mutex
+	scans  map[int64]*scan
+	lastID int64
+}
+
+// targetState is the state of the scans of a target.
+type targetState struct {
+	target *target
+	// running is the scan in progress, nil when there is none. A target runs one scan at a time.
+	running *scan
+	next    time.Time
+	last    *scan
+	// report is the path of the latest report, empty until a scan wrote one.
+	report string
+
+	// The metrics of the target: the finished scans by status and the SLOs they failed to scan since the server
+	// started, the duration of the last scan and the start of the last successful one.
+	scans       map[string]int
+	sloErrors   int
+	duration    time.Duration
+	lastSuccess time.Time
+}
+
+// scan is a scan of a target, as returned by the API.
+type scan struct {
+	ID        int64     `json:"id"`
+	Target    string    `json:"target"`
+	Trigger   string    `json:"trigger"`
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"startedAt"`
+	// FinishedAt and ExitCode are set once the scan is done. exitFlagged is a success.
+	FinishedAt time.Time `json:"finishedAt,omitzero"`
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"`
+	// RunID is the run the scan was saved as in the history store, 0 when it was not, such as when cancelled.
+	RunID int64 `json:"runId,omitempty"`
+
+	// report is the path of the report of the scan.
+	report string
+}
+
+// runServe runs continuously, scanning the targets of the config on their cron schedules into the --store database
+// and serving their latest reports over HTTP on --listen.
+func runServe(args []string) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(runtime.Error); ok {
+				panic(r)
+			}
+			code = exitError
+		}
+	}()
+
+	_ = flag.CommandLine.Parse(args)
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if err := cfg.applyFlags(); err != nil {
+		log.Panicf("%v", err)
+	}
+	if flag.CommandLine.NArg() > 0 {
+		log.Panicf("Usage: vigil %s --config <file> --store <file> [flags]", cmdServe)
+	}
+	if len(cfg.Targets) == 0 {
+		log.Panicf("vigil %s needs the targets to scan in the targets section of --config", cmdServe)
+	}
+	if *storePath == "" {
+		log.Panicf("vigil %s needs --store, the history store of the scans", cmdServe)
+	}
+	if !isFlagSet("format") {
+		*format = formatHTML
+	}
+	switch *format {
+	case formatXLSX, formatCSV, formatJSON, formatHTML, formatPDF, formatSARIF:
+	default:
+		log.Panicf("vigil %s writes report files: --format %s, %s, %s, %s, %s or %s", cmdServe, formatXLSX, formatCSV, formatJSON, formatHTML, formatPDF, formatSARIF)
+	}
+	if *targetName != "" {
+		log.Panicf("vigil %s names the runs after the targets of --config: --target is not used", cmdServe)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Panicf("Failed to locate the vigil binary: %v", err)
+	}
+	tmpl, err := template.New("serve").Parse(serveTemplate)
+	if err != nil {
+		log.Panicf("Failed to parse the status page template: %v", err)
+	}
+	slosTmpl, sloTmpl, err := dashboardTemplates()
+	if err != nil {
+		log.Panicf("Failed to parse the dashboard templates: %v", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s := &server{ctx: ctx, exe: exe, args: args, tmpl: tmpl, slosTmpl: slosTmpl, sloTmpl: sloTmpl, scans: map[int64]*scan{}}
+	for _, t := range cfg.Targets {
+		ts := &targetState{target: t, report: latestReport(t.Name), scans: map[string]int{}}
+		// A restarted server resumes from the last stored run, a successful scan.
+		if run, ok := storedRun(t.Name, time.Time{}); ok {
+			ts.lastSuccess = run.StartedAt
+		}
+		s.targets = append(s.targets, ts)
+	}
+	for _, ts := range s.targets {
+		s.wg.Go(func() { s.schedule(ctx, ts) })
+	}
+
+	httpServer := &http.Server{Addr: *listenAddr, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	// The requests being served can start scans, so the running scans are waited for once they are done.
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	infof("Serving %d targets on %s", len(s.targets), *listenAddr)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		stop()
+		<-shutdown
+		s.wg.Wait()
+		log.Panicf("Failed to serve on %s: %v", *listenAddr, err)
+	}
+
+	infof("Stopping: waiting for the running scans")
+	<-shutdown
+	s.wg.Wait()
+	return exitOK
+}
+
+// schedule scans a target at every time of its schedule until ctx is done. A scheduled scan still running at the
+// next time delays the following one rather than overlapping it, and one is skipped while a scan triggered through
+// the API runs.
+func (s *server) schedule(ctx context.Context, ts *targetState) {
+	for {
+		next := ts.target.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("%s: schedule %s never runs", ts.target.Name, ts.target.Schedule)
+			return
+		}
+		s.mu.Lock()
+		ts.next = next
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		sc, running := s.start(ts, triggerSchedule)
+		if sc == nil {
+			infof("%s: scan %d still running, skipping the scheduled scan", ts.target.Name, running.ID)
+			continue
+		}
+		s.scan(ctx, ts, sc)
+	}
+}
+
+// start registers a new scan of a target as running. It returns nil and the running scan when the target is
+// already being scanned.
+func (s *server) start(ts *targetState, trigger string) (*scan, *scan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ts.running != nil {
+		return nil, ts.running
+	}
+	s.lastID++
+	sc := &scan{ID: s.lastID, Target: ts.target.Name, Trigger: trigger, Status: scanRunning, StartedAt: time.Now()}
+	sc.report = filepath.Join(*reportsDir, ts.target.Name, sc.StartedAt.Format("20060102-150405")+"."+*format)
+	ts.running = sc
+	s.scans[sc.ID] = sc
+	delete(s.scans, sc.ID-maxScans)
+	return sc, nil
+}
+
+// scan runs vigil scan for a target: the command line of vigil serve, then the flags of the target, then the history
+// store, the target name and the report file, so the flags of a target override those of the server.
+func (s *server) scan(ctx context.Context, ts *targetState, sc *scan) {
+	t := ts.target
+	exitCode, errMsg := exitOK, ""
+	defer func() {
+		run, stored := storedRun(t.Name, sc.StartedAt)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		sc.FinishedAt = time.Now()
+		sc.ExitCode, sc.Error = exitCode, errMsg
+		sc.Status = scanSucceeded
+		if errMsg != "" {
+			sc.Status = scanFailed
+		}
+		if stored {
+			sc.RunID = run.ID
+			ts.sloErrors += run.Failed
+		}
+		ts.scans[sc.Status]++
+		ts.duration = sc.FinishedAt.Sub(sc.StartedAt)
+		if sc.Status == scanSucceeded {
+			ts.lastSuccess = sc.StartedAt
+		}
+		ts.running = nil
+		ts.last = sc
+		if _, err := os.Stat(sc.report); err == nil {
+			ts.report = sc.report
+		}
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(sc.report), 0o755); err != nil {
+		exitCode, errMsg = exitError, err.Error()
+		log.Printf("%s: %v", t.Name, err)
+		return
+	}
+
+	args := append([]string{cmdScan}, s.args...)
+	for _, name := range slices.Sorted(maps.Keys(t.Flags)) {
+		args = append(args, fmt.Sprintf("--%s=%s", name, t.Flags[name]))
+	}
+	args = append(args, "--store="+*storePath, "--target="+t.Name, "--format="+*format, "--output="+sc.report, "--force")
+
+	infof("%s: scan %d started by %s", t.Name, sc.ID, sc.Trigger)
+	cmd := exec.CommandContext(ctx, s.exe, args...)
+	// A stopped server interrupts the scan like Ctrl-C, so it still writes a partial report.
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = scanWaitDelay
+	out := &prefixWriter{prefix: t.Name + ": "}
+	cmd.Stdout, cmd.Stderr = out, out
+	err := cmd.Run()
+	out.flush()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		exitCode = exitErr.ExitCode()
+	default:
+		exitCode = exitError
+	}
+	if exitCode != exitOK && exitCode != exitFlagged {
+		errMsg = fmt.Sprintf("exit status %d", exitCode)
+		if err != nil && exitErr == nil {
+			errMsg = err.Error()
+		}
+		log.Printf("%s: scan %d failed: %s", t.Name, sc.ID, errMsg)
+		return
+	}
+	infof("%s: scan %d finished in %s", t.Name, sc.ID, time.Since(sc.StartedAt).Round(time.Second))
+}
+
+// storedRun returns the run a scan of target started at startedAt was saved as. ok is false when it was not saved.
+func storedRun(target string, startedAt time.Time) (run store.Run, ok bool) {
+	st, err := store.Open(*storePath)
+	if err != nil {
+		log.Printf("%v", err)
+		return store.Run{}, false
+	}
+	defer func() { _ = st.Close() }()
+	run, ok, err = st.Latest(context.Background(), target)
+	if err != nil {
+		log.Printf("%v", err)
+		return store.Run{}, false
+	}
+	// The store keeps whole seconds, and the scan starts its run after the server started the scan.
+	if !ok || run.StartedAt.Before(startedAt.Truncate(time.Second)) {
+		return store.Run{}, false
+	}
+	return run, true
+}
+
+// latestReport returns the newest report of a target in --reports-dir, so a restarted server serves the reports of
+// earlier scans. Empty when there is none.
+func latestReport(name string) string {
+	entries, err := os.ReadDir(filepath.Join(*reportsDir, name))
+	if err != nil {
+		return ""
+	}
+	// The names of the reports are the times of their scans, so the last one is the newest.
+	for i := len(entries) - 1; i >= 0; i-- {
+		if e := entries[i]; !e.IsDir() && filepath.Ext(e.Name()) == "."+*format {
+			return filepath.Join(*reportsDir, name, e.Name())
+		}
+	}
+	return ""
+}
+
+// handler serves the status page, the dashboard, the latest report of every target, the API, the metrics and a health
+// check.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	s.handleAPI(mux)
+	mux.HandleFunc("GET /{$}", s.handleStatus)
+	mux.HandleFunc("GET /slos", s.handleSLOs)
+	mux.HandleFunc("GET /slos/{name}", s.handleSLO)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("GET /targets/{name}/report", s.handleReport)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// target returns the state of the target named name, nil when there is none.
+func (s *server) target(name string) *targetState {
+	i := slices.IndexFunc(s.targets, func(ts *targetState) bool { return ts.target.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return s.targets[i]
+}
+
+func (s *server) handleReport(w http.ResponseWriter, r *http.Request) {
+	ts := s.target(r.PathValue("name"))
+	if ts == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	path := ts.report
+	s.mu.Unlock()
+	if path == "" {
+		http.Error(w, "no report yet: the first scan of the target has not finished", http.StatusNotFound)
+		return
+	}
+	http.ServeFile(w, r, path)
+}
+
+// statusRow is a target on the status page.
+type statusRow struct {
+	Name, Schedule, LastRun, Duration, Status, NextRun string
+	Failed, HasReport                                  bool
+	ReportURL, ReportName                              string
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	var rows []statusRow
+	for _, ts := range s.targets {
+		row := statusRow{Name: ts.target.Name, Schedule: ts.target.Schedule, Status: "waiting"}
+		if !ts.next.IsZero() {
+			row.NextRun = ts.next.Format(time.DateTime)
+		}
+		if ts.last != nil {
+			row.LastRun = ts.last.StartedAt.Format(time.DateTime)
+			row.Duration = ts.last.FinishedAt.Sub(ts.last.StartedAt).Round(time.Second).String()
+			row.Status = "ok"
+			if ts.last.ExitCode == exitFlagged {
+				row.Status = "ok, SLOs flagged"
+			}
+			if ts.last.Error != "" {
+				row.Status, row.Failed = "failed: "+ts.last.Error, true
+			}
+		}
+		if ts.running != nil {
+			row.Status = "running"
+		}
+		if ts.report != "" {
+			row.HasReport = true
+			row.ReportURL = "/targets/" + url.PathEscape(ts.target.Name) + "/report"
+			row.ReportName = filepath.Base(ts.report)
+		}
+		rows = append(rows, row)
+	}
+	s.mu.Unlock()
+
+	var buf bytes.Buffer
+	err := s.tmpl.Execute(&buf, map[string]interface{}{
+		"Targets":     rows,
+		"GeneratedBy": i18n.Get(i18n.LangEN).GeneratedBy,
+		"GeneratedAt": time.Now().Format(time.DateTime),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// prefixWriter writes the output of a scan to stderr line by line, each line prefixed with the name of its target so
+// the logs of concurrent scans can be told apart.
+type prefixWriter struct {
+	prefix string
+	mu     sync.Mutex
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "%s%s\n", p.prefix, strings.TrimSuffix(string(p.buf[:i]), "\r"))
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// flush writes the last line when it has no newline.
+func (p *prefixWriter) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.buf) > 0 {
+		fmt.Fprintf(os.Stderr, "%s%s\n", p.prefix, p.buf)
+		p.buf = nil
+	}
+}
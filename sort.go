@@ -0,0 +1,30 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/rluisr/vigil/model"
+)
+
+// Supported --sort orders.
+const (
+	sortName      = "name"
+	sortMinBudget = "min-budget"
+	sortAvgBudget = "avg-budget"
+)
+
+// sortSLOs orders report rows by --sort so consecutive reports diff cleanly.
+// Budgets sort ascending so the worst SLOs come first; ties fall back to the name and then the key.
+func sortSLOs(slos []*model.SLOData) {
+	slices.SortFunc(slos, func(a, b *model.SLOData) int {
+		var c int
+		switch *sortOrder {
+		case sortMinBudget:
+			c = cmp.Compare(a.MinBudget, b.MinBudget)
+		case sortAvgBudget:
+			c = cmp.Compare(a.AvgBudget, b.AvgBudget)
+		}
+		return cmp.Or(c, cmp.Compare(a.DisplayName, b.DisplayName), cmp.Compare(a.Key, b.Key))
+	})
+}
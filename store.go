@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/store"
+)
+
+// storeRun saves the stats of a run into the --store database under --target, after setting the change of the
+// minimum budget of each SLO since the last run of the target stored there.
+func storeRun(ctx context.Context, path string, startedAt time.Time, data map[string]*model.SLOData) {
+	st, err := store.Open(path)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	defer func() {
+		if err := st.Close(); err != nil {
+			log.Printf("Failed to close %s: %v", path, err)
+		}
+	}()
+
+	last, ok, err := st.Latest(ctx, *targetName)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	if ok {
+		previous, err := st.Stats(ctx, last.ID)
+		if err != nil {
+			log.Panicf("%v", err)
+		}
+		setChanges(data, previous)
+	}
+
+	run, err := st.Save(ctx, *targetName, startedAt, data, len(sloFailures))
+	if err != nil {
+		log.Panicf("Failed to store the run in %s: %v", path, err)
+	}
+	infof("Stored %d SLOs as run %d in %s", run.SLOs, run.ID, path)
+}
+
+// setChanges sets the change of the minimum budget of the SLOs the previous run had over the same window.
+func setChanges(data map[string]*model.SLOData, previous map[string]store.SLOStats) {
+	for _, v := range data {
+		p, ok := previous[v.Key]
+		if !ok || p.Window != v.Window {
+			continue
+		}
+		change := v.MinBudget - p.MinBudget
+		v.MinBudgetChange = &change
+	}
+}
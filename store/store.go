@@ -0,0 +1,292 @@
+// Package store keeps the per-SLO stats of every run in a local SQLite database, so changes between runs and trends
+// over weeks can be computed.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	// The SQLite driver registers itself as "sqlite3".
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rluisr/vigil/model"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at INTEGER NOT NULL,
+	target     TEXT    NOT NULL DEFAULT '',
+	failed     INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS slo_stats (
+	run_id            INTEGER NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	key               TEXT    NOT NULL,
+	display_name      TEXT    NOT NULL,
+	project           TEXT    NOT NULL,
+	team              TEXT    NOT NULL,
+	provider          TEXT    NOT NULL,
+	window            TEXT    NOT NULL,
+	category          TEXT    NOT NULL,
+	flag              INTEGER NOT NULL,
+	slo               REAL    NOT NULL,
+	target_slo        REAL    NOT NULL,
+	min_budget        REAL    NOT NULL,
+	avg_budget        REAL    NOT NULL,
+	negative_fraction REAL    NOT NULL,
+	peak_burn_rate    REAL    NOT NULL,
+	health_score      REAL    NOT NULL,
+	budget_consumed   REAL    NOT NULL,
+	PRIMARY KEY (run_id, key)
+);
+CREATE INDEX IF NOT EXISTS slo_stats_key ON slo_stats (key, run_id);
+`
+
+// Store is a history database opened with Open.
+type Store struct {
+	db *sql.DB
+}
+
+// Run is a stored run. Target names what the run scanned when several targets share the database, such as the
+// targets of vigil serve, and is empty otherwise. Failed is the number of SLOs that failed with --continue-on-error,
+// which have no stats.
+type Run struct {
+	ID        int64     `json:"id"`
+	StartedAt time.Time `json:"startedAt"`
+	Target    string    `json:"target,omitempty"`
+	SLOs      int       `json:"slos"`
+	Failed    int       `json:"failed,omitempty"`
+}
+
+// SLOStats are the stats of an SLO in a stored run.
+type SLOStats struct {
+	RunID            int64               `json:"runId"`
+	StartedAt        time.Time           `json:"startedAt"`
+	Target           string              `json:"target,omitempty"`
+	Key              string              `json:"key"`
+	DisplayName      string              `json:"displayName"`
+	Project          string              `json:"project,omitempty"`
+	Team             string              `json:"team,omitempty"`
+	Provider         model.CloudProvider `json:"provider"`
+	Window           string              `json:"window"`
+	Category         model.Category      `json:"category"`
+	Flag             bool                `json:"flag"`
+	SLO              float64             `json:"slo"`
+	TargetSLO        float64             `json:"targetSlo,omitempty"`
+	MinBudget        float64             `json:"minBudget"`
+	AvgBudget        float64             `json:"avgBudget"`
+	NegativeFraction float64             `json:"negativeFraction"`
+	PeakBurnRate     float64             `json:"peakBurnRate"`
+	HealthScore      float64             `json:"healthScore"`
+	BudgetConsumed   float64             `json:"budgetConsumed"`
+}
+
+// StatsOf returns the stats of an SLO of a report, without the run they belong to.
+func StatsOf(v *model.SLOData) SLOStats {
+	return SLOStats{
+		Key:              v.Key,
+		DisplayName:      v.DisplayName,
+		Project:          v.Project,
+		Team:             v.Team,
+		Provider:         v.Provider,
+		Window:           v.Window,
+		Category:         v.Category,
+		Flag:             v.Flag,
+		SLO:              v.SLO,
+		TargetSLO:        v.TargetSLO,
+		MinBudget:        v.MinBudget,
+		AvgBudget:        v.AvgBudget,
+		NegativeFraction: v.NegativeFraction,
+		PeakBurnRate:     v.PeakBurnRate,
+		HealthScore:      v.HealthScore,
+		BudgetConsumed:   v.BudgetConsumed,
+	}
+}
+
+// Open opens the database at path, creating it and its tables when they do not exist.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create the tables of %s: %w", path, err)
+	}
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to migrate %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// addedColumns are the columns of runs added after its first version, with their definitions.
+var addedColumns = [][2]string{
+	{"target", `TEXT NOT NULL DEFAULT ''`},
+	{"failed", `INTEGER NOT NULL DEFAULT 0`},
+}
+
+// migrate adds the columns of the current schema to the tables of databases created by older versions.
+func migrate(db *sql.DB) error {
+	for _, c := range addedColumns {
+		var exists bool
+		err := db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('runs') WHERE name = ?`, c[0]).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE runs ADD COLUMN ` + c[0] + ` ` + c[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save stores the stats of every SLO of a run of target that started at startedAt, in a single transaction, with the
+// number of SLOs that failed.
+func (s *Store) Save(ctx context.Context, target string, startedAt time.Time, data map[string]*model.SLOData, failed int) (Run, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to begin a transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO runs (started_at, target, failed) VALUES (?, ?, ?)`, startedAt.Unix(), target, failed)
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to save the run: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to save the run: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO slo_stats (
+		run_id, key, display_name, project, team, provider, window, category, flag, slo, target_slo,
+		min_budget, avg_budget, negative_fraction, peak_burn_rate, health_score, budget_consumed
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return Run{}, fmt.Errorf("failed to save the SLO stats: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, v := range data {
+		st := StatsOf(v)
+		_, err := stmt.ExecContext(ctx, id, st.Key, st.DisplayName, st.Project, st.Team, string(st.Provider), st.Window,
+			string(st.Category), st.Flag, st.SLO, st.TargetSLO, st.MinBudget, st.AvgBudget, st.NegativeFraction,
+			st.PeakBurnRate, st.HealthScore, st.BudgetConsumed)
+		if err != nil {
+			return Run{}, fmt.Errorf("failed to save the stats of %s: %w", v.DisplayName, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Run{}, fmt.Errorf("failed to commit the run: %w", err)
+	}
+	return Run{ID: id, StartedAt: time.Unix(startedAt.Unix(), 0), Target: target, SLOs: len(data), Failed: failed}, nil
+}
+
+// Runs returns the stored runs, oldest first.
+func (s *Store) Runs(ctx context.Context) ([]Run, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.id, r.started_at, r.target, COUNT(st.key), r.failed
+		FROM runs r LEFT JOIN slo_stats st ON st.run_id = r.id
+		GROUP BY r.id ORDER BY r.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []Run
+	for rows.Next() {
+		var (
+			run       Run
+			startedAt int64
+		)
+		if err := rows.Scan(&run.ID, &startedAt, &run.Target, &run.SLOs, &run.Failed); err != nil {
+			return nil, fmt.Errorf("failed to read the runs: %w", err)
+		}
+		run.StartedAt = time.Unix(startedAt, 0)
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// Latest returns the last stored run of target. ok is false when no run of it is stored yet.
+func (s *Store) Latest(ctx context.Context, target string) (run Run, ok bool, err error) {
+	var startedAt int64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT r.id, r.started_at, r.target, COUNT(st.key), r.failed
+		FROM runs r LEFT JOIN slo_stats st ON st.run_id = r.id
+		WHERE r.target = ?
+		GROUP BY r.id ORDER BY r.id DESC LIMIT 1`, target).Scan(&run.ID, &startedAt, &run.Target, &run.SLOs, &run.Failed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Run{}, false, nil
+	}
+	if err != nil {
+		return Run{}, false, fmt.Errorf("failed to read the last run: %w", err)
+	}
+	run.StartedAt = time.Unix(startedAt, 0)
+	return run, true, nil
+}
+
+// Stats returns the stats of the SLOs of a run by key.
+func (s *Store) Stats(ctx context.Context, runID int64) (map[string]SLOStats, error) {
+	stats, err := s.query(ctx, `WHERE st.run_id = ?`, runID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]SLOStats, len(stats))
+	for _, st := range stats {
+		byKey[st.Key] = st
+	}
+	return byKey, nil
+}
+
+// History returns the stats of the SLOs whose key or display name is name in every run that has them since since,
+// oldest first, for trends over weeks. Display names are not unique, so the stats can be of several SLOs.
+func (s *Store) History(ctx context.Context, name string, since time.Time) ([]SLOStats, error) {
+	return s.query(ctx, `WHERE (st.key = ? OR st.display_name = ?) AND r.started_at >= ?`, name, name, since.Unix())
+}
+
+// Since returns the stats of every SLO in every run since since, oldest first.
+func (s *Store) Since(ctx context.Context, since time.Time) ([]SLOStats, error) {
+	return s.query(ctx, `WHERE r.started_at >= ?`, since.Unix())
+}
+
+// query reads the SLO stats matching where, in the order of their runs.
+func (s *Store) query(ctx context.Context, where string, args ...interface{}) ([]SLOStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT st.run_id, r.started_at, r.target, st.key, st.display_name, st.project, st.team, st.provider, st.window,
+			st.category, st.flag, st.slo, st.target_slo, st.min_budget, st.avg_budget, st.negative_fraction,
+			st.peak_burn_rate, st.health_score, st.budget_consumed
+		FROM slo_stats st JOIN runs r ON r.id = st.run_id `+where+` ORDER BY st.run_id, st.key`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the SLO stats: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []SLOStats
+	for rows.Next() {
+		var (
+			st        SLOStats
+			startedAt int64
+		)
+		err := rows.Scan(&st.RunID, &startedAt, &st.Target, &st.Key, &st.DisplayName, &st.Project, &st.Team, &st.Provider,
+			&st.Window, &st.Category, &st.Flag, &st.SLO, &st.TargetSLO, &st.MinBudget, &st.AvgBudget,
+			&st.NegativeFraction, &st.PeakBurnRate, &st.HealthScore, &st.BudgetConsumed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the SLO stats: %w", err)
+		}
+		st.StartedAt = time.Unix(startedAt, 0)
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
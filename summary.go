@@ -0,0 +1,148 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+)
+
+// reportSummary is the headline block shown at the top of every report.
+type reportSummary struct {
+	Scanned     int       `json:"scanned"`
+	Flagged     int       `json:"flagged"`
+	TooLax      int       `json:"tooLax"`
+	Burning     int       `json:"burning"`
+	Worst       string    `json:"worst,omitempty"`
+	WorstBudget float64   `json:"worstBudget"`
+	Window      string    `json:"window"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Failed is the number of SLOs that could not be processed with --continue-on-error.
+	Failed int `json:"failed"`
+	// Skipped is the number of SLOs left out because the run was cancelled. The report is incomplete when it is positive.
+	Skipped    int  `json:"skipped"`
+	Incomplete bool `json:"incomplete"`
+	// FastBurn is the number of SLOs whose peak burn rate reached the burn rate threshold, 0 when it is disabled.
+	FastBurn int `json:"fastBurn"`
+	// Degrading is the number of SLOs flagged as degrading, 0 unless --flag-degrading is set.
+	Degrading int `json:"degrading"`
+	// Exhausting is the number of SLOs flagged as exhausting, 0 unless --flag-exhaustion is set.
+	Exhausting int `json:"exhausting"`
+	// Healthy and NoData count the SLOs of those categories.
+	Healthy int `json:"healthy"`
+	NoData  int `json:"noData"`
+	// IncidentDriven is the number of flagged SLOs whose spending is driven by a single incident, with --detect-anomalies.
+	IncidentDriven int `json:"incidentDriven"`
+	// Teams rolls the SLOs up by owning team, empty when no SLO has one.
+	Teams []teamSummary `json:"teams,omitempty"`
+}
+
+// summarize aggregates the report data. Too lax, healthy and no data SLOs are counted by category. An SLO is burning
+// when the budget was negative for at least its negative budget fraction of the window and burning fast when its
+// peak burn rate reached the burn rate threshold; degrading and exhausting ones are counted when the audit flagged
+// them. The checks and flags are the ones of auditOptions. The worst SLO has the lowest minimum budget.
+func summarize(data map[string]*model.SLOData, generatedAt time.Time) reportSummary {
+	s := reportSummary{
+		Scanned:     len(data),
+		Window:      window.String(),
+		GeneratedAt: generatedAt,
+		Failed:      len(sloFailures),
+		Skipped:     skippedSLOs,
+		Incomplete:  skippedSLOs > 0,
+		Teams:       summarizeTeams(data),
+	}
+
+	var worst *model.SLOData
+	for _, v := range data {
+		if v.Flag {
+			s.Flagged++
+		}
+		switch v.Category {
+		case model.CategoryLax:
+			s.TooLax++
+		case model.CategoryHealthy:
+			s.Healthy++
+		case model.CategoryNoData:
+			s.NoData++
+		}
+		if burning(v) {
+			s.Burning++
+		}
+		if fastBurn(v) {
+			s.FastBurn++
+		}
+		if trendingToExhaustion(v) {
+			s.Degrading++
+		}
+		if forecastExhaustion(v) {
+			s.Exhausting++
+		}
+		if v.Flag && v.IncidentDriven {
+			s.IncidentDriven++
+		}
+		if worst == nil || cmp.Or(cmp.Compare(v.MinBudget, worst.MinBudget), cmp.Compare(v.DisplayName, worst.DisplayName)) < 0 {
+			worst = v
+		}
+	}
+	if worst != nil {
+		s.Worst = worst.DisplayName
+		s.WorstBudget = worst.MinBudget
+	}
+
+	return s
+}
+
+// rows returns the summary as localized label and value pairs for the tabular reports.
+func (s reportSummary) rows(msgs *i18n.Messages) [][2]string {
+	percentOf := func(n int) string {
+		if s.Scanned == 0 {
+			return strconv.Itoa(n)
+		}
+		return fmt.Sprintf("%d (%.1f%%)", n, float64(n)/float64(s.Scanned)*100)
+	}
+
+	var rows [][2]string
+	if s.Incomplete {
+		rows = append(rows, [2]string{msgs.SummaryIncomplete, fmt.Sprintf(msgs.SummarySkipped, s.Skipped)})
+	}
+	rows = append(rows,
+		[2]string{msgs.SummaryScanned, strconv.Itoa(s.Scanned)},
+		[2]string{msgs.SummaryFlagged, percentOf(s.Flagged)},
+		[2]string{msgs.SummaryTooLax, percentOf(s.TooLax)},
+		[2]string{msgs.SummaryBurning, percentOf(s.Burning)},
+		[2]string{msgs.SummaryHealthy, percentOf(s.Healthy)},
+	)
+	if s.NoData > 0 {
+		rows = append(rows, [2]string{msgs.SummaryNoData, percentOf(s.NoData)})
+	}
+	if auditOptions.DetectAnomalies {
+		rows = append(rows, [2]string{msgs.SummaryIncidentDriven, percentOf(s.IncidentDriven)})
+	}
+	if auditOptions.BurnRateThreshold > 0 {
+		rows = append(rows, [2]string{msgs.SummaryFastBurn, percentOf(s.FastBurn)})
+	}
+	if auditOptions.FlagDegrading {
+		rows = append(rows, [2]string{msgs.SummaryDegrading, percentOf(s.Degrading)})
+	}
+	if auditOptions.FlagExhaustion {
+		rows = append(rows, [2]string{msgs.SummaryExhaustion, percentOf(s.Exhausting)})
+	}
+	if s.Failed > 0 {
+		rows = append(rows, [2]string{msgs.SummaryFailed, strconv.Itoa(s.Failed)})
+	}
+	for _, t := range s.Teams {
+		rows = append(rows, [2]string{fmt.Sprintf(msgs.SummaryTeam, teamName(t.Team, msgs)), fmt.Sprintf(msgs.Summary, t.Flagged, t.Scanned)})
+	}
+	if s.Worst != "" {
+		rows = append(rows, [2]string{msgs.SummaryWorst, fmt.Sprintf("%s (%.2f%%)", s.Worst, s.WorstBudget*100)})
+	}
+	rows = append(rows,
+		[2]string{msgs.SummaryWindow, fmt.Sprintf(msgs.SummaryWindowDays, window.Hours()/24)},
+		[2]string{msgs.SummaryGeneratedAt, s.GeneratedAt.Format(time.RFC3339)},
+	)
+
+	return rows
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+)
+
+// ANSI escape sequences used to colorize the terminal table.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// generateTableReport prints the flagged SLOs as an aligned table to out, colorized when out is a terminal.
+// Rows are red for burning SLOs and yellow for too lax ones.
+func generateTableReport(data map[string]*model.SLOData, msgs *i18n.Messages, out *os.File) {
+	var flagged []*model.SLOData
+	for _, v := range data {
+		if v.Flag {
+			flagged = append(flagged, v)
+		}
+	}
+	sortSLOs(flagged)
+
+	percent := func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) }
+	rows := [][]string{{msgs.HeaderName, msgs.HeaderProject, msgs.HeaderSLO, msgs.HeaderSLIMin, msgs.HeaderSLIAvg, msgs.HeaderNegative, msgs.HeaderPeakBurnRate}}
+	for _, v := range flagged {
+		rows = append(rows, []string{v.DisplayName, v.Project, percent(v.SLO), percent(v.MinBudget), percent(v.AvgBudget), percent(v.NegativeFraction), fmt.Sprintf("%.1fx", v.PeakBurnRate)})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	color := useColor(out)
+	for i, row := range rows {
+		var style string
+		switch {
+		case i == 0:
+			style = ansiBold
+		case flagged[i-1].Category == model.CategoryBurning:
+			style = ansiRed
+		default:
+			style = ansiYellow
+		}
+		writeTableRow(out, row, widths, style, color)
+	}
+
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, msgs.Summary+"\n", len(flagged), len(data))
+	if skippedSLOs > 0 {
+		fmt.Fprintf(out, msgs.SummarySkipped+"\n", skippedSLOs)
+	}
+	for _, e := range sortedFailures() {
+		fmt.Fprintf(out, "%s: %s: %s\n", msgs.HeaderError, e.DisplayName, e.Error)
+	}
+}
+
+func writeTableRow(w io.Writer, row []string, widths []int, style string, color bool) {
+	var b strings.Builder
+	for i, cell := range row {
+		if i > 0 {
+			b.WriteString("  ")
+		}
+		padding := strings.Repeat(" ", widths[i]-displayWidth(cell))
+		// The name and project columns are left aligned, the percentages right aligned.
+		if i < 2 {
+			b.WriteString(cell + padding)
+		} else {
+			b.WriteString(padding + cell)
+		}
+	}
+
+	line := b.String()
+	if color && style != "" {
+		line = style + line + ansiReset
+	}
+	fmt.Fprintln(w, line)
+}
+
+// useColor reports whether f is a terminal and the NO_COLOR convention (https://no-color.org) is not set.
+func useColor(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// displayWidth approximates the number of terminal columns s occupies, counting CJK and full-width runes as two.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r >= 0x2E80 && r <= 0xFFEF {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/rluisr/vigil/i18n"
+	"github.com/rluisr/vigil/model"
+)
+
+// Values of --group-by.
+const (
+	groupByProject = "project"
+	groupByTeam    = "team"
+)
+
+// teamSummary is the rollup of the SLOs owned by one team, empty for the SLOs without an owner.
+type teamSummary struct {
+	Team    string `json:"team"`
+	Scanned int    `json:"scanned"`
+	Flagged int    `json:"flagged"`
+	TooLax  int    `json:"tooLax"`
+	Burning int    `json:"burning"`
+}
+
+// teamName returns the name a team is reported under.
+func teamName(team string, msgs *i18n.Messages) string {
+	return cmp.Or(team, msgs.TeamUnowned)
+}
+
+// summarizeTeams rolls the SLOs up by team, the SLOs without an owner last. It returns nil when no SLO has an owner.
+func summarizeTeams(data map[string]*model.SLOData) []teamSummary {
+	byTeam := make(map[string]*teamSummary)
+	owned := false
+	for _, v := range data {
+		s := byTeam[v.Team]
+		if s == nil {
+			s = &teamSummary{Team: v.Team}
+			byTeam[v.Team] = s
+		}
+		s.Scanned++
+		if v.Flag {
+			s.Flagged++
+		}
+		switch v.Category {
+		case model.CategoryLax:
+			s.TooLax++
+		case model.CategoryBurning:
+			s.Burning++
+		}
+		owned = owned || v.Team != ""
+	}
+	if !owned {
+		return nil
+	}
+
+	teams := make([]teamSummary, 0, len(byTeam))
+	for _, s := range byTeam {
+		teams = append(teams, *s)
+	}
+	slices.SortFunc(teams, func(a, b teamSummary) int {
+		switch {
+		case a.Team == "" && b.Team != "":
+			return 1
+		case a.Team != "" && b.Team == "":
+			return -1
+		default:
+			return cmp.Compare(a.Team, b.Team)
+		}
+	})
+	return teams
+}
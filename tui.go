@@ -0,0 +1,393 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/report"
+	"github.com/rluisr/vigil/utils"
+)
+
+// Keys understood by the TUI, decoded from the raw terminal input.
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyPageUp    = "pgup"
+	keyPageDown  = "pgdown"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyCtrlC     = "ctrl+c"
+)
+
+// sparkBlocks draw the budget series, from the lowest to the highest value.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+const tuiHelp = "↑/↓ move  space select  a select all  / filter  f flagged only  e export  q quit"
+
+// tuiModel is the state of the interactive SLO browser.
+type tuiModel struct {
+	slos        []*model.SLOData
+	visible     []*model.SLOData
+	selected    map[string]bool
+	cursor      int
+	offset      int
+	filter      string
+	filtering   bool
+	flaggedOnly bool
+	status      string
+	// export writes the given SLOs and returns a status line.
+	export func([]*model.SLOData) string
+}
+
+// runTUI browses the scanned SLOs until the user quits. Rows can be exported with the --format and --output of the run.
+func runTUI(data map[string]*model.SLOData, spec *report.Spec) {
+	slos := make([]*model.SLOData, 0, len(data))
+	for _, v := range data {
+		slos = append(slos, v)
+	}
+	sortSLOs(slos)
+
+	m := &tuiModel{
+		slos:     slos,
+		selected: make(map[string]bool),
+		export:   func(rows []*model.SLOData) string { return exportRows(rows, spec) },
+	}
+	m.applyFilter()
+
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		log.Panicf("Failed to enter raw mode: %v", err)
+	}
+	// Alternate screen and hidden cursor, restored on the way out even when an export panics.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		if err := term.Restore(fd, state); err != nil {
+			log.Printf("Failed to restore terminal: %v", err)
+		}
+	}()
+
+	buf := make([]byte, 32)
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		// Raw mode disables the translation of "\n", so every line ends with an explicit carriage return.
+		fmt.Print("\x1b[H\x1b[2J" + strings.ReplaceAll(m.view(width, height), "\n", "\r\n"))
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		if m.handleKey(decodeKey(buf[:n]), height) {
+			return
+		}
+	}
+}
+
+// decodeKey maps raw terminal input to a key name, or the typed text.
+func decodeKey(b []byte) string {
+	switch string(b) {
+	case "\x1b[A", "\x1bOA":
+		return keyUp
+	case "\x1b[B", "\x1bOB":
+		return keyDown
+	case "\x1b[5~":
+		return keyPageUp
+	case "\x1b[6~":
+		return keyPageDown
+	case "\r", "\n":
+		return keyEnter
+	case "\x1b":
+		return keyEscape
+	case "\x7f", "\b":
+		return keyBackspace
+	case "\x03":
+		return keyCtrlC
+	}
+	return string(b)
+}
+
+// handleKey updates the model and reports whether the TUI should quit.
+func (m *tuiModel) handleKey(key string, height int) bool {
+	if key == keyCtrlC {
+		return true
+	}
+
+	if m.filtering {
+		switch key {
+		case keyEnter, keyEscape:
+			m.filtering = false
+		case keyBackspace:
+			if m.filter != "" {
+				_, size := utf8.DecodeLastRuneInString(m.filter)
+				m.filter = m.filter[:len(m.filter)-size]
+			}
+		default:
+			if !strings.HasPrefix(key, "\x1b") && utf8.ValidString(key) {
+				m.filter += key
+			}
+		}
+		m.applyFilter()
+		return false
+	}
+
+	m.status = ""
+	page := max(m.listHeight(height), 1)
+	switch key {
+	case "q", keyEscape:
+		return true
+	case keyUp, "k":
+		m.cursor--
+	case keyDown, "j":
+		m.cursor++
+	case keyPageUp:
+		m.cursor -= page
+	case keyPageDown:
+		m.cursor += page
+	case "g":
+		m.cursor = 0
+	case "G":
+		m.cursor = len(m.visible) - 1
+	case "/":
+		m.filtering = true
+	case "f":
+		m.flaggedOnly = !m.flaggedOnly
+		m.applyFilter()
+	case " ":
+		if v := m.current(); v != nil {
+			m.selected[v.Key] = !m.selected[v.Key]
+		}
+	case "a":
+		all := true
+		for _, v := range m.visible {
+			all = all && m.selected[v.Key]
+		}
+		for _, v := range m.visible {
+			m.selected[v.Key] = !all
+		}
+	case "e":
+		m.status = m.export(m.exportRows())
+	}
+	m.clampCursor(page)
+	return false
+}
+
+// applyFilter recomputes the visible rows from the filter text, matched case-insensitively against the name and project.
+func (m *tuiModel) applyFilter() {
+	needle := strings.ToLower(m.filter)
+	m.visible = m.visible[:0]
+	for _, v := range m.slos {
+		if m.flaggedOnly && !v.Flag {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(v.DisplayName+" "+v.Project), needle) {
+			continue
+		}
+		m.visible = append(m.visible, v)
+	}
+	m.clampCursor(0)
+}
+
+func (m *tuiModel) clampCursor(page int) {
+	m.cursor = max(min(m.cursor, len(m.visible)-1), 0)
+	if page <= 0 {
+		return
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+page {
+		m.offset = m.cursor - page + 1
+	}
+}
+
+func (m *tuiModel) current() *model.SLOData {
+	if m.cursor >= len(m.visible) {
+		return nil
+	}
+	return m.visible[m.cursor]
+}
+
+// exportRows returns the selected rows, or every visible row when nothing is selected.
+func (m *tuiModel) exportRows() []*model.SLOData {
+	var rows []*model.SLOData
+	for _, v := range m.slos {
+		if m.selected[v.Key] {
+			rows = append(rows, v)
+		}
+	}
+	if len(rows) == 0 {
+		return m.visible
+	}
+	return rows
+}
+
+// listHeight is the number of rows left for the SLO list after the header, detail pane and footer.
+func (m *tuiModel) listHeight(height int) int {
+	return height - 8
+}
+
+func (m *tuiModel) view(width, height int) string {
+	var b strings.Builder
+
+	flagged := 0
+	for _, v := range m.slos {
+		if v.Flag {
+			flagged++
+		}
+	}
+	title := fmt.Sprintf("vigil  %d SLOs, %d flagged, %d shown, %d selected", len(m.slos), flagged, len(m.visible), m.selectedCount())
+	if m.flaggedOnly {
+		title += "  [flagged only]"
+	}
+	b.WriteString(ansiBold + truncateWidth(title, width) + ansiReset + "\n")
+
+	nameWidth := max(width-46, 10)
+	header := fmt.Sprintf("   %-*s %-12s %9s %9s %9s", nameWidth, "NAME", "PROJECT", "SLO", "MIN", "AVG")
+	b.WriteString(truncateWidth(header, width) + "\n")
+
+	rows := m.listHeight(height)
+	for i := m.offset; i < len(m.visible) && i < m.offset+rows; i++ {
+		v := m.visible[i]
+		mark := " "
+		if m.selected[v.Key] {
+			mark = "*"
+		}
+		flag := " "
+		if v.Flag {
+			flag = "!"
+		}
+		line := fmt.Sprintf("%s%s %s %-12s %8.3f%% %8.2f%% %8.2f%%", mark, flag, padWidth(v.DisplayName, nameWidth),
+			truncateWidth(v.Project, 12), v.SLO*100, v.MinBudget*100, v.AvgBudget*100)
+		line = truncateWidth(line, width)
+		if i == m.cursor {
+			line = "\x1b[7m" + line + ansiReset
+		}
+		b.WriteString(line + "\n")
+	}
+	for i := len(m.visible) - m.offset; i < rows; i++ {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(strings.Repeat("─", max(width, 1)) + "\n")
+	if v := m.current(); v != nil {
+		b.WriteString(truncateWidth(fmt.Sprintf("%s  threshold %g%%  window %s  negative %.1f%%", v.DisplayName, v.ErrorBudgetThreshold*100, v.Window, v.NegativeFraction*100), width) + "\n")
+		b.WriteString(sparklineText(v.Points, max(width, 1)) + "\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case m.filtering:
+		b.WriteString(truncateWidth("/"+m.filter+"█", width))
+	case m.status != "":
+		b.WriteString(truncateWidth(m.status, width))
+	case m.filter != "":
+		b.WriteString(truncateWidth("filter: "+m.filter+"  "+tuiHelp, width))
+	default:
+		b.WriteString(truncateWidth(tuiHelp, width))
+	}
+
+	return b.String()
+}
+
+func (m *tuiModel) selectedCount() int {
+	n := 0
+	for _, ok := range m.selected {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// sparklineText renders the budget series as block characters, scaled between its lowest and highest point.
+func sparklineText(points []float64, width int) string {
+	sampled := utils.Downsample(points, width)
+	if len(sampled) == 0 {
+		return ""
+	}
+
+	lo, hi := sampled[0], sampled[0]
+	for _, p := range sampled {
+		lo, hi = min(lo, p), max(hi, p)
+	}
+
+	var b strings.Builder
+	for _, p := range sampled {
+		i := 0
+		if hi > lo {
+			i = int((p - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// exportRows writes rows with --format and --output, falling back to JSON for the formats printed to stdout,
+// which would corrupt the screen.
+func exportRows(rows []*model.SLOData, spec *report.Spec) string {
+	if len(rows) == 0 {
+		return "Nothing to export"
+	}
+
+	reportFormat := *format
+	if reportFormat == formatTable || reportFormat == formatGitHub {
+		reportFormat = formatJSON
+	}
+
+	data := make(map[string]*model.SLOData, len(rows))
+	slos := make([]*model.SLO, 0, len(rows))
+	for _, v := range rows {
+		data[v.Key] = v
+		slos = append(slos, &model.SLO{Project: v.Project})
+	}
+
+	formatFlag := *format
+	*format = reportFormat
+	path, err := outputPath(slos, time.Now())
+	*format = formatFlag
+	if err != nil {
+		return err.Error()
+	}
+
+	if err := newReporter(reportFormat, spec, path).Write(data); err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("Exported %d SLOs to %s", len(rows), path)
+}
+
+// truncateWidth cuts s to at most width terminal columns.
+func truncateWidth(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	var b strings.Builder
+	w := 0
+	for _, r := range s {
+		rw := displayWidth(string(r))
+		if w+rw > width {
+			break
+		}
+		b.WriteRune(r)
+		w += rw
+	}
+	return b.String()
+}
+
+// padWidth truncates or pads s to exactly width terminal columns.
+func padWidth(s string, width int) string {
+	s = truncateWidth(s, width)
+	return s + strings.Repeat(" ", width-displayWidth(s))
+}
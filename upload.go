@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rluisr/vigil/upload"
+)
+
+// uploadReport uploads the report of a run that started at startedAt to the --upload destination.
+func uploadReport(ctx context.Context, dest, path string, startedAt time.Time) {
+	d, err := upload.Parse(dest)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	url, err := upload.Upload(ctx, d, path, startedAt)
+	if err != nil {
+		log.Panicf("%v", err)
+	}
+	infof("Report has been uploaded to %s", url)
+}
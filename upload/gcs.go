@@ -0,0 +1,27 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	storage "google.golang.org/api/storage/v1"
+)
+
+// uploadGCS uploads a file to Cloud Storage with Application Default Credentials, which need
+// roles/storage.objectCreator on the bucket.
+func uploadGCS(ctx context.Context, bucket, object, file, contentType string) error {
+	svc, err := storage.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud Storage client: %w", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = svc.Objects.Insert(bucket, &storage.Object{Name: object, ContentType: contentType}).Media(f).Context(ctx).Do()
+	return err
+}
@@ -0,0 +1,82 @@
+// Package upload copies report files to object storage, Google Cloud Storage (gs://) and Amazon S3 or S3 compatible
+// storage (s3://), under a prefix of the date and time of the run.
+package upload
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Supported destination schemes.
+const (
+	SchemeGCS = "gs"
+	SchemeS3  = "s3"
+)
+
+// Destination is a bucket and the prefix of the objects uploaded to it, parsed from gs://bucket/prefix/ or
+// s3://bucket/prefix/.
+type Destination struct {
+	Scheme string
+	Bucket string
+	Prefix string
+}
+
+// Parse parses a gs:// or s3:// destination URL. The prefix is treated as a directory whether or not it ends with a
+// slash.
+func Parse(dest string) (Destination, error) {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return Destination{}, fmt.Errorf("invalid upload destination %s: %w", dest, err)
+	}
+	if u.Scheme != SchemeGCS && u.Scheme != SchemeS3 {
+		return Destination{}, fmt.Errorf("invalid upload destination %s: use gs://bucket/path/ or s3://bucket/path/", dest)
+	}
+	if u.Host == "" {
+		return Destination{}, fmt.Errorf("invalid upload destination %s: no bucket", dest)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return Destination{Scheme: u.Scheme, Bucket: u.Host, Prefix: prefix}, nil
+}
+
+// String returns the destination as a URL.
+func (d Destination) String() string {
+	return fmt.Sprintf("%s://%s/%s", d.Scheme, d.Bucket, d.Prefix)
+}
+
+// Object returns the name of the object a file is uploaded to for a run started at startedAt, under the prefix and
+// the local date and time of the run, e.g. reports/2026-10-16/093000/slo_report.xlsx.
+func (d Destination) Object(file string, startedAt time.Time) string {
+	return d.Prefix + path.Join(startedAt.Format("2006-01-02"), startedAt.Format("150405"), filepath.Base(file))
+}
+
+// Upload uploads a file for a run started at startedAt and returns the URL of the object.
+func Upload(ctx context.Context, d Destination, file string, startedAt time.Time) (string, error) {
+	object := d.Object(file, startedAt)
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	var err error
+	switch d.Scheme {
+	case SchemeGCS:
+		err = uploadGCS(ctx, d.Bucket, object, file, contentType)
+	case SchemeS3:
+		err = uploadS3(ctx, d.Bucket, object, file, contentType)
+	default:
+		err = fmt.Errorf("unsupported scheme %s", d.Scheme)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s to %s://%s/%s: %w", file, d.Scheme, d.Bucket, object, err)
+	}
+	return fmt.Sprintf("%s://%s/%s", d.Scheme, d.Bucket, object), nil
+}
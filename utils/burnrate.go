@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// BurnRates returns the burn rate over lookback ending at each point of a chronological error budget series sampled
+// every step. A burn rate of 1 consumes the whole budget in exactly one window; 14.4 over 1h, as in the Google SRE
+// workbook, consumes 2% of a 30 day budget in that hour.
+//
+// The i-th rate ends at points[i+k], where k is the number of steps in lookback. It returns nil when the series is
+// shorter than lookback or sampled more coarsely than it.
+func BurnRates(points []float64, step, window, lookback time.Duration) []float64 {
+	if step <= 0 || window <= 0 {
+		return nil
+	}
+	k := int(math.Round(float64(lookback) / float64(step)))
+	if k < 1 || k >= len(points) {
+		return nil
+	}
+
+	// The budget consumed during the lookback, relative to the share of the window it covers.
+	scale := float64(window) / (float64(k) * float64(step))
+	rates := make([]float64, 0, len(points)-k)
+	for i := k; i < len(points); i++ {
+		rates = append(rates, (points[i-k]-points[i])*scale)
+	}
+	return rates
+}
+
+// PeakBurnRate returns the highest rate of a BurnRates series, 0 when it is empty.
+func PeakBurnRate(rates []float64) float64 {
+	peak := 0.0
+	for _, r := range rates {
+		peak = max(peak, r)
+	}
+	return peak
+}
+
+// MultiWindowPeak returns the highest burn rate sustained over both a short and a long lookback ending at the same
+// point, the multiwindow condition of the Google SRE workbook that ignores both brief spikes and burns that already
+// stopped. Both series must come from BurnRates over the same points.
+func MultiWindowPeak(short, long []float64) float64 {
+	n := min(len(short), len(long))
+	short, long = short[len(short)-n:], long[len(long)-n:]
+
+	peak := 0.0
+	for i := range n {
+		peak = max(peak, min(short[i], long[i]))
+	}
+	return peak
+}
+
+// TimeToExhaustion returns how long the remaining budget lasts at burnRate. It returns 0 when the budget is already
+// exhausted and false when it is not being consumed, or so slowly that the time does not fit in a time.Duration.
+func TimeToExhaustion(remaining, burnRate float64, window time.Duration) (time.Duration, bool) {
+	if remaining <= 0 {
+		return 0, true
+	}
+	if burnRate <= 0 {
+		return 0, false
+	}
+	d := remaining / burnRate * float64(window)
+	if d >= math.MaxInt64 {
+		return 0, false
+	}
+	return time.Duration(d), true
+}
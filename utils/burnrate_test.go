@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeToExhaustion(t *testing.T) {
+	const window = 720 * time.Hour
+
+	tests := []struct {
+		name      string
+		remaining float64
+		burnRate  float64
+		want      time.Duration
+		ok        bool
+	}{
+		{name: "one window", remaining: 1, burnRate: 1, want: window, ok: true},
+		{name: "fast burn", remaining: 0.5, burnRate: 14.4, want: 25 * time.Hour, ok: true},
+		{name: "exhausted", remaining: -0.2, burnRate: 2, want: 0, ok: true},
+		{name: "not consumed", remaining: 0.8, burnRate: 0},
+		{name: "recovering", remaining: 0.8, burnRate: -1},
+		// Far beyond the longest time.Duration, about 292 years.
+		{name: "tiny burn rate", remaining: 0.95, burnRate: 1e-12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TimeToExhaustion(tt.remaining, tt.burnRate, window)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("TimeToExhaustion(%g, %g, %s) = %s, %t, want %s, %t", tt.remaining, tt.burnRate, window, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
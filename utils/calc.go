@@ -1,7 +1,11 @@
 // Package utils provides utility functions for error budget calculations.
 package utils
 
-import "math"
+import (
+	"cmp"
+	"math"
+	"slices"
+)
 
 // GetMinAvgErrorBudget returns the minimum and average error budget from a slice of data points.
 func GetMinAvgErrorBudget(points []float64) (minValue float64, avgValue float64) {
@@ -20,6 +24,42 @@ func GetMinAvgErrorBudget(points []float64) (minValue float64, avgValue float64)
 	return minValue, avgValue / float64(len(points))
 }
 
+// WeightedMean returns the mean of data weighted by weights, which has the same length. ok is false when the lengths
+// differ or the weights sum to 0.
+func WeightedMean(data, weights []float64) (mean float64, ok bool) {
+	if len(data) != len(weights) {
+		return 0, false
+	}
+
+	sum, total := 0.0, 0.0
+	for i, x := range data {
+		sum += x * weights[i]
+		total += weights[i]
+	}
+	if total <= 0 {
+		return 0, false
+	}
+	return sum / total, true
+}
+
+// TrimLowest returns the indices of data left after dropping its lowest fraction of values, in their original order.
+// The number of values dropped is rounded down, so a fraction too small for data drops none.
+func TrimLowest(data []float64, fraction float64) []int {
+	order := make([]int, len(data))
+	for i := range order {
+		order[i] = i
+	}
+	n := int(fraction * float64(len(data)))
+	if n <= 0 {
+		return order
+	}
+
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(data[a], data[b]) })
+	kept := order[n:]
+	slices.Sort(kept)
+	return kept
+}
+
 // IsPercentNegative returns true if the percentage of negative values in data meets or exceeds percent.
 func IsPercentNegative(data []float64, percent float64) bool {
 	if percent < 0 || percent > 1 {
@@ -30,6 +70,15 @@ func IsPercentNegative(data []float64, percent float64) bool {
 		return false
 	}
 
+	return NegativeFraction(data) >= percent
+}
+
+// NegativeFraction returns the fraction of negative values in data, 0 ~ 1.
+func NegativeFraction(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
 	negativeCount := 0
 	for _, num := range data {
 		if num < 0 {
@@ -37,5 +86,169 @@ func IsPercentNegative(data []float64, percent float64) bool {
 		}
 	}
 
-	return float64(negativeCount)/float64(len(data)) >= percent
+	return float64(negativeCount) / float64(len(data))
+}
+
+// Downsample returns at most n evenly spaced points of data, always keeping the first one.
+func Downsample(data []float64, n int) []float64 {
+	if n <= 0 || len(data) <= n {
+		return data
+	}
+
+	step := int(math.Ceil(float64(len(data)) / float64(n)))
+	sampled := make([]float64, 0, n)
+	for i := 0; i < len(data); i += step {
+		sampled = append(sampled, data[i])
+	}
+	return sampled
+}
+
+// Quantiles returns the q-quantiles of data, 0 ~ 1, interpolated linearly between the closest points.
+// data is left unsorted. It returns zeros when data is empty.
+func Quantiles(data []float64, qs ...float64) []float64 {
+	result := make([]float64, len(qs))
+	if len(data) == 0 {
+		return result
+	}
+
+	sorted := slices.Clone(data)
+	slices.Sort(sorted)
+	for i, q := range qs {
+		pos := q * float64(len(sorted)-1)
+		lo := int(math.Floor(pos))
+		hi := min(lo+1, len(sorted)-1)
+		result[i] = sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+	}
+	return result
+}
+
+// LinearRegression returns the least squares line through data, with x the index of each point.
+// The slope is 0 when data has fewer than two points.
+func LinearRegression(data []float64) (slope, intercept float64) {
+	n := float64(len(data))
+	if len(data) < 2 {
+		_, avg := GetMinAvgErrorBudget(data)
+		return 0, avg
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range data {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	slope = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	return slope, (sumY - slope*sumX) / n
+}
+
+// Holt smooths data with Holt's linear method, double exponential smoothing, and returns the level and the trend
+// per point at the last point. The forecast h points ahead is level + h*trend. alpha and beta, 0 ~ 1, weigh recent
+// points for the level and the trend.
+func Holt(data []float64, alpha, beta float64) (level, trend float64) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	if len(data) == 1 {
+		return data[0], 0
+	}
+
+	level, trend = data[0], data[1]-data[0]
+	for _, y := range data[1:] {
+		previous := level
+		level = alpha*y + (1-alpha)*(level+trend)
+		trend = beta*(level-previous) + (1-beta)*trend
+	}
+	return level, trend
+}
+
+// Outliers marks the points of data whose modified z-score, based on the median absolute deviation, exceeds threshold.
+// 3.5 is the usual threshold. When more than half of the points are equal the MAD is 0, so the mean absolute
+// deviation is used instead; nothing is an outlier when both are 0.
+func Outliers(data []float64, threshold float64) []bool {
+	outliers := make([]bool, len(data))
+	if len(data) == 0 {
+		return outliers
+	}
+
+	median := Quantiles(data, 0.5)[0]
+	deviations := make([]float64, len(data))
+	meanDeviation := 0.0
+	for i, x := range data {
+		deviations[i] = math.Abs(x - median)
+		meanDeviation += deviations[i] / float64(len(data))
+	}
+
+	// Scale factors making both deviations consistent with the standard deviation of a normal distribution.
+	scale := Quantiles(deviations, 0.5)[0] / 0.6745
+	if scale == 0 {
+		scale = meanDeviation * 1.253314
+	}
+	if scale == 0 {
+		return outliers
+	}
+
+	for i, d := range deviations {
+		outliers[i] = d/scale > threshold
+	}
+	return outliers
+}
+
+// LongestRunBelow returns the length of the longest run of consecutive points of data below threshold.
+func LongestRunBelow(data []float64, threshold float64) int {
+	longest, run := 0, 0
+	for _, x := range data {
+		if x < threshold {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// RunsBelow returns the runs of consecutive values of data below threshold as half-open [start, end) index ranges,
+// oldest first. The end of a run that lasts until the last value is len(data).
+func RunsBelow(data []float64, threshold float64) [][2]int {
+	var runs [][2]int
+	start := -1
+	for i, x := range data {
+		switch {
+		case x < threshold && start < 0:
+			start = i
+		case x >= threshold && start >= 0:
+			runs = append(runs, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		runs = append(runs, [2]int{start, len(data)})
+	}
+	return runs
+}
+
+// ConsumedBudget returns the budget consumed over data, the sum of its drops. Rises, when old errors leave the
+// rolling window of the SLO, do not give the consumed budget back.
+func ConsumedBudget(data []float64) float64 {
+	consumed := 0.0
+	for i := 1; i < len(data); i++ {
+		consumed += max(data[i-1]-data[i], 0)
+	}
+	return consumed
+}
+
+// StdDev returns the population standard deviation of data, 0 when it is empty.
+func StdDev(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	_, avg := GetMinAvgErrorBudget(data)
+	variance := 0.0
+	for _, x := range data {
+		variance += (x - avg) * (x - avg)
+	}
+	return math.Sqrt(variance / float64(len(data)))
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+
+	"github.com/rluisr/vigil/model"
+	"github.com/rluisr/vigil/notify"
+)
+
+// webhookSecretEnv names the variable holding the secret the --webhook-url payloads are signed with.
+const webhookSecretEnv = "VIGIL_WEBHOOK_SECRET"
+
+// validateWebhookURL checks --webhook-url is an http(s) URL and the secret to sign with is set.
+func validateWebhookURL(webhook string) error {
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid --webhook-url %s: use an http(s) URL", webhook)
+	}
+	if os.Getenv(webhookSecretEnv) == "" {
+		return fmt.Errorf("--webhook-url needs %s, the secret the payloads are signed with", webhookSecretEnv)
+	}
+	return nil
+}
+
+// sendWebhook posts the JSON report of the run to --webhook-url, signed with VIGIL_WEBHOOK_SECRET in the
+// X-Vigil-Signature-256 header.
+func sendWebhook(ctx context.Context, webhook string, data map[string]*model.SLOData) {
+	body, err := json.Marshal(newJSONReport(data))
+	if err != nil {
+		log.Panicf("Failed to encode the webhook payload: %v", err)
+	}
+	if err := notify.NewWebhook(webhook, os.Getenv(webhookSecretEnv)).Post(ctx, body); err != nil {
+		log.Panicf("Failed to post the results to %s: %v", redactURL(webhook), err)
+	}
+	infof("Posted the results of %d SLOs to %s", len(data), redactURL(webhook))
+}
+
+// redactURL hides the query and user info of a URL, where webhook endpoints often carry tokens.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		u.RawQuery = "..."
+	}
+	return u.String()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// windowsFlag is --window: one or more comma separated durations. The first one is the window every analysis runs
+// over. The others are only summarized in per-window columns, telling a recent problem from a chronic one without
+// separate runs.
+type windowsFlag struct {
+	primary *time.Duration
+	extra   *[]time.Duration
+}
+
+func (w windowsFlag) String() string {
+	if w.primary == nil {
+		return ""
+	}
+	s := []string{w.primary.String()}
+	for _, d := range *w.extra {
+		s = append(s, d.String())
+	}
+	return strings.Join(s, ",")
+}
+
+func (w windowsFlag) Set(v string) error {
+	var ds []time.Duration
+	for _, s := range strings.Split(v, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("%s is not a positive duration", s)
+		}
+		if slices.Contains(ds, d) {
+			return fmt.Errorf("%s is given twice", s)
+		}
+		ds = append(ds, d)
+	}
+	*w.primary = ds[0]
+	*w.extra = ds[1:]
+	return nil
+}
+
+// windows returns every --window, the primary one first.
+func windows() []time.Duration {
+	return append([]time.Duration{*window}, extraWindows...)
+}